@@ -0,0 +1,175 @@
+package archive
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Archive - on-disk copy of everything fetched from the network so engines can
+// be rebuilt by replaying it instead of hitting PDSes and the relay again
+type Archive struct {
+	root string
+}
+
+// RepoRef - a repo revision, the handle it resolved to when fetched and
+// the archived CAR it was fetched as
+type RepoRef struct {
+	DID    string
+	Rev    string
+	Handle string
+	Digest string
+	Path   string
+}
+
+// didEscaper - DIDs may contain ':' and '%' neither of which are portable in file names
+var didEscaper = strings.NewReplacer("%", "%25", ":", "%3A", "/", "%2F")
+
+func Open(root string) (*Archive, error) {
+	if root == "" {
+		return nil, errors.New("missing archive directory")
+	}
+	for _, dir := range []string{CARS_DIR, REPOS_DIR, FIREHOSE_DIR} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &Archive{root: root}, nil
+}
+
+func (a *Archive) Root() string {
+	return a.root
+}
+
+// PutRepo - store car once by its sha256 and record it as did's repo at rev
+// along with the handle did resolved to so replays don't need the network
+func (a *Archive) PutRepo(did, rev, handle string, car []byte) (RepoRef, error) {
+	if did == "" || rev == "" {
+		return RepoRef{}, fmt.Errorf("error archiving repo: missing did or rev")
+	}
+	if filepath.Base(rev) != rev || strings.HasPrefix(rev, ".") {
+		return RepoRef{}, fmt.Errorf("error archiving repo: invalid rev %q", rev)
+	}
+	sum := sha256.Sum256(car)
+	if strings.ContainsAny(handle, "\r\n") {
+		return RepoRef{}, fmt.Errorf("error archiving repo: invalid handle %q", handle)
+	}
+	ref := RepoRef{DID: did, Rev: rev, Handle: handle, Digest: hex.EncodeToString(sum[:])}
+	ref.Path = a.carPath(ref.Digest)
+	if _, err := os.Stat(ref.Path); errors.Is(err, fs.ErrNotExist) {
+		if err = writeFile(ref.Path, car); err != nil {
+			return RepoRef{}, err
+		}
+	} else if err != nil {
+		return RepoRef{}, err
+	}
+	if err := writeFile(a.refPath(did, rev), []byte(ref.Digest+"\n"+ref.Handle+"\n")); err != nil {
+		return RepoRef{}, err
+	}
+	return ref, nil
+}
+
+// Repo - the archived CAR of did at rev, fs.ErrNotExist if it was never fetched
+func (a *Archive) Repo(did, rev string) (RepoRef, error) {
+	return a.readRef(did, rev, a.refPath(did, rev))
+}
+
+// Repos - every archived repo revision ordered by DID then rev
+func (a *Archive) Repos(fn func(RepoRef) error) error {
+	dirs, err := os.ReadDir(filepath.Join(a.root, REPOS_DIR))
+	if err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		var did string
+		if did, err = url.PathUnescape(dir.Name()); err != nil {
+			return fmt.Errorf("error reading archived repo %q: %w", dir.Name(), err)
+		}
+		var revs []os.DirEntry
+		if revs, err = os.ReadDir(filepath.Join(a.root, REPOS_DIR, dir.Name())); err != nil {
+			return err
+		}
+		for _, rev := range revs {
+			// skip in-flight writes
+			if rev.IsDir() || strings.HasPrefix(rev.Name(), ".") {
+				continue
+			}
+			var ref RepoRef
+			if ref, err = a.readRef(did, rev.Name(), filepath.Join(a.root, REPOS_DIR, dir.Name(), rev.Name())); err != nil {
+				return err
+			}
+			if err = fn(ref); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Latest - the newest archived revision of each repo. Revs are TIDs so
+// the last in lexical order is the most recent.
+func (a *Archive) Latest() ([]RepoRef, error) {
+	var latest []RepoRef
+	err := a.Repos(func(ref RepoRef) error {
+		if n := len(latest); n > 0 && latest[n-1].DID == ref.DID {
+			latest[n-1] = ref
+			return nil
+		}
+		latest = append(latest, ref)
+		return nil
+	})
+	return latest, err
+}
+
+func (a *Archive) readRef(did, rev, path string) (RepoRef, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return RepoRef{}, err
+	}
+	// refs written before handles were archived hold only the digest
+	digest, handle, _ := strings.Cut(string(data), "\n")
+	ref := RepoRef{DID: did, Rev: rev, Handle: strings.TrimSpace(handle), Digest: strings.TrimSpace(digest)}
+	if len(ref.Digest) != sha256.Size*2 {
+		return RepoRef{}, fmt.Errorf("error reading archived repo %s@%s: invalid digest %q", did, rev, ref.Digest)
+	}
+	ref.Path = a.carPath(ref.Digest)
+	return ref, nil
+}
+
+func (a *Archive) carPath(digest string) string {
+	return filepath.Join(a.root, CARS_DIR, digest[:2], digest+CAR_EXTENSION)
+}
+
+func (a *Archive) refPath(did, rev string) string {
+	return filepath.Join(a.root, REPOS_DIR, didEscaper.Replace(did), rev)
+}
+
+// writeFile - write data to a temp file beside path then rename it into
+// place so readers never see a partial file
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
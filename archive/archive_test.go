@@ -0,0 +1,197 @@
+package archive
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchive(t *testing.T) {
+	t.Run("repos are stored once by content", putRepoTest)
+	t.Run("latest revision of each repo", latestTest)
+	t.Run("invalid revs are rejected", invalidRevTest)
+	t.Run("firehose segments roll over and replay in order", firehoseReplayTest)
+	t.Run("replay skips frames up to since", firehoseSinceTest)
+	t.Run("refs without a handle still read", legacyRefTest)
+	t.Run("truncated frame ends its segment", firehoseTruncatedTest)
+	t.Run("reopened segment drops its truncated frame", firehoseReopenTest)
+	t.Run("oversized frame length is corrupt", firehoseCorruptTest)
+}
+
+func archiveTest(t *testing.T) *Archive {
+	a, err := Open(t.TempDir())
+	require.Nil(t, err)
+	return a
+}
+
+func putRepoTest(t *testing.T) {
+	a := archiveTest(t)
+	first, err := a.PutRepo("did:plc:alice", "3kaaa", "alice.test", []byte("car"))
+	require.Nil(t, err)
+	data, err := os.ReadFile(first.Path)
+	require.Nil(t, err)
+	assert.Equal(t, "car", string(data))
+	assert.Equal(t, ".car", filepath.Ext(first.Path))
+
+	// identical content at another rev shares the CAR
+	second, err := a.PutRepo("did:plc:alice", "3kbbb", "alice.test", []byte("car"))
+	require.Nil(t, err)
+	assert.Equal(t, first.Digest, second.Digest)
+	assert.Equal(t, first.Path, second.Path)
+
+	ref, err := a.Repo("did:plc:alice", "3kaaa")
+	require.Nil(t, err)
+	assert.Equal(t, first, ref)
+	assert.Equal(t, "alice.test", ref.Handle)
+
+	_, err = a.Repo("did:plc:alice", "3kzzz")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+}
+
+func latestTest(t *testing.T) {
+	a := archiveTest(t)
+	for _, put := range []struct{ did, rev, car string }{
+		{"did:plc:bob", "3kbbb", "bob-2"},
+		{"did:plc:alice", "3kaaa", "alice-1"},
+		{"did:plc:bob", "3kaaa", "bob-1"},
+		{"did:web:example.com", "3kccc", "web-1"},
+	} {
+		_, err := a.PutRepo(put.did, put.rev, "", []byte(put.car))
+		require.Nil(t, err)
+	}
+	latest, err := a.Latest()
+	require.Nil(t, err)
+	require.Len(t, latest, 3)
+	var got []string
+	for _, ref := range latest {
+		got = append(got, ref.DID+"@"+ref.Rev)
+	}
+	assert.Equal(t, []string{"did:plc:alice@3kaaa", "did:plc:bob@3kbbb", "did:web:example.com@3kccc"}, got)
+}
+
+func invalidRevTest(t *testing.T) {
+	a := archiveTest(t)
+	for _, rev := range []string{"", "../3kaaa", ".hidden"} {
+		_, err := a.PutRepo("did:plc:alice", rev, "alice.test", []byte("car"))
+		assert.Error(t, err, rev)
+	}
+}
+
+func legacyRefTest(t *testing.T) {
+	a := archiveTest(t)
+	put, err := a.PutRepo("did:plc:alice", "3kaaa", "alice.test", []byte("car"))
+	require.Nil(t, err)
+	require.Nil(t, os.WriteFile(a.refPath("did:plc:alice", "3kaaa"), []byte(put.Digest+"\n"), 0o644))
+	ref, err := a.Repo("did:plc:alice", "3kaaa")
+	require.Nil(t, err)
+	assert.Equal(t, put.Digest, ref.Digest)
+	assert.Empty(t, ref.Handle)
+}
+
+func firehoseReplayTest(t *testing.T) {
+	a := archiveTest(t)
+	log := a.FirehoseLog(10)
+	for seq := int64(1); seq <= 5; seq++ {
+		require.Nil(t, log.Append(seq, []byte("frame-"+string(rune('0'+seq)))))
+	}
+	require.Nil(t, log.Close())
+	segments, err := a.segments()
+	require.Nil(t, err)
+	assert.Greater(t, len(segments), 1)
+
+	seqs, frames := replayTest(t, a, 0)
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, seqs)
+	assert.Equal(t, "frame-1", frames[0])
+	assert.Equal(t, "frame-5", frames[4])
+}
+
+func firehoseSinceTest(t *testing.T) {
+	a := archiveTest(t)
+	log := a.FirehoseLog(10)
+	for seq := int64(10); seq <= 15; seq++ {
+		require.Nil(t, log.Append(seq, []byte("frame")))
+	}
+	require.Nil(t, log.Close())
+	seqs, _ := replayTest(t, a, 12)
+	assert.Equal(t, []int64{13, 14, 15}, seqs)
+}
+
+func firehoseTruncatedTest(t *testing.T) {
+	a := archiveTest(t)
+	log := a.FirehoseLog(DEFAULT_SEGMENT_SIZE)
+	require.Nil(t, log.Append(1, []byte("complete")))
+	require.Nil(t, log.Append(2, []byte("cut short")))
+	require.Nil(t, log.Close())
+	segments, err := a.segments()
+	require.Nil(t, err)
+	require.Len(t, segments, 1)
+	info, err := os.Stat(segments[0].path)
+	require.Nil(t, err)
+	require.Nil(t, os.Truncate(segments[0].path, info.Size()-3))
+
+	seqs, _ := replayTest(t, a, 0)
+	assert.Equal(t, []int64{1}, seqs)
+}
+
+func firehoseReopenTest(t *testing.T) {
+	a := archiveTest(t)
+	log := a.FirehoseLog(DEFAULT_SEGMENT_SIZE)
+	require.Nil(t, log.Append(1, []byte("complete")))
+	require.Nil(t, log.Append(2, []byte("cut short")))
+	require.Nil(t, log.Close())
+	segments, err := a.segments()
+	require.Nil(t, err)
+	info, err := os.Stat(segments[0].path)
+	require.Nil(t, err)
+	require.Nil(t, os.Truncate(segments[0].path, info.Size()-3))
+
+	// a restart resuming at the segment's first seq appends after frame 1
+	log = a.FirehoseLog(DEFAULT_SEGMENT_SIZE)
+	require.Nil(t, log.Append(1, []byte("again")))
+	require.Nil(t, log.Append(2, []byte("resumed")))
+	require.Nil(t, log.Close())
+	seqs, frames := replayTest(t, a, 0)
+	assert.Equal(t, []int64{1, 1, 2}, seqs)
+	assert.Equal(t, []string{"complete", "again", "resumed"}, frames)
+}
+
+func firehoseCorruptTest(t *testing.T) {
+	a := archiveTest(t)
+	log := a.FirehoseLog(DEFAULT_SEGMENT_SIZE)
+	require.Nil(t, log.Append(1, []byte("complete")))
+	require.Nil(t, log.Close())
+	segments, err := a.segments()
+	require.Nil(t, err)
+	f, err := os.OpenFile(segments[0].path, os.O_WRONLY|os.O_APPEND, 0o644)
+	require.Nil(t, err)
+	record := binary.AppendUvarint(nil, 2)
+	record = binary.AppendUvarint(record, MAX_FRAME_SIZE+1)
+	_, err = f.Write(record)
+	require.Nil(t, err)
+	require.Nil(t, f.Close())
+
+	err = a.ReplayFirehose(context.Background(), 0, func(seq int64, frame []byte) error {
+		return nil
+	})
+	assert.ErrorContains(t, err, "corrupt firehose record")
+	assert.Error(t, a.FirehoseLog(DEFAULT_SEGMENT_SIZE).Append(1, []byte("frame")))
+	assert.Error(t, log.Append(3, make([]byte, MAX_FRAME_SIZE+1)))
+}
+
+func replayTest(t *testing.T, a *Archive, since int64) ([]int64, []string) {
+	var seqs []int64
+	var frames []string
+	require.Nil(t, a.ReplayFirehose(context.Background(), since, func(seq int64, frame []byte) error {
+		seqs = append(seqs, seq)
+		frames = append(frames, string(frame))
+		return nil
+	}))
+	return seqs, frames
+}
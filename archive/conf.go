@@ -0,0 +1,48 @@
+package archive
+
+import (
+	"github.com/mikeblum/atgraph.dev/conf"
+)
+
+type Conf struct {
+	conf.EnvConf
+	cfg *Config
+}
+
+// NewConf - settings decoded once, invalid ones are left at their defaults
+// and reported in the returned error alongside the still usable Conf
+func NewConf() (*Conf, error) {
+	c := &Conf{EnvConf: conf.NewEnvConf()}
+	var err error
+	c.cfg, err = c.decode()
+	return c, err
+}
+
+// Config - typed archive settings, an empty Dir disables archiving
+type Config struct {
+	Dir         string `env:"ATGRAPH_ARCHIVE_DIR"`
+	SegmentSize int64  `env:"ATGRAPH_ARCHIVE_SEGMENT_SIZE" min:"1"`
+}
+
+// decode - defaults overridden by whatever conf.Decode can parse
+func (c *Conf) decode() (*Config, error) {
+	cfg := &Config{
+		SegmentSize: DEFAULT_SEGMENT_SIZE,
+	}
+	return cfg, conf.Decode(c.EnvConf, cfg)
+}
+
+// Config - the settings decoded by NewConf
+func (c *Conf) Config() *Config {
+	return c.cfg
+}
+
+// Dir - archive root, empty disables archiving
+func (c *Conf) Dir() string {
+	return c.cfg.Dir
+}
+
+// SegmentSize - bytes written to a firehose segment before the next one is started
+func (c *Conf) SegmentSize() int64 {
+	return c.cfg.SegmentSize
+}
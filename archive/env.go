@@ -0,0 +1,24 @@
+package archive
+
+const (
+	ENV_ATGRAPH_ARCHIVE_DIR          = "ATGRAPH_ARCHIVE_DIR"
+	ENV_ATGRAPH_ARCHIVE_SEGMENT_SIZE = "ATGRAPH_ARCHIVE_SEGMENT_SIZE"
+
+	// layout beneath the archive root
+	// cars/<sha256[:2]>/<sha256>.car - fetched repo CARs stored once by content
+	CARS_DIR = "cars"
+	// repos/<did>/<rev> - the sha256 of the CAR the repo was fetched as at rev
+	// followed by the handle it resolved to
+	REPOS_DIR = "repos"
+	// firehose/<first seq>.log - segments of length-prefixed firehose frames
+	FIREHOSE_DIR = "firehose"
+
+	CAR_EXTENSION     = ".car"
+	SEGMENT_EXTENSION = ".log"
+
+	// defaults
+	// segments roll over once they reach this many bytes
+	DEFAULT_SEGMENT_SIZE = 64 << 20
+	// larger frame lengths are treated as a corrupt record rather than allocated
+	MAX_FRAME_SIZE = 16 << 20
+)
@@ -0,0 +1,273 @@
+package archive
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// segment names are the zero padded seq of their first frame so they sort in order
+const SEGMENT_NAME_FORMAT = "%020d" + SEGMENT_EXTENSION
+
+// FirehoseLog - append-only log of raw firehose frames split into segments of
+// roughly segmentSize bytes. Each record is uvarint(seq), uvarint(len), frame.
+type FirehoseLog struct {
+	dir         string
+	segmentSize int64
+	mu          sync.Mutex
+	f           *os.File
+	w           *bufio.Writer
+	written     int64
+}
+
+// FirehoseLog - a new segment is started with the first frame appended
+func (a *Archive) FirehoseLog(segmentSize int64) *FirehoseLog {
+	if segmentSize < 1 {
+		segmentSize = DEFAULT_SEGMENT_SIZE
+	}
+	return &FirehoseLog{
+		dir:         filepath.Join(a.root, FIREHOSE_DIR),
+		segmentSize: segmentSize,
+	}
+}
+
+// Append - write frame at seq, rolling over to a new segment once the current one is full
+func (l *FirehoseLog) Append(seq int64, frame []byte) error {
+	if seq < 0 {
+		return fmt.Errorf("error archiving firehose frame: invalid seq %d", seq)
+	}
+	if len(frame) > MAX_FRAME_SIZE {
+		return fmt.Errorf("error archiving firehose frame %d: %d bytes exceeds %d", seq, len(frame), MAX_FRAME_SIZE)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil || l.written >= l.segmentSize {
+		if err := l.roll(seq); err != nil {
+			return err
+		}
+	}
+	var header [2 * binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(header[:], uint64(seq))
+	n += binary.PutUvarint(header[n:], uint64(len(frame)))
+	if _, err := l.w.Write(header[:n]); err != nil {
+		return err
+	}
+	if _, err := l.w.Write(frame); err != nil {
+		return err
+	}
+	l.written += int64(n + len(frame))
+	return nil
+}
+
+// Flush - write buffered frames through to the current segment
+func (l *FirehoseLog) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.w == nil {
+		return nil
+	}
+	return l.w.Flush()
+}
+
+func (l *FirehoseLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.close()
+}
+
+// roll - close the current segment and start one named after seq. A restart
+// that resumes at the same seq appends to the existing segment once any
+// record a crash cut short has been truncated away.
+func (l *FirehoseLog) roll(seq int64) error {
+	if err := l.close(); err != nil {
+		return err
+	}
+	path := filepath.Join(l.dir, fmt.Sprintf(SEGMENT_NAME_FORMAT, seq))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	var size int64
+	if size, err = completeSize(f); err != nil {
+		f.Close()
+		return fmt.Errorf("error reopening firehose segment %s: %w", path, err)
+	}
+	if err = f.Truncate(size); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err = f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return err
+	}
+	l.f = f
+	l.w = bufio.NewWriter(f)
+	l.written = size
+	return nil
+}
+
+// completeSize - bytes of f up to the end of its last complete record
+func completeSize(f *os.File) (int64, error) {
+	r := &countingReader{r: bufio.NewReader(f)}
+	var size int64
+	for {
+		if _, _, err := readRecord(r); err != nil {
+			if errors.Is(err, errTruncated) {
+				return size, nil
+			}
+			return 0, err
+		}
+		size = r.n
+	}
+}
+
+func (l *FirehoseLog) close() error {
+	if l.f == nil {
+		return nil
+	}
+	err := errors.Join(l.w.Flush(), l.f.Close())
+	l.f, l.w, l.written = nil, nil, 0
+	return err
+}
+
+// ReplayFirehose - call fn with every archived frame after since in seq order.
+// A record cut short by a crash ends its segment rather than the replay.
+func (a *Archive) ReplayFirehose(ctx context.Context, since int64, fn func(seq int64, frame []byte) error) error {
+	segments, err := a.segments()
+	if err != nil {
+		return err
+	}
+	for i, segment := range segments {
+		// every frame in this segment precedes the next segment's first
+		if i+1 < len(segments) && segments[i+1].first <= since+1 {
+			continue
+		}
+		if err = replaySegment(ctx, segment.path, since, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type segment struct {
+	first int64
+	path  string
+}
+
+func (a *Archive) segments() ([]segment, error) {
+	dir := filepath.Join(a.root, FIREHOSE_DIR)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var segments []segment
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, SEGMENT_EXTENSION) {
+			continue
+		}
+		var first int64
+		if first, err = strconv.ParseInt(strings.TrimSuffix(name, SEGMENT_EXTENSION), 10, 64); err != nil {
+			continue
+		}
+		segments = append(segments, segment{first: first, path: filepath.Join(dir, name)})
+	}
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].first < segments[j].first
+	})
+	return segments, nil
+}
+
+// errTruncated - the segment ends part way through a record
+var errTruncated = errors.New("truncated firehose record")
+
+func replaySegment(ctx context.Context, path string, since int64, fn func(int64, []byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		var seq int64
+		var frame []byte
+		if seq, frame, err = readRecord(r); err != nil {
+			if errors.Is(err, errTruncated) {
+				return nil
+			}
+			return fmt.Errorf("error replaying firehose segment %s: %w", path, err)
+		}
+		if seq <= since {
+			continue
+		}
+		if err = fn(seq, frame); err != nil {
+			return err
+		}
+	}
+}
+
+// readRecord - the next uvarint(seq), uvarint(len), frame record. A clean
+// or partial end of r is errTruncated and a length over MAX_FRAME_SIZE is corrupt.
+func readRecord(r recordReader) (int64, []byte, error) {
+	seq, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, nil, truncated(err)
+	}
+	var size uint64
+	if size, err = binary.ReadUvarint(r); err != nil {
+		return 0, nil, truncated(err)
+	}
+	if size > MAX_FRAME_SIZE || seq > math.MaxInt64 {
+		return 0, nil, fmt.Errorf("corrupt firehose record: seq %d with %d byte frame", seq, size)
+	}
+	frame := make([]byte, size)
+	if _, err = io.ReadFull(r, frame); err != nil {
+		return 0, nil, truncated(err)
+	}
+	return int64(seq), frame, nil
+}
+
+type recordReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+func truncated(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return errTruncated
+	}
+	return err
+}
+
+// countingReader - tracks the bytes read so far
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
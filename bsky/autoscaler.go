@@ -0,0 +1,119 @@
+package bsky
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// queue utilisation above which a worker is added
+	DEFAULT_SCALE_UP_RATIO = 0.75
+	// queue utilisation below which a worker is removed
+	DEFAULT_SCALE_DOWN_RATIO = 0.1
+)
+
+// ScalePolicy - bounds and thresholds for a single class of workers
+type ScalePolicy struct {
+	Min            int
+	Max            int
+	ScaleUpRatio   float64
+	ScaleDownRatio float64
+	// minimum rate limit headroom required to keep / add workers (0 disables the check)
+	MinHeadroom float64
+}
+
+func NewScalePolicy(minWorkers, maxWorkers int, minHeadroom float64) ScalePolicy {
+	return ScalePolicy{
+		Min:            minWorkers,
+		Max:            maxWorkers,
+		ScaleUpRatio:   DEFAULT_SCALE_UP_RATIO,
+		ScaleDownRatio: DEFAULT_SCALE_DOWN_RATIO,
+		MinHeadroom:    minHeadroom,
+	}
+}
+
+// Desired - worker count to converge on given the queue depth and rate limit headroom
+// workers are added / removed one at a time to avoid thrashing between ticks
+func (s ScalePolicy) Desired(current, queued, capacity int, headroom float64) int {
+	var utilisation float64
+	if capacity > 0 {
+		utilisation = float64(queued) / float64(capacity)
+	}
+
+	desired := current
+	switch {
+	case s.MinHeadroom > 0 && headroom < s.MinHeadroom:
+		// back off before the rate limiter starts returning 429s
+		desired--
+	case utilisation >= s.ScaleUpRatio:
+		desired++
+	case utilisation <= s.ScaleDownRatio:
+		desired--
+	}
+
+	return max(s.Min, min(desired, s.Max))
+}
+
+// workerSet - dynamically sized set of workers of a single type
+type workerSet struct {
+	kind   string
+	policy ScalePolicy
+	run    func(ctx context.Context, workerID int, stop <-chan struct{}) error
+	mu     sync.Mutex
+	stops  []chan struct{}
+	nextID int
+}
+
+func newWorkerSet(kind string, policy ScalePolicy, run func(context.Context, int, <-chan struct{}) error) *workerSet {
+	return &workerSet{
+		kind:   kind,
+		policy: policy,
+		run:    run,
+	}
+}
+
+func (w *workerSet) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.stops)
+}
+
+// add - start a new worker in the pool's errgroup
+func (w *workerSet) add(ctx context.Context, g *errgroup.Group) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.nextID++
+	workerID := w.nextID
+	stop := make(chan struct{})
+	w.stops = append(w.stops, stop)
+	g.Go(func() error {
+		return w.run(ctx, workerID, stop)
+	})
+}
+
+// remove - signal the most recently started worker to exit after its current job
+func (w *workerSet) remove() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.stops) == 0 {
+		return false
+	}
+	last := len(w.stops) - 1
+	close(w.stops[last])
+	w.stops = w.stops[:last]
+	return true
+}
+
+// scaleTo - add / remove workers until the set matches n
+func (w *workerSet) scaleTo(ctx context.Context, g *errgroup.Group, n int) {
+	for w.Size() < n {
+		w.add(ctx, g)
+	}
+	for w.Size() > n {
+		if !w.remove() {
+			return
+		}
+	}
+}
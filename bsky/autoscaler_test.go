@@ -0,0 +1,73 @@
+package bsky
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestAutoscaler(t *testing.T) {
+	t.Run("scale up when queue is saturated", scaleUpTest)
+	t.Run("scale down when queue is idle", scaleDownTest)
+	t.Run("hold steady between thresholds", scaleHoldTest)
+	t.Run("respect min / max bounds", scaleBoundsTest)
+	t.Run("back off when rate limit headroom is low", scaleHeadroomTest)
+	t.Run("worker set grows and shrinks", workerSetScaleTest)
+	t.Run("headroom defaults to 1 before any response", headroomDefaultTest)
+}
+
+func scaleUpTest(t *testing.T) {
+	policy := NewScalePolicy(1, 10, DEFAULT_RATE_LIMIT_MIN_HEADROOM)
+	assert.Equal(t, 6, policy.Desired(5, 10, 10, 1))
+}
+
+func scaleDownTest(t *testing.T) {
+	policy := NewScalePolicy(1, 10, DEFAULT_RATE_LIMIT_MIN_HEADROOM)
+	assert.Equal(t, 4, policy.Desired(5, 0, 10, 1))
+}
+
+func scaleHoldTest(t *testing.T) {
+	policy := NewScalePolicy(1, 10, DEFAULT_RATE_LIMIT_MIN_HEADROOM)
+	assert.Equal(t, 5, policy.Desired(5, 5, 10, 1))
+}
+
+func scaleBoundsTest(t *testing.T) {
+	policy := NewScalePolicy(2, 5, 0)
+	assert.Equal(t, 5, policy.Desired(5, 10, 10, 1))
+	assert.Equal(t, 2, policy.Desired(2, 0, 10, 1))
+	// out of bounds counts are clamped back in
+	assert.Equal(t, 5, policy.Desired(8, 5, 10, 1))
+	assert.Equal(t, 2, policy.Desired(0, 5, 10, 1))
+}
+
+func scaleHeadroomTest(t *testing.T) {
+	policy := NewScalePolicy(1, 10, 0.2)
+	// saturated queue but nearly out of budget
+	assert.Equal(t, 4, policy.Desired(5, 10, 10, 0.1))
+	// headroom check disabled for ingest workers
+	ingest := NewScalePolicy(1, 10, 0)
+	assert.Equal(t, 6, ingest.Desired(5, 10, 10, 0))
+}
+
+func workerSetScaleTest(t *testing.T) {
+	g, ctx := errgroup.WithContext(context.Background())
+	workers := newWorkerSet(WorkerTypeRepo, NewScalePolicy(1, 10, 0), func(ctx context.Context, workerID int, stop <-chan struct{}) error {
+		<-stop
+		return nil
+	})
+	workers.scaleTo(ctx, g, 4)
+	assert.Equal(t, 4, workers.Size())
+	workers.scaleTo(ctx, g, 1)
+	assert.Equal(t, 1, workers.Size())
+	workers.scaleTo(ctx, g, 0)
+	assert.Equal(t, 0, workers.Size())
+	assert.Nil(t, g.Wait())
+	assert.False(t, workers.remove())
+}
+
+func headroomDefaultTest(t *testing.T) {
+	state := &RateLimitState{}
+	assert.Equal(t, float64(1), state.Headroom())
+}
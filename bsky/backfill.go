@@ -2,6 +2,8 @@ package bsky
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/bluesky-social/indigo/api/atproto"
 	"golang.org/x/sync/errgroup"
@@ -10,46 +12,67 @@ import (
 func (c *Client) BackfillRepos(ctx context.Context, pool *WorkerPool) error {
 	g, ctx := errgroup.WithContext(ctx)
 
-	// Process results
+	// Process results - one per repo once all of its items are ingested
+	results := pool.Results()
 	g.Go(func() error {
-		for {
-			select {
-			case <-ctx.Done():
-				c.log.WithError(ctx.Err()).Error("Context done - exiting...")
-				return ctx.Err()
-			case err, ok := <-pool.results:
-				if !ok {
-					// results closed out
-					return nil
-				}
-				if err != nil {
-					c.log.WithError(err).Error("Error processing results - exiting...")
-				}
-				pool.metrics.jobsInflight.Add(ctx, -1)
-				pool.jobsInflight.Add(-1)
-			}
-		}
+		return c.drainResults(ctx, results)
 	})
 
+	// Submit repos - one goroutine per page so listing isn't blocked on the jobs queue
+	submit, submitCtx := errgroup.WithContext(ctx)
+
+	listErr := c.listAllRepos(submitCtx, pool, submit)
+
+	// every repo has been submitted - let the pool drain. The pool is closed
+	// even when listing failed so Done / Wait resolve for repos already submitted.
+	submitErr := submit.Wait()
+	pool.Close()
+
+	return errors.Join(listErr, submitErr, g.Wait())
+}
+
+// listAllRepos - page through the relay's repos submitting each to pool
+func (c *Client) listAllRepos(ctx context.Context, pool *WorkerPool, submit *errgroup.Group) error {
 	var cursor *string
 	page := 1
 
 	for {
-		next, err := c.listRepos(ctx, cursor, page, pool, g)
+		next, err := c.listRepos(ctx, cursor, page, pool, submit)
 		if err != nil {
 			c.log.WithError(err).Error("Error listing repo", "cursor", cursor, "page", page)
 			return err
 		}
 
 		if next == nil || *next == "" {
-			break
+			pool.Progress().ListingDone()
+			return nil
 		}
 
 		cursor = next
 		page++
 	}
+}
 
-	return g.Wait()
+// drainResults - log failed repos until the pool drains
+func (c *Client) drainResults(ctx context.Context, results <-chan RepoResult) error {
+	for {
+		select {
+		case <-ctx.Done():
+			c.log.WithError(ctx.Err()).Error("Context done - exiting...")
+			return ctx.Err()
+		case result, ok := <-results:
+			if !ok {
+				// results closed out - pool drained
+				return nil
+			}
+			if result.Err != nil {
+				c.log.WithErrorMsg(result.Err, "Error processing repo", "did", result.DID)
+			}
+			if result.Failed > 0 {
+				c.log.With("did", result.DID, "items", result.Items, "failed", result.Failed).Warn("Repo ingested with failures")
+			}
+		}
+	}
 }
 
 func (c *Client) listRepos(ctx context.Context, next *string, page int, pool *WorkerPool, g *errgroup.Group) (*string, error) {
@@ -63,31 +86,27 @@ func (c *Client) listRepos(ctx context.Context, next *string, page int, pool *Wo
 		cursor = *next
 	}
 
+	start := time.Now()
 	if repos, err = atproto.SyncListRepos(ctx, c.atproto, cursor, pageSize); err != nil {
 		if !suppressATProtoErr(err) {
 			c.log.WithErrorMsg(err, "Error fetching bsky repo", "next", next)
 		}
 		return next, err
 	}
+	pool.Progress().Listed(ctx, len(repos.Repos), time.Since(start))
 
 	c.log.With("action", "list-repos", "next", next, "page", page, "page-size", pageSize, "repos", len(repos.Repos)).Info("Fetching Bluesky repos")
 
 	g.Go(func() error {
 		for _, repo := range repos.Repos {
 			if filterRepo(repo) {
+				pool.Progress().Skipped(ctx)
 				continue
 			}
 
-			// Increment count before submitting
-			pool.jobsInflight.Add(1)
-			pool.metrics.jobsInflight.Add(ctx, 1)
-
-			if err = pool.Submit(ctx, RepoJob{
+			if err := pool.Submit(ctx, RepoJob{
 				repo: repo,
 			}); err != nil {
-				// Decrement count on submission failure
-				pool.jobsInflight.Add(-1)
-				pool.metrics.jobsInflight.Add(ctx, -1)
 				c.log.WithErrorMsg(err, "Error submitting bsky repo for ingestion", "did", repo.Did)
 			}
 		}
@@ -0,0 +1,99 @@
+package bsky
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mikeblum/atgraph.dev/archive"
+	"golang.org/x/sync/errgroup"
+)
+
+// ImportCARs - ingest repo exports (sync.getRepo dumps, account backups) from
+// a .car file or a directory tree of them through the same worker pool as
+// BackfillRepos. Nothing is fetched unless the pool has a directory.
+func (c *Client) ImportCARs(ctx context.Context, pool *WorkerPool, path string) error {
+	start := time.Now()
+	cars, err := ListCARs(path)
+	if err != nil {
+		c.log.WithErrorMsg(err, "Error listing CAR files", "path", path)
+		return err
+	}
+	pool.Progress().Listed(ctx, len(cars), time.Since(start))
+	c.log.With("action", "list-cars", "path", path, "repos", len(cars)).Info("Importing repo CAR files")
+	jobs := make([]RepoJob, 0, len(cars))
+	for _, car := range cars {
+		jobs = append(jobs, RepoJob{car: car})
+	}
+	return c.importCARs(ctx, pool, jobs)
+}
+
+// ReplayArchive - re-ingest the newest archived revision of every repo
+// with the handle it resolved to when it was fetched
+func (c *Client) ReplayArchive(ctx context.Context, pool *WorkerPool, a *archive.Archive) error {
+	start := time.Now()
+	refs, err := a.Latest()
+	if err != nil {
+		c.log.WithErrorMsg(err, "Error listing archived repos", "archive", a.Root())
+		return err
+	}
+	jobs := make([]RepoJob, 0, len(refs))
+	for _, ref := range refs {
+		jobs = append(jobs, RepoJob{car: ref.Path, handle: ref.Handle})
+	}
+	pool.Progress().Listed(ctx, len(jobs), time.Since(start))
+	c.log.With("action", "list-archive", "archive", a.Root(), "repos", len(jobs)).Info("Replaying archived repos")
+	return c.importCARs(ctx, pool, jobs)
+}
+
+func (c *Client) importCARs(ctx context.Context, pool *WorkerPool, jobs []RepoJob) error {
+	pool.Progress().ListingDone()
+
+	g, ctx := errgroup.WithContext(ctx)
+
+	// Process results - one per repo once all of its items are ingested
+	results := pool.Results()
+	g.Go(func() error {
+		return c.drainResults(ctx, results)
+	})
+
+	for _, job := range jobs {
+		if err := pool.Submit(ctx, job); err != nil {
+			c.log.WithErrorMsg(err, "Error submitting CAR for ingestion", "car", job.car)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+	}
+
+	// every CAR has been submitted - let the pool drain
+	pool.Close()
+
+	return g.Wait()
+}
+
+// ListCARs - path itself when it's a file, otherwise every .car file
+// beneath it in lexical order
+func ListCARs(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var cars []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(p), CAR_EXTENSION) {
+			cars = append(cars, p)
+		}
+		return nil
+	})
+	return cars, err
+}
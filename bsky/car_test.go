@@ -0,0 +1,278 @@
+package bsky
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	bskyItem "github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/atproto/identity"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/bluesky-social/indigo/repo"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
+	"github.com/ipld/go-car"
+	carutil "github.com/ipld/go-car/util"
+	"github.com/mikeblum/atgraph.dev/archive"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportCARs(t *testing.T) {
+	t.Run("list .car files beneath a directory", listCARsTest)
+	t.Run("import CAR without network", importCARTest)
+	t.Run("unreadable CAR fails its repo", importCARErrTest)
+	t.Run("repo is synced once its batches are written", importDeferredTest)
+	t.Run("replay the latest archived revision", replayArchiveTest)
+	t.Run("repo failing part way through its walk is not synced", fetchPartialWalkTest)
+}
+
+// carTest - write repoTest as a CARv1 rooted at its signed commit
+func carTest(t *testing.T, path string) {
+	r, root := commitTest(t)
+	f, err := os.Create(path)
+	require.Nil(t, err)
+	defer f.Close()
+	writeCARTest(t, f, r, root)
+}
+
+// writeCARTest - every block of r as a CARv1 rooted at root
+func writeCARTest(t *testing.T, w io.Writer, r *repo.Repo, root cid.Cid) {
+	ctx := context.Background()
+	require.Nil(t, car.WriteHeader(&car.CarHeader{Roots: []cid.Cid{root}, Version: 1}, w))
+	keys, err := r.Blockstore().AllKeysChan(ctx)
+	require.Nil(t, err)
+	for key := range keys {
+		block, err := r.Blockstore().Get(ctx, key)
+		require.Nil(t, err)
+		require.Nil(t, carutil.LdWrite(w, key.Bytes(), block.RawData()))
+	}
+}
+
+func listCARsTest(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, os.MkdirAll(filepath.Join(dir, "nested"), 0o755))
+	for _, name := range []string{"b.car", "a.CAR", "notes.txt", filepath.Join("nested", "c.car")} {
+		require.Nil(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+	}
+	cars, err := ListCARs(dir)
+	require.Nil(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "a.CAR"),
+		filepath.Join(dir, "b.car"),
+		filepath.Join(dir, "nested", "c.car"),
+	}, cars)
+
+	// a single file is imported as given
+	cars, err = ListCARs(filepath.Join(dir, "notes.txt"))
+	require.Nil(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "notes.txt")}, cars)
+
+	_, err = ListCARs(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func importCARTest(t *testing.T) {
+	dir := t.TempDir()
+	carTest(t, filepath.Join(dir, "did-plc-test.car"))
+
+	var mu sync.Mutex
+	var ingested []RepoItem
+	var synced []string
+	pool := workerPoolTest(t).
+		WithIngest(func(ctx context.Context, workerID int, item RepoItem) error {
+			mu.Lock()
+			defer mu.Unlock()
+			ingested = append(ingested, item)
+			return nil
+		}).
+		WithRepoSynced(func(ctx context.Context, did, rev string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			synced = append(synced, did)
+			return nil
+		})
+	summary := importTest(t, pool, dir)
+
+	assert.Equal(t, Summary{Repos: 1, Items: 1}, summary)
+	assert.Equal(t, []string{"did:plc:test"}, synced)
+	require.Len(t, ingested, 1)
+	follow := ingested[0]
+	assert.Equal(t, ITEM_GRAPH_FOLLOW, follow.NSID)
+	assert.Equal(t, syntax.DID("did:plc:test"), follow.DID)
+	assert.NotEmpty(t, follow.Rev)
+	// offline imports can't know the handle
+	require.NotNil(t, follow.Ident)
+	assert.Equal(t, syntax.HandleInvalid, follow.Ident.Handle)
+	status := pool.Status()
+	assert.Equal(t, int64(1), status.ReposListed)
+	assert.Positive(t, status.BytesDownloaded)
+}
+
+func importCARErrTest(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "broken.car"), []byte("not a car"), 0o644))
+	pool := workerPoolTest(t).WithIngest(func(ctx context.Context, workerID int, item RepoItem) error {
+		return nil
+	})
+	summary := importTest(t, pool, dir)
+	assert.Equal(t, Summary{Repos: 1, RepoFailures: 1}, summary)
+}
+
+func importDeferredTest(t *testing.T) {
+	for name, flushErr := range map[string]error{
+		"written": nil,
+		"failed":  errors.New("flush failed"),
+	} {
+		dir := t.TempDir()
+		carTest(t, filepath.Join(dir, "did-plc-test.car"))
+		flushes := make(chan func(error), 1)
+		var synced atomic.Int64
+		pool := workerPoolTest(t).
+			WithIngest(func(ctx context.Context, workerID int, item RepoItem) error {
+				// buffered by the engine, written later
+				flushes <- item.Defer()
+				return nil
+			}).
+			WithRepoSynced(func(ctx context.Context, did, rev string) error {
+				synced.Add(1)
+				return nil
+			})
+		go func() {
+			flush := <-flushes
+			// ingested but not yet written
+			assert.Equal(t, int64(0), synced.Load(), name)
+			flush(flushErr)
+		}()
+		summary := importTest(t, pool, dir)
+		if flushErr == nil {
+			assert.Equal(t, Summary{Repos: 1, Items: 1}, summary, name)
+			assert.Equal(t, int64(1), synced.Load(), name)
+			continue
+		}
+		// the repo's rows never reached the graph
+		assert.Equal(t, Summary{Repos: 1, RepoFailures: 1, Items: 1, ItemFailures: 1}, summary, name)
+		assert.Equal(t, int64(0), synced.Load(), name)
+	}
+}
+
+func replayArchiveTest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "repo.car")
+	carTest(t, path)
+	data, err := os.ReadFile(path)
+	require.Nil(t, err)
+	a, err := archive.Open(t.TempDir())
+	require.Nil(t, err)
+	_, err = a.PutRepo("did:plc:test", "3kaaa", "old.test", []byte("superseded"))
+	require.Nil(t, err)
+	_, err = a.PutRepo("did:plc:test", "3kzzz", "test.bsky.social", data)
+	require.Nil(t, err)
+
+	var synced []string
+	var handles []syntax.Handle
+	pool := workerPoolTest(t).
+		WithIngest(func(ctx context.Context, workerID int, item RepoItem) error {
+			handles = append(handles, item.Ident.Handle)
+			return nil
+		}).
+		WithRepoSynced(func(ctx context.Context, did, rev string) error {
+			synced = append(synced, did)
+			return nil
+		})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		_ = pool.Start(ctx)
+	}()
+	<-pool.PoolReady()
+	require.Nil(t, (&Client{log: pool.log}).ReplayArchive(ctx, pool, a))
+	summary, err := pool.Wait(ctx)
+	require.Nil(t, err)
+	// only the newest rev is replayed
+	assert.Equal(t, Summary{Repos: 1, Items: 1}, summary)
+	assert.Equal(t, []string{"did:plc:test"}, synced)
+	// replayed offline with the handle archived beside the CAR
+	assert.Equal(t, []syntax.Handle{"test.bsky.social"}, handles)
+}
+
+func fetchPartialWalkTest(t *testing.T) {
+	ctx := context.Background()
+	r := repo.NewRepo(ctx, "did:plc:test", blockstore.NewBlockstore(datastore.NewMapDatastore()))
+	created := "2025-01-01T00:00:00Z"
+	_, err := r.PutRecord(ctx, ITEM_GRAPH_FOLLOW.String()+"/3kaaa", &bskyItem.GraphFollow{Subject: "did:plc:subject", CreatedAt: created})
+	require.Nil(t, err)
+	// a record that doesn't decode as a follow ends the walk after the first
+	_, err = r.PutRecord(ctx, ITEM_GRAPH_FOLLOW.String()+"/3kbbb", &bskyItem.FeedLike{CreatedAt: created})
+	require.Nil(t, err)
+	root, _, err := r.Commit(ctx, func(ctx context.Context, did string, data []byte) ([]byte, error) {
+		return []byte("sig"), nil
+	})
+	require.Nil(t, err)
+	var data bytes.Buffer
+	writeCARTest(t, &data, r, root)
+
+	var fetches atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fetches.Add(1)
+		w.Header().Set("Content-Type", "application/vnd.ipld.car")
+		_, _ = w.Write(data.Bytes())
+	}))
+	defer srv.Close()
+	dir := identity.NewMockDirectory()
+	dir.Insert(identity.Identity{
+		DID:      "did:plc:test",
+		Handle:   "test.bsky.social",
+		Services: map[string]identity.Service{"atproto_pds": {Type: "AtprotoPersonalDataServer", URL: srv.URL}},
+	})
+
+	var synced atomic.Int64
+	pool := workerPoolTest(t).
+		WithDirectory(&dir).
+		WithIngest(func(ctx context.Context, workerID int, item RepoItem) error {
+			return nil
+		}).
+		WithRepoSynced(func(ctx context.Context, did, rev string) error {
+			synced.Add(1)
+			return nil
+		})
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	go func() {
+		_ = pool.Start(ctx)
+	}()
+	<-pool.PoolReady()
+	active := true
+	require.Nil(t, pool.Submit(ctx, RepoJob{repo: &atproto.SyncListRepos_Repo{Did: "did:plc:test", Active: &active}}))
+	pool.Close()
+	summary, err := pool.Wait(ctx)
+	require.Nil(t, err)
+	// the follow walked before the failure is ingested once, the repo fails
+	assert.Equal(t, Summary{Repos: 1, RepoFailures: 1, Items: 1}, summary)
+	assert.Zero(t, synced.Load())
+	assert.Equal(t, int64(1), fetches.Load())
+}
+
+// importTest - run pool over the CARs in dir and wait for it to drain
+func importTest(t *testing.T, pool *WorkerPool, dir string) Summary {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		_ = pool.Start(ctx)
+	}()
+	<-pool.PoolReady()
+	require.Nil(t, (&Client{log: pool.log}).ImportCARs(ctx, pool, dir))
+	summary, err := pool.Wait(ctx)
+	require.Nil(t, err)
+	return summary
+}
@@ -20,8 +20,11 @@ type Client struct {
 
 // bsky/api for public api client usage
 // unauthenticated
-func NewAPIClient() *Client {
-	cfg := NewConf()
+func NewAPIClient() (*Client, error) {
+	cfg, err := NewConf()
+	if err != nil {
+		return nil, err
+	}
 
 	client := &xrpc.Client{
 		Client: NewHTTPClient(),
@@ -32,13 +35,16 @@ func NewAPIClient() *Client {
 		atproto: client,
 		conf:    cfg,
 		log:     conf.NewLog(),
-	}
+	}, nil
 }
 
 // bsky/sync for server <-> server synchronization
 // requires authentication
 func NewSyncClient() (*Client, error) {
-	client := NewAPIClient()
+	client, err := NewAPIClient()
+	if err != nil {
+		return nil, err
+	}
 	return client.authenticated()
 }
 
@@ -1,54 +1,161 @@
 package bsky
 
 import (
-	"strconv"
+	"cmp"
+	"time"
 
 	"github.com/mikeblum/atgraph.dev/conf"
 )
 
 type Conf struct {
 	conf.EnvConf
+	cfg *Config
 }
 
-func NewConf() *Conf {
-	return &Conf{conf.NewEnvConf()}
+// NewConf - settings decoded once, invalid ones are left at their defaults
+// and reported in the returned error alongside the still usable Conf
+func NewConf() (*Conf, error) {
+	c := &Conf{EnvConf: conf.NewEnvConf()}
+	var err error
+	c.cfg, err = c.decode()
+	return c, err
+}
+
+// Config - typed bsky settings, zero worker counts / bounds are derived from BSKY_WORKER_COUNT
+type Config struct {
+	Host                 string        `env:"BSKY_PDS_URL"`
+	Identifier           string        `env:"BSKY_IDENTIFIER"`
+	Password             string        `env:"BSKY_PASSWORD" secret:"true"`
+	PageSize             int           `env:"BSKY_PAGE_SIZE" min:"1"`
+	WorkerCount          int           `env:"BSKY_WORKER_COUNT" min:"1"`
+	MaxRetries           int           `env:"BSKY_MAX_RETRY_COUNT" min:"0"`
+	RepoWorkerCount      int           `env:"BSKY_REPO_WORKER_COUNT" min:"1"`
+	RepoWorkerMin        int           `env:"BSKY_REPO_WORKER_MIN" min:"1"`
+	RepoWorkerMax        int           `env:"BSKY_REPO_WORKER_MAX" min:"1"`
+	IngestWorkerCount    int           `env:"BSKY_INGEST_WORKER_COUNT" min:"1"`
+	IngestWorkerMin      int           `env:"BSKY_INGEST_WORKER_MIN" min:"1"`
+	IngestWorkerMax      int           `env:"BSKY_INGEST_WORKER_MAX" min:"1"`
+	Autoscale            bool          `env:"BSKY_AUTOSCALE"`
+	AutoscaleInterval    time.Duration `env:"BSKY_AUTOSCALE_INTERVAL" min:"1ns"`
+	RateLimitMinHeadroom float64       `env:"BSKY_RATE_LIMIT_MIN_HEADROOM" min:"0" max:"1"`
+	ProgressInterval     time.Duration `env:"BSKY_PROGRESS_INTERVAL" min:"1ns"`
+	CARPath              string        `env:"BSKY_CAR_PATH"`
+	CARResolve           bool          `env:"BSKY_CAR_RESOLVE"`
+	StatusFile           string        `env:"BSKY_STATUS_FILE"`
+	ExpectedRepos        int64         `env:"BSKY_EXPECTED_REPOS" min:"0"`
+}
+
+// decode - defaults overridden by whatever conf.Decode can parse
+func (c *Conf) decode() (*Config, error) {
+	cfg := &Config{
+		Host:                 BSKY_ENTRYWAY_URL,
+		PageSize:             DEFAULT_PAGE_SIZE,
+		WorkerCount:          DEFAULT_WORKER_COUNT,
+		MaxRetries:           DEFAULT_MAX_RETRIES,
+		RepoWorkerMin:        DEFAULT_WORKER_MIN,
+		IngestWorkerMin:      DEFAULT_WORKER_MIN,
+		AutoscaleInterval:    DEFAULT_AUTOSCALE_INTERVAL,
+		RateLimitMinHeadroom: DEFAULT_RATE_LIMIT_MIN_HEADROOM,
+		ProgressInterval:     DEFAULT_PROGRESS_INTERVAL,
+		CARResolve:           DEFAULT_CAR_RESOLVE,
+	}
+	return cfg, conf.Decode(c.EnvConf, cfg)
+}
+
+// Config - the settings decoded by NewConf
+func (c *Conf) Config() *Config {
+	return c.cfg
 }
 
 func (c *Conf) host() string {
-	return c.GetEnv(BSKY_APP_VIEW_URL, BSKY_ENTRYWAY_URL)
+	return c.cfg.Host
 }
 
 func (c *Conf) identifier() string {
-	return c.GetEnv(ENV_BSKY_IDENTIFIER, "")
+	return c.cfg.Identifier
 }
 
 func (c *Conf) password() string {
-	return c.GetEnv(ENV_BSKY_PASSWORD, "")
+	return c.cfg.Password
 }
 
 func (c *Conf) PageSize() int {
-	var pageSize int
-	var err error
-	if pageSize, err = strconv.Atoi(c.GetEnv(ENV_BSKY_PAGE_SIZE, strconv.Itoa(DEFAULT_PAGE_SIZE))); err != nil {
-		pageSize = DEFAULT_PAGE_SIZE
-	}
-	return pageSize
+	return c.cfg.PageSize
 }
 
 func (c *Conf) WorkerCount() int {
-	var pageSize int
-	var err error
-	if pageSize, err = strconv.Atoi(c.GetEnv(ENV_BSKY_WORKER_COUNT, strconv.Itoa(DEFAULT_WORKER_COUNT))); err != nil {
-		pageSize = DEFAULT_WORKER_COUNT
-	}
-	return pageSize
+	return c.cfg.WorkerCount
 }
 
 func (c *Conf) MaxRetries() int {
-	var maxRetries int
-	var err error
-	if maxRetries, err = strconv.Atoi(c.GetEnv(ENV_BSKY_MAX_RETRY_COUNT, strconv.Itoa(DEFAULT_MAX_RETRIES))); err != nil {
-		maxRetries = DEFAULT_MAX_RETRIES
+	return c.cfg.MaxRetries
+}
+
+// RepoWorkerCount - initial number of CAR download workers (defaults to BSKY_WORKER_COUNT)
+func (c *Conf) RepoWorkerCount() int {
+	cfg := c.cfg
+	return cmp.Or(cfg.RepoWorkerCount, cfg.WorkerCount)
+}
+
+// IngestWorkerCount - initial number of graph write workers (defaults to BSKY_WORKER_COUNT)
+func (c *Conf) IngestWorkerCount() int {
+	cfg := c.cfg
+	return cmp.Or(cfg.IngestWorkerCount, cfg.WorkerCount)
+}
+
+func (c *Conf) RepoWorkerBounds() (int, int) {
+	cfg := c.cfg
+	return workerBounds(cfg.RepoWorkerMin, cfg.RepoWorkerMax, c.RepoWorkerCount())
+}
+
+func (c *Conf) IngestWorkerBounds() (int, int) {
+	cfg := c.cfg
+	return workerBounds(cfg.IngestWorkerMin, cfg.IngestWorkerMax, c.IngestWorkerCount())
+}
+
+func (c *Conf) Autoscale() bool {
+	return c.cfg.Autoscale
+}
+
+func (c *Conf) AutoscaleInterval() time.Duration {
+	return c.cfg.AutoscaleInterval
+}
+
+func (c *Conf) RateLimitMinHeadroom() float64 {
+	return c.cfg.RateLimitMinHeadroom
+}
+
+func (c *Conf) ProgressInterval() time.Duration {
+	return c.cfg.ProgressInterval
+}
+
+// CARPath - optional .car file or directory of them to import instead of listing the network
+func (c *Conf) CARPath() string {
+	return c.cfg.CARPath
+}
+
+// CARResolve - resolve handles of imported CAR repos over the network
+func (c *Conf) CARResolve() bool {
+	return c.cfg.CARResolve
+}
+
+// StatusFile - optional path to periodically write a JSON status snapshot to
+func (c *Conf) StatusFile() string {
+	return c.cfg.StatusFile
+}
+
+// ExpectedRepos - optional hint of the network size used to estimate the listing ETA
+func (c *Conf) ExpectedRepos() int64 {
+	return c.cfg.ExpectedRepos
+}
+
+// workerBounds resolves [min, max] so that min <= initial <= max always holds,
+// an unset max defaults to DEFAULT_WORKER_MAX
+func workerBounds(minWorkers, maxWorkers, initial int) (int, int) {
+	if maxWorkers == 0 {
+		maxWorkers = max(DEFAULT_WORKER_MAX, initial)
 	}
-	return maxRetries
+	minWorkers = min(max(minWorkers, 1), initial)
+	maxWorkers = max(maxWorkers, initial)
+	return minWorkers, maxWorkers
 }
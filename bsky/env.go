@@ -1,12 +1,28 @@
 package bsky
 
+import "time"
+
 const (
-	ENV_BSKY_IDENTIFIER      = "BSKY_IDENTIFIER"
-	ENV_BSKY_MAX_RETRY_COUNT = "BSKY_MAX_RETRY_COUNT"
-	ENV_BSKY_PASSWORD        = "BSKY_PASSWORD"
-	ENV_BSKY_PDS_URL         = "BSKY_PDS_URL"
-	ENV_BSKY_PAGE_SIZE       = "BSKY_PAGE_SIZE"
-	ENV_BSKY_WORKER_COUNT    = "BSKY_WORKER_COUNT"
+	ENV_BSKY_AUTOSCALE               = "BSKY_AUTOSCALE"
+	ENV_BSKY_AUTOSCALE_INTERVAL      = "BSKY_AUTOSCALE_INTERVAL"
+	ENV_BSKY_CAR_PATH                = "BSKY_CAR_PATH"
+	ENV_BSKY_CAR_RESOLVE             = "BSKY_CAR_RESOLVE"
+	ENV_BSKY_EXPECTED_REPOS          = "BSKY_EXPECTED_REPOS"
+	ENV_BSKY_IDENTIFIER              = "BSKY_IDENTIFIER"
+	ENV_BSKY_INGEST_WORKER_COUNT     = "BSKY_INGEST_WORKER_COUNT"
+	ENV_BSKY_INGEST_WORKER_MAX       = "BSKY_INGEST_WORKER_MAX"
+	ENV_BSKY_INGEST_WORKER_MIN       = "BSKY_INGEST_WORKER_MIN"
+	ENV_BSKY_MAX_RETRY_COUNT         = "BSKY_MAX_RETRY_COUNT"
+	ENV_BSKY_PASSWORD                = "BSKY_PASSWORD"
+	ENV_BSKY_PDS_URL                 = "BSKY_PDS_URL"
+	ENV_BSKY_PAGE_SIZE               = "BSKY_PAGE_SIZE"
+	ENV_BSKY_PROGRESS_INTERVAL       = "BSKY_PROGRESS_INTERVAL"
+	ENV_BSKY_REPO_WORKER_COUNT       = "BSKY_REPO_WORKER_COUNT"
+	ENV_BSKY_REPO_WORKER_MAX         = "BSKY_REPO_WORKER_MAX"
+	ENV_BSKY_REPO_WORKER_MIN         = "BSKY_REPO_WORKER_MIN"
+	ENV_BSKY_WORKER_COUNT            = "BSKY_WORKER_COUNT"
+	ENV_BSKY_RATE_LIMIT_MIN_HEADROOM = "BSKY_RATE_LIMIT_MIN_HEADROOM"
+	ENV_BSKY_STATUS_FILE             = "BSKY_STATUS_FILE"
 
 	// defaults
 	// https://docs.bsky.app/docs/advanced-guides/api-directory#bluesky-services
@@ -18,6 +34,21 @@ const (
 	BSKY_RELAY_URL       = "https://bsky.network"
 	DEFAULT_PAGE_SIZE    = 1000
 	DEFAULT_WORKER_COUNT = 5
+	DEFAULT_WORKER_MIN   = 1
+	DEFAULT_WORKER_MAX   = DEFAULT_WORKER_COUNT * 4
 	DEFAULT_MAX_RETRIES  = 3
 	ITEMS_BUFFER         = 100
+
+	// autoscaler defaults
+	DEFAULT_AUTOSCALE_INTERVAL = 5 * time.Second
+	// fraction of the rate limit budget that must remain before adding repo workers
+	DEFAULT_RATE_LIMIT_MIN_HEADROOM = 0.2
+
+	// CAR import defaults
+	CAR_EXTENSION = ".car"
+	// resolve handles of imported CAR repos so profiles don't land as handle.invalid
+	DEFAULT_CAR_RESOLVE = true
+
+	// progress defaults
+	DEFAULT_PROGRESS_INTERVAL = 30 * time.Second
 )
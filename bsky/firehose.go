@@ -1,11 +1,14 @@
 package bsky
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/bluesky-social/indigo/events"
+	"github.com/mikeblum/atgraph.dev/archive"
 	"github.com/mikeblum/atgraph.dev/conf"
 
 	"github.com/bluesky-social/indigo/api/atproto"
@@ -20,7 +23,8 @@ const (
 )
 
 type Firehose struct {
-	log *conf.Log
+	log     *conf.Log
+	archive *archive.FirehoseLog
 }
 
 func NewFirehose() *Firehose {
@@ -29,11 +33,14 @@ func NewFirehose() *Firehose {
 	}
 }
 
-func (f *Firehose) Stream() error {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
-	defer cancel()
+// WithArchive - append every streamed frame to log before it's handled
+func (f *Firehose) WithArchive(log *archive.FirehoseLog) *Firehose {
+	f.archive = log
+	return f
+}
 
-	rsc := &events.RepoStreamCallbacks{
+func (f *Firehose) callbacks() *events.RepoStreamCallbacks {
+	return &events.RepoStreamCallbacks{
 		RepoCommit: func(evt *atproto.SyncSubscribeRepos_Commit) error {
 			f.log.Info("Event", "repo", evt.Repo)
 			for _, op := range evt.Ops {
@@ -42,6 +49,33 @@ func (f *Firehose) Stream() error {
 			return nil
 		},
 	}
+}
+
+// handler - archive the frame, if configured, ahead of the callbacks
+func (f *Firehose) handler() func(context.Context, *events.XRPCStreamEvent) error {
+	rsc := f.callbacks()
+	if f.archive == nil {
+		return rsc.EventHandler
+	}
+	return func(ctx context.Context, xev *events.XRPCStreamEvent) error {
+		// frames without a seq (#info, errors) aren't replayable
+		if seq, ok := xev.GetSequence(); ok {
+			var frame bytes.Buffer
+			if err := xev.Serialize(&frame); err != nil {
+				return err
+			}
+			if err := f.archive.Append(seq, frame.Bytes()); err != nil {
+				f.log.WithErrorMsg(err, "Error archiving firehose frame", "seq", seq)
+				return err
+			}
+		}
+		return rsc.EventHandler(ctx, xev)
+	}
+}
+
+func (f *Firehose) Stream(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
 
 	conn, _, err := websocket.DefaultDialer.DialContext(ctx, BSKY_WSS_URL, http.Header{})
 	if err != nil {
@@ -49,6 +83,22 @@ func (f *Firehose) Stream() error {
 		return err
 	}
 	defer conn.Close()
-	sched := sequential.NewScheduler(SCHEDULER_IDENT, rsc.EventHandler)
+	if f.archive != nil {
+		defer f.archive.Close()
+	}
+	sched := sequential.NewScheduler(SCHEDULER_IDENT, f.handler())
 	return events.HandleRepoStream(ctx, conn, sched, f.log.Logger)
 }
+
+// Replay - feed archived frames after since through the stream callbacks
+// in place of the relay
+func (f *Firehose) Replay(ctx context.Context, a *archive.Archive, since int64) error {
+	handle := f.callbacks().EventHandler
+	return a.ReplayFirehose(ctx, since, func(seq int64, frame []byte) error {
+		var xev events.XRPCStreamEvent
+		if err := xev.Deserialize(bytes.NewReader(frame)); err != nil {
+			return fmt.Errorf("error reading archived firehose frame %d: %w", seq, err)
+		}
+		return handle(ctx, &xev)
+	})
+}
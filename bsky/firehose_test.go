@@ -0,0 +1,58 @@
+package bsky
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/events"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	"github.com/ipfs/go-cid"
+	"github.com/mikeblum/atgraph.dev/archive"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirehose(t *testing.T) {
+	t.Run("archived frames round trip", firehoseArchiveTest)
+}
+
+func firehoseArchiveTest(t *testing.T) {
+	ctx := context.Background()
+	a, err := archive.Open(t.TempDir())
+	require.Nil(t, err)
+	log := a.FirehoseLog(archive.DEFAULT_SEGMENT_SIZE)
+	handle := NewFirehose().WithArchive(log).handler()
+	commit, err := cid.Decode("bafyreie5737gdxlw5i64vzichcalba3z2v5n6icifvx5xytvske7mr3hpm")
+	require.Nil(t, err)
+	require.Nil(t, handle(ctx, &events.XRPCStreamEvent{
+		RepoCommit: &atproto.SyncSubscribeRepos_Commit{
+			Seq:    7,
+			Repo:   "did:plc:test",
+			Rev:    "3kabc",
+			Commit: lexutil.LexLink(commit),
+			Time:   "2025-01-01T00:00:00Z",
+		},
+	}))
+	// no seq - not archived
+	require.Nil(t, handle(ctx, &events.XRPCStreamEvent{
+		RepoInfo: &atproto.SyncSubscribeRepos_Info{Name: "OutdatedCursor"},
+	}))
+	require.Nil(t, log.Close())
+
+	var replayed []*events.XRPCStreamEvent
+	require.Nil(t, a.ReplayFirehose(ctx, 0, func(seq int64, frame []byte) error {
+		var xev events.XRPCStreamEvent
+		require.Nil(t, xev.Deserialize(bytes.NewReader(frame)))
+		replayed = append(replayed, &xev)
+		return nil
+	}))
+	require.Len(t, replayed, 1)
+	require.NotNil(t, replayed[0].RepoCommit)
+	assert.Equal(t, int64(7), replayed[0].RepoCommit.Seq)
+	assert.Equal(t, "did:plc:test", replayed[0].RepoCommit.Repo)
+
+	// replay through the stream callbacks
+	assert.Nil(t, NewFirehose().Replay(ctx, a, 0))
+}
@@ -0,0 +1,28 @@
+package bsky
+
+import (
+	"context"
+
+	"github.com/bluesky-social/indigo/atproto/identity"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+// ResolveDID - DID for a handle or DID, resolved through dir
+// or identity.DefaultDirectory() when dir is nil
+func ResolveDID(ctx context.Context, dir identity.Directory, identifier string) (syntax.DID, error) {
+	atid, err := syntax.ParseAtIdentifier(identifier)
+	if err != nil {
+		return "", err
+	}
+	if did, err := atid.AsDID(); err == nil {
+		return did, nil
+	}
+	if dir == nil {
+		dir = identity.DefaultDirectory()
+	}
+	ident, err := dir.Lookup(ctx, *atid)
+	if err != nil {
+		return "", err
+	}
+	return ident.DID, nil
+}
@@ -0,0 +1,35 @@
+package bsky
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bluesky-social/indigo/atproto/identity"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveDID(t *testing.T) {
+	ctx := context.Background()
+	dir := identity.NewMockDirectory()
+	dir.Insert(identity.Identity{
+		DID:    syntax.DID("did:plc:alice"),
+		Handle: syntax.Handle("alice.test"),
+	})
+
+	did, err := ResolveDID(ctx, &dir, "alice.test")
+	require.Nil(t, err)
+	assert.Equal(t, "did:plc:alice", did.String())
+
+	// DIDs pass through without a lookup
+	did, err = ResolveDID(ctx, &dir, "did:plc:bob")
+	require.Nil(t, err)
+	assert.Equal(t, "did:plc:bob", did.String())
+
+	_, err = ResolveDID(ctx, &dir, "missing.test")
+	assert.NotNil(t, err)
+
+	_, err = ResolveDID(ctx, &dir, "not an identifier")
+	assert.NotNil(t, err)
+}
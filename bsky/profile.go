@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/bluesky-social/indigo/api/atproto"
 	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/lex/util"
 )
 
 type Profile struct {
@@ -18,3 +20,77 @@ func (p Profile) String() string {
 	}
 	return sb.String()
 }
+
+// ProfileContent - app.bsky.actor.profile record content flattened for
+// storage. Optional fields are zero valued when missing from the record.
+type ProfileContent struct {
+	DisplayName          string
+	Description          string
+	Avatar               Blob
+	Banner               Blob
+	Labels               []string
+	PinnedPost           StrongRef
+	JoinedViaStarterPack StrongRef
+}
+
+// Blob - CID, mime type and size of a blob reference ex. an avatar
+type Blob struct {
+	CID      string
+	MimeType string
+	Size     int64
+}
+
+// StrongRef - AT-URI and CID of a specific version of a record
+type StrongRef struct {
+	URI string
+	CID string
+}
+
+func NewProfileContent(actor *bsky.ActorProfile) ProfileContent {
+	content := ProfileContent{
+		Avatar:               newBlob(actor.Avatar),
+		Banner:               newBlob(actor.Banner),
+		Labels:               []string{},
+		PinnedPost:           newStrongRef(actor.PinnedPost),
+		JoinedViaStarterPack: newStrongRef(actor.JoinedViaStarterPack),
+	}
+	if actor.DisplayName != nil {
+		content.DisplayName = *actor.DisplayName
+	}
+	if actor.Description != nil {
+		content.Description = *actor.Description
+	}
+	if actor.Labels != nil && actor.Labels.LabelDefs_SelfLabels != nil {
+		for _, label := range actor.Labels.LabelDefs_SelfLabels.Values {
+			if label != nil {
+				content.Labels = append(content.Labels, label.Val)
+			}
+		}
+	}
+	return content
+}
+
+func newBlob(blob *util.LexBlob) Blob {
+	if blob == nil {
+		return Blob{}
+	}
+	var ref string
+	if blob.Ref.Defined() {
+		ref = blob.Ref.String()
+	}
+	return Blob{
+		CID:      ref,
+		MimeType: blob.MimeType,
+		Size:     blob.Size,
+	}
+}
+
+func newStrongRef(ref *atproto.RepoStrongRef) StrongRef {
+	if ref == nil {
+		return StrongRef{}
+	}
+	return StrongRef{
+		URI: ref.Uri,
+		CID: ref.Cid,
+	}
+}
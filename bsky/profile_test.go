@@ -0,0 +1,56 @@
+package bsky
+
+import (
+	"testing"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/lex/util"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileContent(t *testing.T) {
+	t.Run("flattens the full profile record", profileContentTest)
+	t.Run("missing fields are zero valued", profileContentEmptyTest)
+}
+
+func profileContentTest(t *testing.T) {
+	avatar, err := cid.Decode("bafkreie5737gdxlw5i64vzichcalba3z2v5n6icifvx5xytvske7mr3hpm")
+	require.Nil(t, err)
+	displayName := "atgraph"
+	description := "graphing the atmosphere"
+	content := NewProfileContent(&bsky.ActorProfile{
+		DisplayName: &displayName,
+		Description: &description,
+		Avatar:      &util.LexBlob{Ref: util.LexLink(avatar), MimeType: "image/jpeg", Size: 1024},
+		Labels: &bsky.ActorProfile_Labels{
+			LabelDefs_SelfLabels: &atproto.LabelDefs_SelfLabels{
+				Values: []*atproto.LabelDefs_SelfLabel{{Val: "!no-unauthenticated"}, nil},
+			},
+		},
+		PinnedPost:           &atproto.RepoStrongRef{Uri: "at://did:plc:test/app.bsky.feed.post/3k", Cid: "bafypost"},
+		JoinedViaStarterPack: &atproto.RepoStrongRef{Uri: "at://did:plc:owner/app.bsky.graph.starterpack/3j", Cid: "bafypack"},
+	})
+	assert.Equal(t, displayName, content.DisplayName)
+	assert.Equal(t, description, content.Description)
+	assert.Equal(t, Blob{CID: avatar.String(), MimeType: "image/jpeg", Size: 1024}, content.Avatar)
+	assert.Equal(t, Blob{}, content.Banner)
+	assert.Equal(t, []string{"!no-unauthenticated"}, content.Labels)
+	assert.Equal(t, StrongRef{URI: "at://did:plc:test/app.bsky.feed.post/3k", CID: "bafypost"}, content.PinnedPost)
+	assert.Equal(t, StrongRef{URI: "at://did:plc:owner/app.bsky.graph.starterpack/3j", CID: "bafypack"}, content.JoinedViaStarterPack)
+}
+
+func profileContentEmptyTest(t *testing.T) {
+	content := NewProfileContent(&bsky.ActorProfile{})
+	assert.Empty(t, content.DisplayName)
+	assert.Empty(t, content.Description)
+	assert.Equal(t, Blob{}, content.Avatar)
+	assert.Equal(t, Blob{}, content.Banner)
+	// non-nil so array columns and list properties are written as empty
+	assert.NotNil(t, content.Labels)
+	assert.Empty(t, content.Labels)
+	assert.Equal(t, StrongRef{}, content.PinnedPost)
+	assert.Equal(t, StrongRef{}, content.JoinedViaStarterPack)
+}
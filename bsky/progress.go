@@ -0,0 +1,235 @@
+package bsky
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	RepoStateListed   = "listed"
+	RepoStateSkipped  = "skipped"
+	RepoStateFetched  = "fetched"
+	RepoStateIngested = "ingested"
+	RepoStateFailed   = "failed"
+)
+
+// Status - point in time snapshot of a backfill, serialized to BSKY_STATUS_FILE
+type Status struct {
+	Started         time.Time  `json:"started"`
+	Updated         time.Time  `json:"updated"`
+	Elapsed         string     `json:"elapsed"`
+	Pages           int64      `json:"pages"`
+	ListingDone     bool       `json:"listing_done"`
+	ReposListed     int64      `json:"repos_listed"`
+	ReposSkipped    int64      `json:"repos_skipped"`
+	ReposFetched    int64      `json:"repos_fetched"`
+	ReposIngested   int64      `json:"repos_ingested"`
+	ReposFailed     int64      `json:"repos_failed"`
+	Items           int64      `json:"items"`
+	ItemFailures    int64      `json:"item_failures"`
+	BytesDownloaded int64      `json:"bytes_downloaded"`
+	ExpectedRepos   int64      `json:"expected_repos,omitempty"`
+	ListRate        float64    `json:"list_rate"`
+	RepoRate        float64    `json:"repo_rate"`
+	ItemRate        float64    `json:"item_rate"`
+	ByteRate        float64    `json:"byte_rate"`
+	ETA             *time.Time `json:"eta,omitempty"`
+	ETASeconds      float64    `json:"eta_seconds,omitempty"`
+}
+
+// Progress - backfill counters shared by the lister, repo workers and ingest workers
+type Progress struct {
+	started       time.Time
+	expectedRepos int64
+	metrics       *ProgressMetrics
+	pages         atomic.Int64
+	listingNanos  atomic.Int64
+	listingDone   atomic.Bool
+	listed        atomic.Int64
+	skipped       atomic.Int64
+	fetched       atomic.Int64
+	ingested      atomic.Int64
+	failed        atomic.Int64
+	items         atomic.Int64
+	itemFailures  atomic.Int64
+	bytes         atomic.Int64
+}
+
+func NewProgress(ctx context.Context, expectedRepos int64) (*Progress, error) {
+	metrics, err := NewProgressMetrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Progress{
+		started:       time.Now().UTC(),
+		expectedRepos: expectedRepos,
+		metrics:       metrics,
+	}, nil
+}
+
+// Listed - a SyncListRepos page of n repos was fetched in elapsed
+func (p *Progress) Listed(ctx context.Context, n int, elapsed time.Duration) {
+	p.pages.Add(1)
+	p.listingNanos.Add(int64(elapsed))
+	p.listed.Add(int64(n))
+	p.metrics.repos.Add(ctx, int64(n), metric.WithAttributes(attribute.String("state", RepoStateListed)))
+	p.metrics.pageLatency.Record(ctx, elapsed.Seconds())
+}
+
+// ListingDone - the SyncListRepos cursor is exhausted
+func (p *Progress) ListingDone() {
+	p.listingDone.Store(true)
+}
+
+func (p *Progress) Skipped(ctx context.Context) {
+	p.skipped.Add(1)
+	p.metrics.repos.Add(ctx, 1, metric.WithAttributes(attribute.String("state", RepoStateSkipped)))
+}
+
+// Fetched - a repo CAR of size bytes was downloaded
+func (p *Progress) Fetched(ctx context.Context, size int) {
+	p.fetched.Add(1)
+	p.bytes.Add(int64(size))
+	p.metrics.repos.Add(ctx, 1, metric.WithAttributes(attribute.String("state", RepoStateFetched)))
+	p.metrics.bytes.Add(ctx, int64(size))
+}
+
+// ItemDone - a single record finished ingesting
+func (p *Progress) ItemDone(ctx context.Context, err error) {
+	p.items.Add(1)
+	if err != nil {
+		p.itemFailures.Add(1)
+	}
+}
+
+// ItemFailed - a record counted by ItemDone was lost when its batch failed to write
+func (p *Progress) ItemFailed(ctx context.Context, err error) {
+	p.itemFailures.Add(1)
+}
+
+// RepoDone - a repo and all of its items completed
+func (p *Progress) RepoDone(ctx context.Context, result RepoResult) {
+	state := RepoStateIngested
+	if result.Err != nil {
+		state = RepoStateFailed
+		p.failed.Add(1)
+	} else {
+		p.ingested.Add(1)
+	}
+	p.metrics.repos.Add(ctx, 1, metric.WithAttributes(attribute.String("state", state)))
+}
+
+func (p *Progress) Status() Status {
+	now := time.Now().UTC()
+	elapsed := now.Sub(p.started)
+	status := Status{
+		Started:         p.started,
+		Updated:         now,
+		Elapsed:         elapsed.Round(time.Second).String(),
+		Pages:           p.pages.Load(),
+		ListingDone:     p.listingDone.Load(),
+		ReposListed:     p.listed.Load(),
+		ReposSkipped:    p.skipped.Load(),
+		ReposFetched:    p.fetched.Load(),
+		ReposIngested:   p.ingested.Load(),
+		ReposFailed:     p.failed.Load(),
+		Items:           p.items.Load(),
+		ItemFailures:    p.itemFailures.Load(),
+		BytesDownloaded: p.bytes.Load(),
+		ExpectedRepos:   p.expectedRepos,
+	}
+	if listing := time.Duration(p.listingNanos.Load()); listing > 0 {
+		status.ListRate = float64(status.ReposListed) / listing.Seconds()
+	}
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		status.RepoRate = float64(status.ReposIngested+status.ReposFailed) / seconds
+		status.ItemRate = float64(status.Items) / seconds
+		status.ByteRate = float64(status.BytesDownloaded) / seconds
+	}
+	if eta, ok := estimateETA(status); ok {
+		at := now.Add(eta)
+		status.ETA = &at
+		status.ETASeconds = eta.Seconds()
+	}
+	return status
+}
+
+// estimateETA - time remaining until every repo is processed
+// while listing, the total is projected from the SyncListRepos pagination rate
+// and BSKY_EXPECTED_REPOS - without that hint only the listed backlog is estimated
+func estimateETA(status Status) (time.Duration, bool) {
+	if status.RepoRate <= 0 {
+		return 0, false
+	}
+	total := status.ReposListed
+	var listingETA time.Duration
+	if !status.ListingDone && status.ExpectedRepos > status.ReposListed {
+		total = status.ExpectedRepos
+		if status.ListRate > 0 {
+			listingETA = seconds(float64(status.ExpectedRepos-status.ReposListed) / status.ListRate)
+		}
+	}
+	remaining := total - status.ReposSkipped - status.ReposIngested - status.ReposFailed
+	if remaining <= 0 {
+		return 0, true
+	}
+	return max(listingETA, seconds(float64(remaining)/status.RepoRate)), true
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Report - log and record a progress snapshot, writing it to path if set
+func (p *Progress) Report(ctx context.Context, path string) (Status, error) {
+	status := p.Status()
+	p.metrics.repoRate.Record(ctx, status.RepoRate)
+	p.metrics.itemRate.Record(ctx, status.ItemRate)
+	p.metrics.byteRate.Record(ctx, status.ByteRate)
+	p.metrics.eta.Record(ctx, int64(status.ETASeconds))
+	if path == "" {
+		return status, nil
+	}
+	return status, WriteStatus(path, status)
+}
+
+// WriteStatus - atomically replace the JSON status snapshot at path
+func WriteStatus(path string, status Status) error {
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return err
+	}
+	var tmp *os.File
+	if tmp, err = os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*"); err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ReadStatus - load a JSON status snapshot written by another process
+func ReadStatus(path string) (*Status, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var status Status
+	if err = json.Unmarshal(data, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
@@ -0,0 +1,105 @@
+package bsky
+
+import (
+	"context"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type ProgressMetrics struct {
+	repos       metric.Int64Counter
+	bytes       metric.Int64Counter
+	pageLatency metric.Float64Histogram
+	repoRate    metric.Float64Gauge
+	itemRate    metric.Float64Gauge
+	byteRate    metric.Float64Gauge
+	eta         metric.Int64Gauge
+}
+
+func NewProgressMetrics(ctx context.Context) (*ProgressMetrics, error) {
+	buildInfo, ok := debug.ReadBuildInfo()
+	version := "unknown"
+	if ok {
+		version = buildInfo.Main.Version
+	}
+
+	meter := otel.GetMeterProvider().Meter(
+		"bsky.backfill",
+		metric.WithInstrumentationVersion(version),
+	)
+
+	repos, err := meter.Int64Counter(
+		"bsky.backfill.repos",
+		metric.WithDescription("Repos by backfill state: listed, skipped, fetched, ingested, failed"),
+		metric.WithUnit("{repos}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := meter.Int64Counter(
+		"bsky.backfill.bytes_downloaded",
+		metric.WithDescription("Repo CAR bytes downloaded"),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	pageLatency, err := meter.Float64Histogram(
+		"bsky.backfill.list_page_latency",
+		metric.WithDescription("SyncListRepos page latency"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	repoRate, err := meter.Float64Gauge(
+		"bsky.backfill.repo_throughput",
+		metric.WithDescription("Repos completed per second"),
+		metric.WithUnit("{repos}/s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	itemRate, err := meter.Float64Gauge(
+		"bsky.backfill.item_throughput",
+		metric.WithDescription("Items ingested per second"),
+		metric.WithUnit("{items}/s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	byteRate, err := meter.Float64Gauge(
+		"bsky.backfill.byte_throughput",
+		metric.WithDescription("Repo CAR bytes downloaded per second"),
+		metric.WithUnit("By/s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	eta, err := meter.Int64Gauge(
+		"bsky.backfill.eta",
+		metric.WithDescription("Estimated seconds until the backfill completes"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProgressMetrics{
+		repos:       repos,
+		bytes:       bytes,
+		pageLatency: pageLatency,
+		repoRate:    repoRate,
+		itemRate:    itemRate,
+		byteRate:    byteRate,
+		eta:         eta,
+	}, nil
+}
@@ -0,0 +1,86 @@
+package bsky
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgress(t *testing.T) {
+	t.Run("no eta before any repo completes", etaUnknownTest)
+	t.Run("eta from listed backlog once listing is done", etaListedTest)
+	t.Run("eta bounded by pagination rate while listing", etaListingTest)
+	t.Run("status counters", statusCountersTest)
+	t.Run("status snapshot round trips through file", statusFileTest)
+}
+
+func etaUnknownTest(t *testing.T) {
+	_, ok := estimateETA(Status{ReposListed: 100})
+	assert.False(t, ok)
+}
+
+func etaListedTest(t *testing.T) {
+	eta, ok := estimateETA(Status{
+		ListingDone:   true,
+		ReposListed:   100,
+		ReposSkipped:  10,
+		ReposIngested: 40,
+		RepoRate:      5,
+	})
+	require.True(t, ok)
+	assert.Equal(t, 10*time.Second, eta)
+}
+
+func etaListingTest(t *testing.T) {
+	eta, ok := estimateETA(Status{
+		ReposListed:   1000,
+		ExpectedRepos: 10000,
+		ReposIngested: 100,
+		// listing 9000 more repos @ 10/s dominates processing 9900 @ 100/s
+		ListRate: 10,
+		RepoRate: 100,
+	})
+	require.True(t, ok)
+	assert.Equal(t, 900*time.Second, eta)
+}
+
+func statusCountersTest(t *testing.T) {
+	ctx := context.TODO()
+	progress, err := NewProgress(ctx, 0)
+	require.Nil(t, err)
+	progress.Listed(ctx, 3, time.Second)
+	progress.Skipped(ctx)
+	progress.Fetched(ctx, 1024)
+	progress.ItemDone(ctx, nil)
+	progress.RepoDone(ctx, RepoResult{DID: "did:plc:test", Items: 1})
+	progress.ListingDone()
+	status := progress.Status()
+	assert.Equal(t, int64(1), status.Pages)
+	assert.Equal(t, int64(3), status.ReposListed)
+	assert.Equal(t, int64(1), status.ReposSkipped)
+	assert.Equal(t, int64(1), status.ReposFetched)
+	assert.Equal(t, int64(1), status.ReposIngested)
+	assert.Equal(t, int64(1), status.Items)
+	assert.Equal(t, int64(1024), status.BytesDownloaded)
+	assert.Equal(t, float64(3), status.ListRate)
+	assert.True(t, status.ListingDone)
+}
+
+func statusFileTest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.json")
+	status := Status{
+		Started:     time.Now().UTC().Truncate(time.Second),
+		ReposListed: 42,
+		ListingDone: true,
+	}
+	require.Nil(t, WriteStatus(path, status))
+	read, err := ReadStatus(path)
+	require.Nil(t, err)
+	assert.Equal(t, status.ReposListed, read.ReposListed)
+	assert.True(t, status.Started.Equal(read.Started))
+	assert.True(t, read.ListingDone)
+}
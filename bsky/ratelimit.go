@@ -51,9 +51,12 @@ type RateLimitHandler struct {
 
 // NewRateLimitHandler - default rate limt
 func NewRateLimitHandler(ctx context.Context, client *xrpc.Client) (*RateLimitHandler, error) {
-	var err error
-	conf := NewConf()
 	log := log.NewLog()
+	conf, err := NewConf()
+	if err != nil {
+		log.WithErrorMsg(err, "Error reading bsky settings", "type", "rate-limit")
+		return nil, err
+	}
 	var metrics *RateLimitMetrics
 	if metrics, err = NewRateLimitMetrics(ctx); err != nil {
 		log.WithErrorMsg(err, "Error bootstrapping metrics", "type", "rate-limit")
@@ -71,7 +74,9 @@ func NewRateLimitHandler(ctx context.Context, client *xrpc.Client) (*RateLimitHa
 	}, nil
 }
 
-// WithRetry executes an API call with rate limit handling
+// WithRetry executes an API call with rate limit handling. Only rate limited
+// calls are retried, any other error is returned as is apart from suppressed
+// repo takedown / deactivated errors.
 func (h *RateLimitHandler) WithRetry(ctx context.Context, opType OperationType, opName string, operation func() error) error {
 	baseAttrs := []attribute.KeyValue{
 		attribute.String("name", opName),
@@ -91,9 +96,9 @@ func (h *RateLimitHandler) WithRetry(ctx context.Context, opType OperationType,
 
 		var apiErr *xrpc.Error
 		var ok bool
-		// short circuit if no atproto error
+		// short circuit if no atproto error - only rate limits are retried
 		if apiErr, ok = err.(*xrpc.Error); !ok {
-			return nil
+			return err
 		}
 		// suppress repo takedown / deactivated errors
 		if suppressATProtoErr(err) {
@@ -119,6 +124,8 @@ func (h *RateLimitHandler) WithRetry(ctx context.Context, opType OperationType,
 				h.metrics.retryAttempts.Add(ctx, 1, metric.WithAttributes(baseAttrs...))
 				continue
 			}
+		default:
+			return err
 		}
 	}
 	var retryErr error
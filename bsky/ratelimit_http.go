@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/bluesky-social/indigo/util"
@@ -21,14 +22,48 @@ const (
 
 type RateLimitInterceptor struct {
 	metrics   *RateLimitMetrics
+	state     *RateLimitState
 	transport http.RoundTripper
 }
 
+// RateLimitState tracks the most recently observed RateLimit-* headers
+// so the worker pool can scale against the remaining request budget
+type RateLimitState struct {
+	remaining atomic.Int64
+	limit     atomic.Int64
+}
+
+// shared across all clients minted via NewHTTPClient
+var rateLimitState = &RateLimitState{}
+
+func (s *RateLimitState) Observe(info *xrpc.RatelimitInfo) {
+	if info == nil || info.Limit <= 0 {
+		return
+	}
+	s.remaining.Store(int64(info.Remaining))
+	s.limit.Store(int64(info.Limit))
+}
+
+// Headroom - fraction of the rate limit budget remaining [0, 1]
+// defaults to 1 until a rate limited response has been observed
+func (s *RateLimitState) Headroom() float64 {
+	limit := s.limit.Load()
+	if limit <= 0 {
+		return 1
+	}
+	remaining := s.remaining.Load()
+	if remaining <= 0 {
+		return 0
+	}
+	return min(float64(remaining)/float64(limit), 1)
+}
+
 func NewHTTPClient() *http.Client {
 	metrics, _ := NewRateLimitMetrics(context.Background())
 	client := util.RobustHTTPClient()
 	client.Transport = &RateLimitInterceptor{
 		metrics:   metrics,
+		state:     rateLimitState,
 		transport: http.DefaultTransport,
 	}
 	return client
@@ -68,6 +103,9 @@ func (r *RateLimitInterceptor) RoundTrip(req *http.Request) (*http.Response, err
 	ctx := resp.Request.Context()
 	r.metrics.rateLimitRequestsRemaining.Record(ctx, int64(info.Remaining), metric.WithAttributes(baseAttrs...))
 	r.metrics.rateLimitRequestsLimit.Record(ctx, int64(info.Limit), metric.WithAttributes(baseAttrs...))
+	if r.state != nil {
+		r.state.Observe(info)
+	}
 
 	return resp, nil
 }
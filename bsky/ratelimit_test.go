@@ -25,6 +25,7 @@ func TestRateLimit(t *testing.T) {
 	t.Run("max retries exceeded", maxRetriesExceededTest)
 	t.Run("retry context cancelled", retryContextCancelledTest)
 	t.Run("retry after specified deadline", resetDeadlineTest)
+	t.Run("errors other than rate limits are returned without retry", retryOtherErrorsTest)
 	t.Run("op=read retry upto MAX_WAIT if MAX_RETRIES = 0 without deadline", func(t *testing.T) {
 		exponentialBackoffTest(t, ReadOperation)
 	})
@@ -85,7 +86,8 @@ func maxRetriesTest(t *testing.T) {
 	handler, err := NewRateLimitHandler(context.TODO(), xrpcClientTest())
 	assert.Nil(t, err)
 	assert.NotNil(t, handler)
-	conf := NewConf()
+	conf, err := NewConf()
+	require.Nil(t, err)
 	assert.Equal(t, conf.MaxRetries(), handler.maxRetries)
 	assert.Equal(t, DEFAULT_MAX_RETRIES, handler.maxRetries)
 }
@@ -138,6 +140,23 @@ func retryContextCancelledTest(t *testing.T) {
 	assert.True(t, errors.Is(err, context.DeadlineExceeded))
 }
 
+func retryOtherErrorsTest(t *testing.T) {
+	handler, err := NewRateLimitHandler(context.TODO(), xrpcClientTest())
+	require.Nil(t, err)
+	for _, opErr := range []error{
+		errors.New("found wrong type in follow location in tree"),
+		&xrpc.Error{StatusCode: http.StatusInternalServerError},
+	} {
+		attempt := 0
+		err = handler.WithRetry(context.Background(), ReadOperation, opName, func() error {
+			attempt++
+			return opErr
+		})
+		assert.Equal(t, opErr, err)
+		assert.Equal(t, 1, attempt)
+	}
+}
+
 func resetDeadlineTest(t *testing.T) {
 	handler, err := NewRateLimitHandler(context.TODO(), xrpcClientTest())
 	assert.Nil(t, err)
@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/bluesky-social/indigo/api/atproto"
 	"github.com/bluesky-social/indigo/api/bsky"
@@ -14,12 +15,28 @@ import (
 	"github.com/ipfs/go-cid"
 )
 
+// RepoJob - a listed repo fetched over sync.getRepo, or a CAR file read from disk
 type RepoJob struct {
 	repo *atproto.SyncListRepos_Repo
+	car  string
+	// handle - archived alongside car when it was fetched, used when it can't be resolved
+	handle  string
+	tracker *repoTracker
 }
 
+// id - the listed DID, or the CAR path until its commit has been read
+func (j RepoJob) id() string {
+	if j.repo != nil {
+		return j.repo.Did
+	}
+	return j.car
+}
+
+// RepoItem - a single record read from a repo. Path is <collection>/<rkey>,
+// URI is at://<did>/<collection>/<rkey> and CID is the record block's CID.
 type RepoItem struct {
 	repo    *repo.Repo
+	tracker *repoTracker
 	Data    any                `json:"data"`
 	DID     syntax.DID         `json:"did"`
 	Err     error              `json:"err"`
@@ -27,6 +44,10 @@ type RepoItem struct {
 	Sig     string             `json:"sig"`
 	Ident   *identity.Identity `json:"ident"`
 	NSID    syntax.NSID        `json:"nsid"`
+	Path    string             `json:"path"`
+	RKey    string             `json:"rkey"`
+	URI     syntax.ATURI       `json:"uri"`
+	CID     string             `json:"cid"`
 	Version int64              `json:"version"`
 }
 
@@ -44,13 +65,17 @@ func (e *LexiconError) Error() string {
 	return fmt.Sprintf("TODO: unsupported lexicon: %s", e.nsid.String())
 }
 
-func resolveLexicon(ctx context.Context, ident *identity.Identity, r *repo.Repo, items chan RepoItem) error {
+// resolveLexicon - walk every record in the repo, sending supported lexicons
+// to items. Unsupported lexicons are counted and skipped rather than aborting
+// the walk since records are visited in key order (feed.* before graph.*)
+func resolveLexicon(ctx context.Context, ident *identity.Identity, r *repo.Repo, tracker *repoTracker, items chan RepoItem) (map[syntax.NSID]int, error) {
 	// extract DID from repo commit
 	var did syntax.DID
 	var err error
+	skipped := make(map[syntax.NSID]int)
 	sc := r.SignedCommit()
 	if did, err = syntax.ParseDID(sc.Did); err != nil {
-		return err
+		return skipped, err
 	}
 	err = r.ForEach(ctx, "", func(k string, v cid.Cid) error {
 		var data any
@@ -59,7 +84,8 @@ func resolveLexicon(ctx context.Context, ident *identity.Identity, r *repo.Repo,
 		if _, rec, err = r.GetRecord(ctx, k); err != nil {
 			return err
 		}
-		nsid := syntax.NSID(strings.SplitN(k, "/", 2)[0]).Normalize()
+		collection, rkey, _ := strings.Cut(k, "/")
+		nsid := syntax.NSID(collection).Normalize()
 
 		var lexiconErr *LexiconError
 		switch nsid {
@@ -90,7 +116,6 @@ func resolveLexicon(ctx context.Context, ident *identity.Identity, r *repo.Repo,
 			if data, ok = rec.(*bsky.GraphBlock); !ok {
 				return fmt.Errorf("found wrong type in block location in tree: %s", did)
 			}
-			lexiconErr = NewLexiconError(ITEM_GRAPH_BLOCK)
 		case ITEM_GRAPH_LIST_BLOCK:
 			if data, ok = rec.(*bsky.GraphListblock); !ok {
 				return fmt.Errorf("found wrong type in listblock location in tree: %s", did)
@@ -107,26 +132,86 @@ func resolveLexicon(ctx context.Context, ident *identity.Identity, r *repo.Repo,
 			}
 			lexiconErr = NewLexiconError(ITEM_GRAPH_LIST_ITEM)
 		default:
-			return NewLexiconError(nsid)
+			lexiconErr = NewLexiconError(nsid)
 		}
 
 		if lexiconErr != nil {
-			return lexiconErr
+			skipped[lexiconErr.nsid]++
+			return nil
 		}
 
-		items <- RepoItem{
+		if tracker != nil {
+			tracker.add()
+		}
+		item := RepoItem{
 			repo:    r,
+			tracker: tracker,
 			Data:    data,
 			Rev:     sc.Rev,
 			Sig:     base64.StdEncoding.EncodeToString(sc.Sig),
 			DID:     did,
 			Ident:   ident,
 			NSID:    nsid,
+			Path:    k,
+			RKey:    rkey,
+			URI:     recordURI(did, k),
+			CID:     v.String(),
 			Version: sc.Version,
 		}
+		select {
+		case items <- item:
+		case <-ctx.Done():
+			if tracker != nil {
+				// the item never reached an ingest worker
+				tracker.release()
+			}
+			return ctx.Err()
+		}
 
 		return nil
 	})
 
-	return err
+	return skipped, err
+}
+
+// recordURI - at://<did>/<collection>/<rkey> for a repo path
+func recordURI(did syntax.DID, path string) syntax.ATURI {
+	return syntax.ATURI(fmt.Sprintf("at://%s/%s", did, path))
+}
+
+// Defer - engines that buffer the item call Defer before returning from
+// Ingest and settle the returned func once the batch holding it was written.
+// The item's repo completes, and is marked synced, only after every deferred
+// item settled without error. Items outside a repo walk get a no-op.
+func (i RepoItem) Defer() func(error) {
+	if i.tracker == nil {
+		return func(error) {}
+	}
+	return i.tracker.deferred()
+}
+
+// RevVersion - the repo rev (a TID) as an integer so writes can be ordered
+// by revision. TIDs are monotonic so a newer rev is always a larger version;
+// 0 for a missing or malformed rev which any valid rev supersedes.
+func (i RepoItem) RevVersion() uint64 {
+	return RevVersion(i.Rev)
+}
+
+// RevVersion - see RepoItem.RevVersion
+func RevVersion(rev string) uint64 {
+	tid, err := syntax.ParseTID(rev)
+	if err != nil {
+		return 0
+	}
+	return tid.Integer()
+}
+
+// RevTime - timestamp encoded in the rev TID: when the repo commit was made.
+// The unix epoch for a missing or malformed rev.
+func RevTime(rev string) time.Time {
+	tid, err := syntax.ParseTID(rev)
+	if err != nil {
+		return time.Unix(0, 0).UTC()
+	}
+	return tid.Time().UTC()
 }
@@ -0,0 +1,97 @@
+package bsky
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/bluesky-social/indigo/repo"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveLexicon(t *testing.T) {
+	t.Run("unsupported lexicons don't abort the walk", resolveSkipTest)
+	t.Run("rev version orders by TID", revVersionTest)
+	t.Run("cancelled walk stops sending items", resolveCancelTest)
+}
+
+// repoTest - signed in-memory repo with a like and post sorted ahead of the follow
+func repoTest(t *testing.T) *repo.Repo {
+	r, _ := commitTest(t)
+	return r
+}
+
+// commitTest - repoTest along with the CID of its signed commit
+func commitTest(t *testing.T) (*repo.Repo, cid.Cid) {
+	ctx := context.Background()
+	r := repo.NewRepo(ctx, "did:plc:test", blockstore.NewBlockstore(datastore.NewMapDatastore()))
+	created := "2025-01-01T00:00:00Z"
+	_, _, err := r.CreateRecord(ctx, ITEM_FEED_LIKE.String(), &bsky.FeedLike{CreatedAt: created})
+	require.Nil(t, err)
+	_, _, err = r.CreateRecord(ctx, ITEM_FEED_POST.String(), &bsky.FeedPost{Text: "hello", CreatedAt: created})
+	require.Nil(t, err)
+	_, _, err = r.CreateRecord(ctx, ITEM_GRAPH_FOLLOW.String(), &bsky.GraphFollow{Subject: "did:plc:subject", CreatedAt: created})
+	require.Nil(t, err)
+	root, _, err := r.Commit(ctx, func(ctx context.Context, did string, data []byte) ([]byte, error) {
+		return []byte("sig"), nil
+	})
+	require.Nil(t, err)
+	return r, root
+}
+
+func resolveSkipTest(t *testing.T) {
+	items := make(chan RepoItem, 10)
+	skipped, err := resolveLexicon(context.Background(), nil, repoTest(t), nil, items)
+	require.Nil(t, err)
+	close(items)
+
+	var resolved []RepoItem
+	for item := range items {
+		resolved = append(resolved, item)
+	}
+	require.Len(t, resolved, 1)
+	assert.Equal(t, ITEM_GRAPH_FOLLOW, resolved[0].NSID)
+	follow := resolved[0]
+	assert.NotEmpty(t, follow.RKey)
+	assert.Equal(t, ITEM_GRAPH_FOLLOW.String()+"/"+follow.RKey, follow.Path)
+	assert.Equal(t, "at://did:plc:test/"+follow.Path, follow.URI.String())
+	assert.Equal(t, follow.RKey, follow.URI.RecordKey().String())
+	assert.NotEmpty(t, follow.CID)
+	assert.Equal(t, 1, skipped[ITEM_FEED_LIKE])
+	assert.Equal(t, 1, skipped[ITEM_FEED_POST])
+}
+
+func resolveCancelTest(t *testing.T) {
+	var results []RepoResult
+	tracker := newRepoTracker("did:plc:test", func(result RepoResult) {
+		results = append(results, result)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// nothing reads items, as once the ingest workers have exited
+	_, err := resolveLexicon(ctx, nil, repoTest(t), tracker, make(chan RepoItem))
+	assert.ErrorIs(t, err, context.Canceled)
+	// the unsent item no longer holds the repo open
+	tracker.walkDone(err)
+	require.Len(t, results, 1)
+	assert.Zero(t, results[0].Items)
+	assert.ErrorIs(t, results[0].Err, context.Canceled)
+}
+
+func revVersionTest(t *testing.T) {
+	older := RepoItem{Rev: syntax.NewTIDFromTime(time.Unix(1700000000, 0), 0).String()}
+	newer := RepoItem{Rev: syntax.NewTIDFromTime(time.Unix(1700000001, 0), 0).String()}
+	assert.Greater(t, newer.RevVersion(), older.RevVersion())
+	assert.Zero(t, RepoItem{Rev: "not-a-tid"}.RevVersion())
+	assert.Zero(t, RepoItem{}.RevVersion())
+
+	// rev TIDs encode the commit time
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), RevTime(older.Rev))
+	assert.Equal(t, time.Unix(0, 0).UTC(), RevTime("not-a-tid"))
+}
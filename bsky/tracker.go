@@ -0,0 +1,152 @@
+package bsky
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// RepoResult - outcome of a single repo once all of its items have been ingested
+type RepoResult struct {
+	DID string
+	// Rev - repo revision the items were read from, empty if the repo was never fetched
+	Rev    string
+	Items  int64
+	Failed int64
+	Err    error
+}
+
+// Summary - totals across every repo submitted to the pool
+type Summary struct {
+	Repos        int64 `json:"repos"`
+	RepoFailures int64 `json:"repo_failures"`
+	Items        int64 `json:"items"`
+	ItemFailures int64 `json:"item_failures"`
+}
+
+// repoTracker counts a repo's outstanding items so the repo completes exactly once:
+// pending starts at 1 for the CAR walk itself and every emitted item adds 1,
+// the walk finishing and each ingested item subtract 1 - the repo is done at 0.
+// Engines that buffer an item hold the repo open until its batch is written.
+type repoTracker struct {
+	did        atomic.Value
+	rev        atomic.Value
+	pending    atomic.Int64
+	items      atomic.Int64
+	failed     atomic.Int64
+	errMu      sync.Mutex
+	err        error
+	once       sync.Once
+	complete   func(RepoResult)
+	itemFailed func(error)
+}
+
+func newRepoTracker(did string, complete func(RepoResult)) *repoTracker {
+	t := &repoTracker{
+		complete: complete,
+	}
+	t.did.Store(did)
+	t.pending.Store(1)
+	return t
+}
+
+// add - an item was emitted from the repo and queued for ingest
+func (t *repoTracker) add() {
+	t.pending.Add(1)
+}
+
+// itemDone - an emitted item finished ingesting (successfully or not)
+func (t *repoTracker) itemDone(err error) {
+	t.items.Add(1)
+	if err != nil {
+		t.failed.Add(1)
+	}
+	t.release()
+}
+
+// deferred - an engine buffered an item, the returned func settles it once the
+// batch holding it is written. A failed write fails the whole repo: its rows
+// never reached the graph so it must not be marked synced.
+func (t *repoTracker) deferred() func(error) {
+	t.pending.Add(1)
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() {
+			if err != nil {
+				t.failed.Add(1)
+				t.fail(err)
+				if t.itemFailed != nil {
+					t.itemFailed(err)
+				}
+			}
+			t.release()
+		})
+	}
+}
+
+// walked - the repo of did was read at rev and its records are being walked.
+// CAR imports only learn the DID from the commit so it replaces the job's.
+func (t *repoTracker) walked(did, rev string) {
+	t.did.Store(did)
+	t.rev.Store(rev)
+}
+
+// walkDone - no further items will be emitted for this repo
+func (t *repoTracker) walkDone(err error) {
+	t.fail(err)
+	t.release()
+}
+
+func (t *repoTracker) fail(err error) {
+	if err == nil {
+		return
+	}
+	t.errMu.Lock()
+	defer t.errMu.Unlock()
+	t.err = errors.Join(t.err, err)
+}
+
+func (t *repoTracker) release() {
+	if t.pending.Add(-1) != 0 {
+		return
+	}
+	t.once.Do(func() {
+		did, _ := t.did.Load().(string)
+		rev, _ := t.rev.Load().(string)
+		t.errMu.Lock()
+		err := t.err
+		t.errMu.Unlock()
+		t.complete(RepoResult{
+			DID:    did,
+			Rev:    rev,
+			Items:  t.items.Load(),
+			Failed: t.failed.Load(),
+			Err:    err,
+		})
+	})
+}
+
+type summaryCounters struct {
+	repos        atomic.Int64
+	repoFailures atomic.Int64
+	items        atomic.Int64
+	itemFailures atomic.Int64
+}
+
+func (c *summaryCounters) record(result RepoResult) {
+	c.repos.Add(1)
+	if result.Err != nil {
+		c.repoFailures.Add(1)
+	}
+	c.items.Add(result.Items)
+	c.itemFailures.Add(result.Failed)
+}
+
+func (c *summaryCounters) snapshot() Summary {
+	return Summary{
+		Repos:        c.repos.Load(),
+		RepoFailures: c.repoFailures.Load(),
+		Items:        c.items.Load(),
+		ItemFailures: c.itemFailures.Load(),
+	}
+}
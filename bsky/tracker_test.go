@@ -0,0 +1,189 @@
+package bsky
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/util"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker(t *testing.T) {
+	t.Run("repo completes after walk and all items", trackerCompleteTest)
+	t.Run("repo without items completes on walk", trackerEmptyTest)
+	t.Run("item failures are counted", trackerFailuresTest)
+	t.Run("repo waits for deferred items", trackerDeferredTest)
+	t.Run("failed deferred item fails the repo", trackerDeferredFailTest)
+	t.Run("closed pool with no jobs drains", poolDrainEmptyTest)
+	t.Run("closed pool rejects new jobs", poolClosedSubmitTest)
+	t.Run("pool drains without reading results", poolDrainNoResultsTest)
+	t.Run("failed listing still closes the pool", backfillListErrTest)
+}
+
+func trackerCompleteTest(t *testing.T) {
+	var results []RepoResult
+	tracker := newRepoTracker("did:plc:test", func(result RepoResult) {
+		results = append(results, result)
+	})
+	tracker.walked("did:plc:test", "3kabc")
+	tracker.add()
+	tracker.add()
+	tracker.itemDone(nil)
+	tracker.walkDone(nil)
+	assert.Empty(t, results)
+	tracker.itemDone(nil)
+	require.Len(t, results, 1)
+	assert.Equal(t, "did:plc:test", results[0].DID)
+	assert.Equal(t, "3kabc", results[0].Rev)
+	assert.Equal(t, int64(2), results[0].Items)
+	assert.Equal(t, int64(0), results[0].Failed)
+	assert.Nil(t, results[0].Err)
+}
+
+func trackerEmptyTest(t *testing.T) {
+	var results []RepoResult
+	walkErr := errors.New("repo not found")
+	tracker := newRepoTracker("did:plc:test", func(result RepoResult) {
+		results = append(results, result)
+	})
+	tracker.walkDone(walkErr)
+	require.Len(t, results, 1)
+	assert.Empty(t, results[0].Rev)
+	assert.Equal(t, int64(0), results[0].Items)
+	assert.ErrorIs(t, results[0].Err, walkErr)
+}
+
+func trackerFailuresTest(t *testing.T) {
+	var summary summaryCounters
+	tracker := newRepoTracker("did:plc:test", summary.record)
+	tracker.add()
+	tracker.add()
+	tracker.walkDone(nil)
+	tracker.itemDone(errors.New("ingest failed"))
+	tracker.itemDone(nil)
+	assert.Equal(t, Summary{
+		Repos:        1,
+		RepoFailures: 0,
+		Items:        2,
+		ItemFailures: 1,
+	}, summary.snapshot())
+}
+
+func trackerDeferredTest(t *testing.T) {
+	var results []RepoResult
+	tracker := newRepoTracker("did:plc:test", func(result RepoResult) {
+		results = append(results, result)
+	})
+	item := RepoItem{tracker: tracker}
+	tracker.add()
+	flushed := item.Defer()
+	tracker.itemDone(nil)
+	tracker.walkDone(nil)
+	// ingested but still buffered
+	assert.Empty(t, results)
+	flushed(nil)
+	// settling twice is a no-op
+	flushed(nil)
+	require.Len(t, results, 1)
+	assert.Equal(t, int64(1), results[0].Items)
+	assert.Nil(t, results[0].Err)
+	// items outside a repo walk have nothing to hold open
+	RepoItem{}.Defer()(nil)
+}
+
+func trackerDeferredFailTest(t *testing.T) {
+	var results []RepoResult
+	var itemFailures []error
+	flushErr := errors.New("flush failed")
+	tracker := newRepoTracker("did:plc:test", func(result RepoResult) {
+		results = append(results, result)
+	})
+	tracker.itemFailed = func(err error) {
+		itemFailures = append(itemFailures, err)
+	}
+	item := RepoItem{tracker: tracker}
+	tracker.add()
+	flushed := item.Defer()
+	tracker.itemDone(nil)
+	tracker.walkDone(nil)
+	flushed(flushErr)
+	require.Len(t, results, 1)
+	assert.Equal(t, int64(1), results[0].Failed)
+	assert.ErrorIs(t, results[0].Err, flushErr)
+	assert.Equal(t, []error{flushErr}, itemFailures)
+}
+
+func poolDrainEmptyTest(t *testing.T) {
+	pool := workerPoolTest(t)
+	pool.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	summary, err := pool.Wait(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, Summary{}, summary)
+	_, ok := <-pool.Results()
+	assert.False(t, ok)
+}
+
+func poolClosedSubmitTest(t *testing.T) {
+	pool := workerPoolTest(t)
+	pool.Close()
+	active := true
+	err := pool.Submit(context.Background(), RepoJob{
+		repo: &atproto.SyncListRepos_Repo{Did: "did:plc:test", Active: &active},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, int64(0), pool.jobsInflight.Load())
+}
+
+func poolDrainNoResultsTest(t *testing.T) {
+	pool := workerPoolTest(t)
+	// more completed repos than the results buffer holds
+	repos := cap(pool.results) + 1
+	pool.pending.Add(repos)
+	for range repos {
+		pool.complete(context.TODO(), RepoResult{DID: "did:plc:test"})
+	}
+	pool.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	summary, err := pool.Wait(ctx)
+	require.Nil(t, err)
+	assert.Equal(t, int64(repos), summary.Repos)
+}
+
+func backfillListErrTest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"InternalServerError"}`, http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	pool := workerPoolTest(t)
+	cfg, err := NewConf()
+	require.Nil(t, err)
+	client := &Client{
+		atproto: &xrpc.Client{Client: util.TestingHTTPClient(), Host: srv.URL},
+		conf:    cfg,
+		log:     pool.log,
+	}
+	assert.Error(t, client.BackfillRepos(context.Background(), pool))
+	select {
+	case <-pool.Done():
+	case <-time.After(time.Second):
+		t.Fatal("pool never drained after the listing failed")
+	}
+}
+
+func workerPoolTest(t *testing.T) *WorkerPool {
+	cfg, err := NewConf()
+	require.Nil(t, err)
+	pool, err := NewWorkerPool(context.TODO(), &Client{atproto: xrpcClientTest()}, cfg)
+	require.Nil(t, err)
+	return pool
+}
@@ -1,10 +1,12 @@
 package bsky
 
 import (
+	"bufio"
 	"bytes"
 	"context"
-	"errors"
 	"fmt"
+	"os"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -13,26 +15,52 @@ import (
 	"github.com/bluesky-social/indigo/atproto/syntax"
 	"github.com/bluesky-social/indigo/repo"
 	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/mikeblum/atgraph.dev/archive"
 	log "github.com/mikeblum/atgraph.dev/conf"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 	"golang.org/x/sync/errgroup"
 )
 
+const (
+	WorkerTypeRepo   = "repo"
+	WorkerTypeIngest = "ingest"
+)
+
 type WorkerPool struct {
-	client       *Client
-	log          *log.Log
-	jobs         chan RepoJob
-	items        chan RepoItem
-	results      chan error
-	jobsInflight atomic.Int64
-	poolReady    chan bool
-	ingestReady  chan bool
-	done         chan bool
-	rateLimiter  *RateLimitHandler
-	metrics      *WorkerMetrics
-	ingest       func(context.Context, int, RepoItem) error
-	workerCount  int
+	client            *Client
+	log               *log.Log
+	jobs              chan RepoJob
+	items             chan RepoItem
+	results           chan RepoResult
+	resultsEnabled    atomic.Bool
+	jobsInflight      atomic.Int64
+	poolReady         chan bool
+	ingestReady       chan bool
+	shutdown          chan bool
+	drained           chan struct{}
+	pending           sync.WaitGroup
+	submitMu          sync.RWMutex
+	closed            bool
+	closeOnce         sync.Once
+	summary           summaryCounters
+	progress          *Progress
+	progressInterval  time.Duration
+	statusFile        string
+	rateLimiter       *RateLimitHandler
+	rateLimitState    *RateLimitState
+	metrics           *WorkerMetrics
+	ingest            func(context.Context, int, RepoItem) error
+	directory         identity.Directory
+	archive           *archive.Archive
+	synced            func(context.Context, string, string) error
+	group             *errgroup.Group
+	repoWorkers       *workerSet
+	ingestWorkers     *workerSet
+	repoWorkerCount   int
+	ingestWorkerCount int
+	autoscale         bool
+	autoscaleInterval time.Duration
 }
 
 func NewWorkerPool(ctx context.Context, client *Client, conf *Conf) (*WorkerPool, error) {
@@ -44,19 +72,38 @@ func NewWorkerPool(ctx context.Context, client *Client, conf *Conf) (*WorkerPool
 	if err != nil {
 		return nil, err
 	}
-	return &WorkerPool{
-		client:      client,
-		log:         log.NewLog(),
-		jobs:        make(chan RepoJob, conf.WorkerCount()*2),
-		items:       make(chan RepoItem, conf.WorkerCount()*2),
-		results:     make(chan error, conf.WorkerCount()*2),
-		poolReady:   make(chan bool),
-		ingestReady: make(chan bool),
-		done:        make(chan bool),
-		rateLimiter: rateLimit,
-		metrics:     metrics,
-		workerCount: conf.WorkerCount(),
-	}, nil
+	progress, err := NewProgress(ctx, conf.ExpectedRepos())
+	if err != nil {
+		return nil, err
+	}
+	repoMin, repoMax := conf.RepoWorkerBounds()
+	ingestMin, ingestMax := conf.IngestWorkerBounds()
+	p := &WorkerPool{
+		client: client,
+		log:    log.NewLog(),
+		// size queues for the upper bound so the autoscaler has room to grow into
+		jobs:              make(chan RepoJob, repoMax*2),
+		items:             make(chan RepoItem, ingestMax*2),
+		results:           make(chan RepoResult, repoMax*2),
+		poolReady:         make(chan bool),
+		ingestReady:       make(chan bool),
+		shutdown:          make(chan bool),
+		drained:           make(chan struct{}),
+		rateLimiter:       rateLimit,
+		rateLimitState:    rateLimitState,
+		metrics:           metrics,
+		repoWorkerCount:   conf.RepoWorkerCount(),
+		ingestWorkerCount: conf.IngestWorkerCount(),
+		autoscale:         conf.Autoscale(),
+		autoscaleInterval: conf.AutoscaleInterval(),
+		progress:          progress,
+		progressInterval:  conf.ProgressInterval(),
+		statusFile:        conf.StatusFile(),
+	}
+	// only CAR downloads count against the PDS rate limit
+	p.repoWorkers = newWorkerSet(WorkerTypeRepo, NewScalePolicy(repoMin, repoMax, conf.RateLimitMinHeadroom()), p.repoWorker)
+	p.ingestWorkers = newWorkerSet(WorkerTypeIngest, NewScalePolicy(ingestMin, ingestMax, 0), p.ingestWorker)
+	return p, nil
 }
 
 func (p *WorkerPool) StartMonitor(ctx context.Context) *WorkerPool {
@@ -72,12 +119,71 @@ func (p *WorkerPool) StartMonitor(ctx context.Context) *WorkerPool {
 				p.metrics.jobsQueued.Record(ctx, int64(len(p.jobs)))
 				p.metrics.itemsQueued.Record(ctx, int64(len(p.items)))
 				p.metrics.resultsQueued.Record(ctx, int64(len(p.results)))
+				p.metrics.workers.Record(ctx, int64(p.repoWorkers.Size()), metric.WithAttributes(attribute.String("type", WorkerTypeRepo)))
+				p.metrics.workers.Record(ctx, int64(p.ingestWorkers.Size()), metric.WithAttributes(attribute.String("type", WorkerTypeIngest)))
+			}
+		}
+	}()
+	return p
+}
+
+// StartProgress - periodically log backfill progress and write the status snapshot
+func (p *WorkerPool) StartProgress(ctx context.Context) *WorkerPool {
+	go func() {
+		ticker := time.NewTicker(p.progressInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.drained:
+				// final snapshot once every repo has completed
+				p.reportProgress(ctx)
+				return
+			case <-ticker.C:
+				p.reportProgress(ctx)
 			}
 		}
 	}()
 	return p
 }
 
+func (p *WorkerPool) reportProgress(ctx context.Context) {
+	status, err := p.progress.Report(ctx, p.statusFile)
+	if err != nil {
+		p.log.WithErrorMsg(err, "Error writing backfill status", "path", p.statusFile)
+	}
+	args := []any{
+		"action", "progress",
+		"elapsed", status.Elapsed,
+		"pages", status.Pages,
+		"listed", status.ReposListed,
+		"skipped", status.ReposSkipped,
+		"fetched", status.ReposFetched,
+		"ingested", status.ReposIngested,
+		"failed", status.ReposFailed,
+		"items", status.Items,
+		"bytes", status.BytesDownloaded,
+		"repos-per-sec", fmt.Sprintf("%.2f", status.RepoRate),
+		"items-per-sec", fmt.Sprintf("%.2f", status.ItemRate),
+	}
+	if status.ETA != nil {
+		args = append(args, "eta", seconds(status.ETASeconds).Round(time.Second))
+	}
+	p.log.With(args...).Info("Backfill progress")
+}
+
+// Progress - backfill counters shared with the repo lister
+func (p *WorkerPool) Progress() *Progress {
+	return p.progress
+}
+
+// Status - current backfill status snapshot
+func (p *WorkerPool) Status() Status {
+	return p.progress.Status()
+}
+
 func (p *WorkerPool) PoolReady() chan bool {
 	return p.poolReady
 }
@@ -95,31 +201,50 @@ func (p *WorkerPool) WithIngest(ingest func(context.Context, int, RepoItem) erro
 	return p
 }
 
+// WithRepoSynced - called with (did, rev) once every record of a repo has been
+// ingested and written without error so engines can close out edges the repo
+// no longer contains
+func (p *WorkerPool) WithRepoSynced(synced func(ctx context.Context, did, rev string) error) *WorkerPool {
+	p.synced = synced
+	return p
+}
+
+// WithDirectory - resolve identities through dir: fetched repos fall back to
+// identity.DefaultDirectory, imported CAR repos stay offline without one.
+func (p *WorkerPool) WithDirectory(dir identity.Directory) *WorkerPool {
+	p.directory = dir
+	return p
+}
+
+// WithArchive - keep every fetched repo CAR in a so it can be replayed later
+func (p *WorkerPool) WithArchive(a *archive.Archive) *WorkerPool {
+	p.archive = a
+	return p
+}
+
 // Start - step #1: start worker pool
 func (p *WorkerPool) Start(ctx context.Context) error {
 	g, ctx := errgroup.WithContext(ctx)
+	p.group = g
 
-	p.log.Info("Starting worker pool", "worker-count", p.workerCount)
+	p.log.Info("Starting worker pool",
+		"repo-worker-count", p.repoWorkerCount,
+		"ingest-worker-count", p.ingestWorkerCount,
+		"autoscale", p.autoscale)
 
 	// Start repo workers
-	for i := 0; i < p.workerCount; i++ {
-		workerID := i + 1
-		g.Go(func() error {
-			return p.repoWorker(ctx, workerID)
-		})
-	}
+	p.repoWorkers.scaleTo(ctx, g, p.repoWorkerCount)
 
 	// Start ingest workers
-	for i := 0; i < p.workerCount; i++ {
-		workerID := i + 1
-		g.Go(func() error {
-			return p.ingestWorker(ctx, workerID)
-		})
+	p.ingestWorkers.scaleTo(ctx, g, p.ingestWorkerCount)
+
+	if p.autoscale {
+		go p.autoscaler(ctx)
 	}
 
 	go func() {
 		<-ctx.Done()
-		close(p.done)
+		close(p.shutdown)
 	}()
 
 	// Signal pool is ready
@@ -132,20 +257,155 @@ func (p *WorkerPool) Start(ctx context.Context) error {
 
 // Submit - step #2: submit repo jobs for processing
 func (p *WorkerPool) Submit(ctx context.Context, job RepoJob) error {
-	if job.repo == nil {
-		return fmt.Errorf("error submitting RepoJob: missing repo")
+	if job.repo == nil && job.car == "" {
+		return fmt.Errorf("error submitting RepoJob: missing repo or CAR")
+	}
+
+	p.submitMu.RLock()
+	if p.closed {
+		p.submitMu.RUnlock()
+		return fmt.Errorf("worker pool is closed to new jobs")
 	}
+	// count the repo as inflight until all of its items are ingested
+	p.pending.Add(1)
+	p.submitMu.RUnlock()
+	p.jobsInflight.Add(1)
+	p.metrics.jobsInflight.Add(ctx, 1)
+
+	job.tracker = newRepoTracker(job.id(), func(result RepoResult) {
+		p.complete(ctx, result)
+	})
+	job.tracker.itemFailed = func(err error) {
+		p.progress.ItemFailed(ctx, err)
+	}
+
 	select {
 	case <-ctx.Done():
+		p.abandon(ctx)
 		return ctx.Err()
-	case <-p.done:
+	case <-p.shutdown:
+		p.abandon(ctx)
 		return fmt.Errorf("worker pool is shutting down")
 	case p.jobs <- job: // block until more work can be processed
 		return nil
 	}
 }
 
-func (p *WorkerPool) ingestWorker(ctx context.Context, workerID int) error {
+// Close - step #3: no more jobs will be submitted, Done fires once inflight repos drain
+func (p *WorkerPool) Close() {
+	p.closeOnce.Do(func() {
+		p.submitMu.Lock()
+		p.closed = true
+		p.submitMu.Unlock()
+		go func() {
+			p.pending.Wait()
+			close(p.results)
+			close(p.drained)
+		}()
+	})
+}
+
+// Done - closed once the pool is closed and every submitted repo has completed
+func (p *WorkerPool) Done() <-chan struct{} {
+	return p.drained
+}
+
+// Wait - block until the pool drains or ctx is cancelled
+func (p *WorkerPool) Wait(ctx context.Context) (Summary, error) {
+	select {
+	case <-ctx.Done():
+		return p.Summary(), ctx.Err()
+	case <-p.drained:
+		return p.Summary(), nil
+	}
+}
+
+// Results - opt in to one RepoResult per repo completed after the call,
+// closed once the pool drains. Call it before submitting jobs to see every
+// repo; once called the channel must be drained until closed as completing
+// repos block on it. Pools that only need Done / Wait never call Results.
+func (p *WorkerPool) Results() <-chan RepoResult {
+	p.resultsEnabled.Store(true)
+	return p.results
+}
+
+func (p *WorkerPool) Summary() Summary {
+	return p.summary.snapshot()
+}
+
+// complete - a repo and all of its items finished processing
+func (p *WorkerPool) complete(ctx context.Context, result RepoResult) {
+	defer p.pending.Done()
+	p.jobsInflight.Add(-1)
+	p.metrics.jobsInflight.Add(ctx, -1)
+	// a partial walk can't tell removed records from failed ones. Items
+	// engines deferred are settled by now: the repo only completes once
+	// every batch holding its rows has been written, and a failed write
+	// fails the repo, so synced never runs ahead of the repo's edges.
+	if p.synced != nil && result.Err == nil && result.Failed == 0 && result.Rev != "" {
+		if err := p.synced(ctx, result.DID, result.Rev); err != nil {
+			p.log.WithErrorMsg(err, "Error marking repo synced", "did", result.DID, "rev", result.Rev)
+			result.Err = err
+		}
+	}
+	p.summary.record(result)
+	p.progress.RepoDone(ctx, result)
+	if !p.resultsEnabled.Load() {
+		return
+	}
+	select {
+	case p.results <- result:
+	case <-p.shutdown:
+	}
+}
+
+// abandon - a submitted repo never made it onto the jobs queue
+func (p *WorkerPool) abandon(ctx context.Context) {
+	p.jobsInflight.Add(-1)
+	p.metrics.jobsInflight.Add(ctx, -1)
+	p.pending.Done()
+}
+
+// autoscaler - periodically resize repo / ingest workers within their bounds
+func (p *WorkerPool) autoscaler(ctx context.Context) {
+	ticker := time.NewTicker(p.autoscaleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.shutdown:
+			return
+		case <-ticker.C:
+			headroom := p.rateLimitState.Headroom()
+			p.rescale(ctx, p.repoWorkers, len(p.jobs), cap(p.jobs), headroom)
+			p.rescale(ctx, p.ingestWorkers, len(p.items), cap(p.items), headroom)
+		}
+	}
+}
+
+func (p *WorkerPool) rescale(ctx context.Context, workers *workerSet, queued, capacity int, headroom float64) {
+	current := workers.Size()
+	desired := workers.policy.Desired(current, queued, capacity, headroom)
+	if desired == current {
+		return
+	}
+	p.log.Info("Scaling workers",
+		"type", workers.kind,
+		"from", current,
+		"to", desired,
+		"queued", queued,
+		"capacity", capacity,
+		"headroom", headroom)
+	workers.scaleTo(ctx, p.group, desired)
+	p.metrics.scaleEvents.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("type", workers.kind),
+		attribute.Int("delta", desired-current),
+	))
+}
+
+func (p *WorkerPool) ingestWorker(ctx context.Context, workerID int, stop <-chan struct{}) error {
 	p.log.Info("Worker started", "type", "ingest", "worker-id", workerID)
 	defer p.log.Info("Worker shutting down", "type", "ingest", "worker-id", workerID)
 
@@ -154,7 +414,10 @@ func (p *WorkerPool) ingestWorker(ctx context.Context, workerID int) error {
 		case <-ctx.Done():
 			p.log.Info("Context cancelled", "type", "ingest", "worker-id", workerID)
 			return ctx.Err()
-		case <-p.done:
+		case <-stop:
+			p.log.Info("Worker scaled down", "type", "ingest", "worker-id", workerID)
+			return nil
+		case <-p.shutdown:
 			p.log.Info("Done channel closed", "type", "ingest", "worker-id", workerID)
 			return nil
 		case item, ok := <-p.items:
@@ -185,12 +448,15 @@ func (p *WorkerPool) ingestWorker(ctx context.Context, workerID int) error {
 				attribute.String("status", status),
 				attribute.String("action", "ingest"),
 			))
-			p.results <- err
+			p.progress.ItemDone(ctx, err)
+			if item.tracker != nil {
+				item.tracker.itemDone(err)
+			}
 		}
 	}
 }
 
-func (p *WorkerPool) repoWorker(ctx context.Context, workerID int) error {
+func (p *WorkerPool) repoWorker(ctx context.Context, workerID int, stop <-chan struct{}) error {
 	p.log.Info("Worker started", "type", "repo", "worker-id", workerID)
 	defer p.log.Info("Worker shutting down", "type", "repo", "worker-id", workerID)
 
@@ -199,7 +465,10 @@ func (p *WorkerPool) repoWorker(ctx context.Context, workerID int) error {
 		case <-ctx.Done():
 			p.log.Info("Context cancelled", "type", "repo", "worker-id", workerID)
 			return ctx.Err()
-		case <-p.done:
+		case <-stop:
+			p.log.Info("Worker scaled down", "type", "repo", "worker-id", workerID)
+			return nil
+		case <-p.shutdown:
 			p.log.Info("Done channel closed", "type", "repo", "worker-id", workerID)
 			return nil
 		case job, ok := <-p.jobs:
@@ -208,31 +477,42 @@ func (p *WorkerPool) repoWorker(ctx context.Context, workerID int) error {
 				return nil
 			}
 
+			if job.car != "" {
+				p.log.Info("Processing job",
+					"action", "read-car",
+					"type", "repo",
+					"worker-id", workerID,
+					"car", job.car)
+				// local files don't count against the PDS rate limit
+				err := p.readCAR(ctx, job)
+				if err != nil {
+					p.log.WithErrorMsg(err, "Error reading CAR",
+						"action", "read-car",
+						"type", "repo",
+						"worker-id", workerID,
+						"car", job.car)
+				}
+				job.tracker.walkDone(err)
+				continue
+			}
+
 			p.log.Info("Processing job",
 				"action", "get-repo",
 				"type", "repo",
 				"worker-id", workerID,
 				"did", job.repo.Did)
 
-			err := p.rateLimiter.WithRetry(ctx, ReadOperation, "getRepo", func() error {
-				if err := p.getRepo(ctx, job); err != nil {
-					if !suppressATProtoErr(err) {
-						p.log.WithErrorMsg(err, "Error getting repo",
-							"worker-id", workerID,
-							"did", job.repo.Did)
-					}
-					return err
-				}
-				return nil
-			})
-
+			// only the fetch is retried: repeating the walk would emit its items twice
+			err := p.getRepo(ctx, job)
 			if err != nil {
-				p.log.WithErrorMsg(err, "Retries exhausted",
+				p.log.WithErrorMsg(err, "Error getting repo",
 					"action", "get-repo",
 					"type", "repo",
 					"worker-id", workerID,
 					"did", job.repo.Did)
 			}
+			// all items for this repo have been queued for ingest
+			job.tracker.walkDone(err)
 		}
 	}
 }
@@ -245,7 +525,11 @@ func (p *WorkerPool) getRepo(ctx context.Context, job RepoJob) error {
 	if atid, err = syntax.ParseAtIdentifier(job.repo.Did); err != nil {
 		return err
 	}
-	if ident, err = identity.DefaultDirectory().Lookup(ctx, *atid); err != nil {
+	dir := p.directory
+	if dir == nil {
+		dir = identity.DefaultDirectory()
+	}
+	if ident, err = dir.Lookup(ctx, *atid); err != nil {
 		return err
 	}
 	xrpcc := xrpc.Client{
@@ -255,28 +539,102 @@ func (p *WorkerPool) getRepo(ctx context.Context, job RepoJob) error {
 	if xrpcc.Host == "" {
 		return fmt.Errorf("no PDS endpoint for identity: %s", atid)
 	}
-	if repoData, err = atproto.SyncGetRepo(ctx, &xrpcc, ident.DID.String(), ""); err != nil {
-		if !suppressATProtoErr(err) {
-			p.log.WithErrorMsg(err, "Error fetching bsky repo")
-		}
+	var fetchErr error
+	if err = p.rateLimiter.WithRetry(ctx, ReadOperation, "getRepo", func() error {
+		repoData, fetchErr = atproto.SyncGetRepo(ctx, &xrpcc, ident.DID.String(), "")
+		return fetchErr
+	}); err != nil {
 		return err
 	}
+	if fetchErr != nil && suppressATProtoErr(fetchErr) {
+		// taken down / deactivated repos have nothing to walk
+		return nil
+	}
+	p.progress.Fetched(ctx, len(repoData))
 	var r *repo.Repo
 	if r, err = repo.ReadRepoFromCar(context.Background(), bytes.NewReader(repoData)); err != nil {
 		p.log.WithErrorMsg(err, "Error reading bsky repo")
 		return err
 	}
+	if p.archive != nil {
+		// a missing archive copy only costs a refetch on replay
+		sc := r.SignedCommit()
+		if _, err = p.archive.PutRepo(sc.Did, sc.Rev, ident.Handle.String(), repoData); err != nil {
+			p.log.WithErrorMsg(err, "Error archiving bsky repo", "did", sc.Did, "rev", sc.Rev)
+		}
+	}
 
-	if err = resolveLexicon(ctx, ident, r, p.items); err != nil {
-		// Unwrap error to check if it's a LexiconError
-		if unwrappedErr := errors.Unwrap(err); unwrappedErr != nil {
-			var lexErr *LexiconError
-			if errors.As(unwrappedErr, &lexErr) {
-				p.log.With(
-					"did", job.repo.Did,
-					"lexicon-type", lexErr.nsid.Name()).Debug("Skipping due to lexicon error")
-			}
+	return p.walkRepo(ctx, job, ident, r)
+}
+
+// readCAR - read a repo exported to a CAR file, resolving its identity
+// only when the pool has a directory
+func (p *WorkerPool) readCAR(ctx context.Context, job RepoJob) error {
+	f, err := os.Open(job.car)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var info os.FileInfo
+	if info, err = f.Stat(); err != nil {
+		return err
+	}
+	var r *repo.Repo
+	if r, err = repo.ReadRepoFromCar(ctx, bufio.NewReader(f)); err != nil {
+		return err
+	}
+	p.progress.Fetched(ctx, int(info.Size()))
+	var did syntax.DID
+	if did, err = syntax.ParseDID(r.SignedCommit().Did); err != nil {
+		return err
+	}
+	return p.walkRepo(ctx, job, p.carIdentity(ctx, did, job.handle), r)
+}
+
+// carIdentity - the resolved identity of an imported repo when a directory is
+// configured and the lookup succeeds, otherwise an offline one with the
+// archived handle or handle.invalid when there isn't one
+func (p *WorkerPool) carIdentity(ctx context.Context, did syntax.DID, archived string) *identity.Identity {
+	if p.directory != nil {
+		ident, err := p.directory.LookupDID(ctx, did)
+		if err == nil {
+			return ident
+		}
+		p.log.WithErrorMsg(err, "Error resolving identity - importing offline", "did", did.String())
+	}
+	handle, err := syntax.ParseHandle(archived)
+	if err != nil {
+		handle = syntax.HandleInvalid
+	}
+	return &identity.Identity{DID: did, Handle: handle}
+}
+
+// walkRepo - queue every supported record of a fetched or imported repo for ingest
+func (p *WorkerPool) walkRepo(ctx context.Context, job RepoJob, ident *identity.Identity, r *repo.Repo) error {
+	sc := r.SignedCommit()
+	if job.tracker != nil {
+		job.tracker.walked(sc.Did, sc.Rev)
+	}
+	// ingest workers stop reading items on shutdown - stop the walk with them
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-p.shutdown:
+			cancel()
+		case <-ctx.Done():
 		}
+	}()
+	skipped, err := resolveLexicon(ctx, ident, r, job.tracker, p.items)
+	for nsid, count := range skipped {
+		p.log.With(
+			"did", sc.Did,
+			"lexicon-type", nsid.Name(),
+			"records", count).Debug("Skipping unsupported lexicon")
+	}
+	if err != nil {
+		p.log.WithErrorMsg(err, "Error walking bsky repo", "did", sc.Did)
+		return err
 	}
 
 	return nil
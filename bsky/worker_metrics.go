@@ -14,6 +14,8 @@ type WorkerMetrics struct {
 	resultsQueued metric.Int64Gauge
 	jobsInflight  metric.Int64UpDownCounter
 	itemsCount    metric.Int64Counter
+	workers       metric.Int64Gauge
+	scaleEvents   metric.Int64Counter
 }
 
 func NewWorkerMetrics(ctx context.Context) (*WorkerMetrics, error) {
@@ -73,11 +75,31 @@ func NewWorkerMetrics(ctx context.Context) (*WorkerMetrics, error) {
 		return nil, err
 	}
 
+	workers, err := meter.Int64Gauge(
+		"bsky.worker.workers",
+		metric.WithDescription("Number of running workers by type"),
+		metric.WithUnit("{workers}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	scaleEvents, err := meter.Int64Counter(
+		"bsky.worker.scale_events",
+		metric.WithDescription("Autoscaler resize events by worker type"),
+		metric.WithUnit("{events}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return &WorkerMetrics{
 		jobsQueued:    jobsQueued,
 		itemsQueued:   itemsQueued,
 		resultsQueued: resultsQueued,
 		jobsInflight:  jobsInflight,
 		itemsCount:    itemsCount,
+		workers:       workers,
+		scaleEvents:   scaleEvents,
 	}, nil
 }
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+
+	"github.com/mikeblum/atgraph.dev/conf"
+	"github.com/mikeblum/atgraph.dev/graph"
+	"github.com/mikeblum/atgraph.dev/graph/analyze"
+	"github.com/mikeblum/atgraph.dev/o11y"
+)
+
+// analyze - PageRank, communities and follow recommendations over the follow
+// graph, written back to every engine selected via ATGRAPH_ENGINES
+func analyzeCommand() *Command {
+	return &Command{
+		Name:    CMD_ANALYZE,
+		Summary: "compute PageRank, communities and follow recommendations",
+		Setup: func(fs *flag.FlagSet) func(context.Context, io.Writer, []string) error {
+			envFlag(fs, "iterations", analyze.ENV_ATGRAPH_ANALYZE_ITERATIONS, "maximum PageRank iterations")
+			envFlag(fs, "damping", analyze.ENV_ATGRAPH_ANALYZE_DAMPING, "PageRank damping factor")
+			envFlag(fs, "recommendations", analyze.ENV_ATGRAPH_ANALYZE_RECOMMENDATIONS, "recommendations per profile, 0 skips them")
+			envFlag(fs, "seed", analyze.ENV_ATGRAPH_ANALYZE_SEED, "community detection seed")
+			dryRun := fs.Bool("dry-run", false, "compute without writing scores or recommendations")
+			return func(ctx context.Context, out io.Writer, args []string) error {
+				if len(args) > 0 {
+					return usageErrorf("unexpected arguments %q", args)
+				}
+				return runAnalyze(ctx, *dryRun)
+			}
+		},
+	}
+}
+
+func runAnalyze(ctx context.Context, dryRun bool) error {
+	log := conf.NewLog()
+	var err error
+
+	// configure o11y
+	if _, err = o11y.NewO11y(ctx, log); err != nil {
+		log.WithErrorMsg(err, "Error bootstrapping OTEL o11y")
+		return err
+	}
+	defer o11y.Cleanup(context.Background())
+
+	var graphConf *graph.Conf
+	if graphConf, err = graph.NewConf(); err != nil {
+		return err
+	}
+	var cfg *analyze.Conf
+	if cfg, err = analyze.NewConf(); err != nil {
+		return err
+	}
+	var engine *graph.CompositeEngine
+	if engine, err = graph.Open(ctx, graphConf); err != nil {
+		log.WithErrorMsg(err, "Error bootstrapping graph engines", "registered", graph.Engines())
+		return err
+	}
+	log.With("engines", engine.Backends()).Info("Graph engines ready")
+
+	var writer graph.ScoreWriter = engine
+	if dryRun {
+		writer = discardScores{}
+	} else if err = engine.LoadSchema(ctx); err != nil {
+		// apply pending schema migrations for the analytics tables / indexes
+		log.WithErrorMsg(err, "Error migrating schema")
+		engine.Close(context.Background())
+		return err
+	}
+
+	summary, err := analyze.Run(ctx, cfg, engine, writer)
+	// close flushes buffered scores - don't let a cancelled ctx drop them
+	err = errors.Join(err, engine.Close(context.Background()))
+	if err != nil {
+		log.WithErrorMsg(err, "Error analyzing follow graph ❌")
+		return err
+	}
+	log.With(
+		"nodes", summary.Nodes,
+		"edges", summary.Edges,
+		"communities", summary.Communities,
+		"recommendations", summary.Recommendations,
+		"elapsed", summary.Elapsed,
+		"dry-run", dryRun,
+	).Info("Follow graph analysis successful ✅")
+	return nil
+}
+
+// discardScores - -dry-run drops the results
+type discardScores struct{}
+
+func (discardScores) WriteScores(context.Context, []graph.Score) error { return nil }
+func (discardScores) WriteRecommendations(context.Context, []graph.Recommendations) error {
+	return nil
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+)
+
+// App - the atgraph command and its subcommands
+type App struct {
+	Name     string
+	Stdout   io.Writer
+	Stderr   io.Writer
+	Commands []*Command
+}
+
+// Command - a subcommand. Setup registers its flags on fs and returns the
+// body run once they've been parsed.
+type Command struct {
+	Name    string
+	Args    string
+	Summary string
+	// skip validating the configuration before the command runs
+	NoConfig bool
+	Setup    func(fs *flag.FlagSet) func(ctx context.Context, out io.Writer, args []string) error
+}
+
+func New() *App {
+	return &App{
+		Name:   APP_NAME,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Commands: []*Command{
+			syncCommand(),
+			firehoseCommand(),
+			profileCommand(),
+			migrateCommand(),
+			exportCommand(),
+			analyzeCommand(),
+			pathCommand(),
+			serveCommand(),
+			configCommand(),
+			versionCommand(),
+		},
+	}
+}
+
+// Main - atgraph [global flags] <command> [flags] [args], returning the exit code
+func (a *App) Main(ctx context.Context, args []string) int {
+	fs := a.flagSet(a.Name, a.usage)
+	if err := fs.Parse(args); err != nil {
+		return parseExitCode(err)
+	}
+	if fs.NArg() == 0 {
+		a.usage()
+		return EXIT_USAGE
+	}
+	name, rest := fs.Arg(0), fs.Args()[1:]
+	if name == CMD_HELP {
+		if len(rest) == 0 {
+			a.usage()
+			return EXIT_OK
+		}
+		// help <command> is <command> -h
+		name, rest = rest[0], []string{"-h"}
+	}
+	return a.Run(ctx, name, rest)
+}
+
+// Run - a single subcommand with its own flags and args, returning the exit code.
+// SIGINT / SIGTERM cancel ctx.
+func (a *App) Run(ctx context.Context, name string, args []string) int {
+	cmd := a.command(name)
+	if cmd == nil {
+		fmt.Fprintf(a.Stderr, "%s: unknown command %q\n\n", a.Name, name)
+		a.usage()
+		return EXIT_USAGE
+	}
+	fs := a.flagSet(a.Name+" "+cmd.Name, nil)
+	body := cmd.Setup(fs)
+	fs.Usage = func() {
+		synopsis := strings.TrimSpace(fmt.Sprintf("%s %s [flags] %s", a.Name, cmd.Name, cmd.Args))
+		fmt.Fprintf(a.Stderr, "%s\n\nusage: %s\n\nflags:\n", cmd.Summary, synopsis)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return parseExitCode(err)
+	}
+
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	var err error
+	if !cmd.NoConfig {
+		err = validateConfig()
+	}
+	if err == nil {
+		err = body(ctx, a.Stdout, fs.Args())
+	}
+	if errors.Is(err, ErrUsage) {
+		fmt.Fprintf(a.Stderr, "%s %s: %v\n\n", a.Name, cmd.Name, err)
+		fs.Usage()
+	}
+	return a.exitCode(ctx, cmd.Name, err)
+}
+
+// exitCode - EXIT_OK, EXIT_USAGE for bad flags / args, EXIT_CONFIG for bad
+// settings, EXIT_INTERRUPTED once a signal cancelled ctx, otherwise EXIT_ERROR
+func (a *App) exitCode(ctx context.Context, name string, err error) int {
+	switch {
+	case err == nil:
+		return EXIT_OK
+	case errors.Is(err, ErrUsage):
+		return EXIT_USAGE
+	case errors.Is(err, ErrConfig):
+		fmt.Fprintf(a.Stderr, "%s %s: %v\n", a.Name, name, err)
+		return EXIT_CONFIG
+	case ctx.Err() != nil:
+		return EXIT_INTERRUPTED
+	}
+	fmt.Fprintf(a.Stderr, "%s %s: %v\n", a.Name, name, err)
+	return EXIT_ERROR
+}
+
+func (a *App) command(name string) *Command {
+	for _, cmd := range a.Commands {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// flagSet - flags that return errors rather than exiting, with the global flags registered
+func (a *App) flagSet(name string, usage func()) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.Stderr)
+	if usage != nil {
+		fs.Usage = usage
+	}
+	globalFlags(fs)
+	return fs
+}
+
+// parseExitCode - -h / -help is a success, anything else was already
+// reported by the flag set along with the usage
+func parseExitCode(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return EXIT_OK
+	}
+	return EXIT_USAGE
+}
+
+func (a *App) usage() {
+	fmt.Fprintf(a.Stderr, "usage: %s [flags] <command> [flags] [args]\n\ncommands:\n", a.Name)
+	w := tabwriter.NewWriter(a.Stderr, 0, 4, 2, ' ', 0)
+	for _, cmd := range a.Commands {
+		fmt.Fprintf(w, "  %s\t%s\n", cmd.Name, cmd.Summary)
+	}
+	fmt.Fprintf(w, "  %s\t%s\n", CMD_HELP, "help for a command")
+	w.Flush()
+	fmt.Fprintf(a.Stderr, "\nflags (also accepted after the command):\n")
+	fs := flag.NewFlagSet(a.Name, flag.ContinueOnError)
+	fs.SetOutput(a.Stderr)
+	globalFlags(fs)
+	fs.PrintDefaults()
+	fmt.Fprintf(a.Stderr, "\nRun '%s help <command>' for its flags.\n", a.Name)
+	fmt.Fprintf(a.Stderr, "Settings resolve from flags, then the environment, .env, the -config file and finally defaults.\n")
+}
+
+// usageErrorf - bad arguments, reported with the command's usage
+func usageErrorf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrUsage, fmt.Sprintf(format, args...))
+}
+
+// joinArgs - synopsis of the accepted values
+func joinArgs(values ...string) string {
+	return strings.Join(values, "|")
+}
@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikeblum/atgraph.dev/bsky"
+	"github.com/mikeblum/atgraph.dev/conf"
+	"github.com/mikeblum/atgraph.dev/graph/analyze"
+	"github.com/mikeblum/atgraph.dev/graph/clickhouse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ENV_CLI_TEST = "ATGRAPH_CLI_TEST"
+
+func TestCLI(t *testing.T) {
+	t.Run("no command prints usage", noCommandTest)
+	t.Run("help lists commands", helpTest)
+	t.Run("help <command> prints its flags", helpCommandTest)
+	t.Run("unknown command is a usage error", unknownCommandTest)
+	t.Run("bad flags are a usage error", badFlagTest)
+	t.Run("command errors exit 1", commandErrorTest)
+	t.Run("cancelled commands exit 130", interruptedTest)
+	t.Run("flags override the environment", envFlagTest)
+	t.Run("version", versionTest)
+	t.Run("config prints settings with secrets redacted", configDumpTest)
+	t.Run("invalid config exits 78", configInvalidTest)
+	t.Run("migrate rejects unknown actions", migrateUsageTest)
+	t.Run("export dates and datetimes", parseTimeTest)
+}
+
+// appTest - an App with a single echo command whose body returns err
+func appTest(err error) (*App, *bytes.Buffer, *bytes.Buffer) {
+	var stdout, stderr bytes.Buffer
+	app := New()
+	app.Stdout, app.Stderr = &stdout, &stderr
+	app.Commands = append(app.Commands, &Command{
+		Name:    "echo",
+		Args:    "[args]",
+		Summary: "echo args",
+		Setup: func(fs *flag.FlagSet) func(context.Context, io.Writer, []string) error {
+			envFlag(fs, "value", ENV_CLI_TEST, "value to echo")
+			upper := fs.Bool("upper", false, "upper case")
+			return func(ctx context.Context, out io.Writer, args []string) error {
+				if err != nil {
+					return err
+				}
+				line := strings.Join(append(args, conf.NewEnvConf().GetEnv(ENV_CLI_TEST, "")), " ")
+				if *upper {
+					line = strings.ToUpper(line)
+				}
+				_, err := io.WriteString(out, line)
+				return err
+			}
+		},
+	})
+	return app, &stdout, &stderr
+}
+
+func noCommandTest(t *testing.T) {
+	app, _, stderr := appTest(nil)
+	assert.Equal(t, EXIT_USAGE, app.Main(context.Background(), nil))
+	assert.Contains(t, stderr.String(), "usage: atgraph")
+}
+
+func helpTest(t *testing.T) {
+	for _, args := range [][]string{{CMD_HELP}, {"-h"}, {"--help"}} {
+		app, _, stderr := appTest(nil)
+		assert.Equal(t, EXIT_OK, app.Main(context.Background(), args), args)
+		for _, name := range []string{CMD_SYNC, CMD_FIREHOSE, CMD_PROFILE, CMD_MIGRATE, CMD_EXPORT, CMD_SERVE, CMD_VERSION} {
+			assert.Contains(t, stderr.String(), name, args)
+		}
+	}
+}
+
+func helpCommandTest(t *testing.T) {
+	app, _, stderr := appTest(nil)
+	assert.Equal(t, EXIT_OK, app.Main(context.Background(), []string{CMD_HELP, "echo"}))
+	assert.Contains(t, stderr.String(), "usage: atgraph echo [flags] [args]")
+	assert.Contains(t, stderr.String(), "-upper")
+	assert.Contains(t, stderr.String(), ENV_CLI_TEST)
+	// global flags are accepted by every command
+	assert.Contains(t, stderr.String(), "-engines")
+}
+
+func unknownCommandTest(t *testing.T) {
+	app, _, stderr := appTest(nil)
+	assert.Equal(t, EXIT_USAGE, app.Main(context.Background(), []string{"bogus"}))
+	assert.Contains(t, stderr.String(), `unknown command "bogus"`)
+}
+
+func badFlagTest(t *testing.T) {
+	app, _, _ := appTest(nil)
+	assert.Equal(t, EXIT_USAGE, app.Main(context.Background(), []string{"echo", "-bogus"}))
+	app, _, _ = appTest(nil)
+	assert.Equal(t, EXIT_USAGE, app.Main(context.Background(), []string{"-bogus", "echo"}))
+	app, _, stderr := appTest(usageErrorf("missing thing"))
+	assert.Equal(t, EXIT_USAGE, app.Main(context.Background(), []string{"echo"}))
+	assert.Contains(t, stderr.String(), "missing thing")
+	assert.Contains(t, stderr.String(), "usage: atgraph echo")
+}
+
+func commandErrorTest(t *testing.T) {
+	app, _, stderr := appTest(errors.New("boom"))
+	assert.Equal(t, EXIT_ERROR, app.Main(context.Background(), []string{"echo"}))
+	assert.Contains(t, stderr.String(), "atgraph echo: boom")
+}
+
+func interruptedTest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	app, _, _ := appTest(context.Canceled)
+	assert.Equal(t, EXIT_INTERRUPTED, app.Run(ctx, "echo", nil))
+}
+
+func envFlagTest(t *testing.T) {
+	t.Setenv(ENV_CLI_TEST, "from-env")
+	app, stdout, _ := appTest(nil)
+	require.Equal(t, EXIT_OK, app.Main(context.Background(), []string{"echo", "-upper", "hello"}))
+	assert.Equal(t, "HELLO FROM-ENV", stdout.String())
+
+	app, stdout, _ = appTest(nil)
+	require.Equal(t, EXIT_OK, app.Main(context.Background(), []string{"echo", "--value", "from-flag", "hello"}))
+	assert.Equal(t, "hello from-flag", stdout.String())
+	assert.Equal(t, "from-flag", conf.NewEnvConf().GetEnv(ENV_CLI_TEST, ""))
+}
+
+func versionTest(t *testing.T) {
+	app, stdout, _ := appTest(nil)
+	require.Equal(t, EXIT_OK, app.Main(context.Background(), []string{CMD_VERSION}))
+	assert.True(t, strings.HasPrefix(stdout.String(), APP_NAME+" "))
+	app, _, _ = appTest(nil)
+	assert.Equal(t, EXIT_USAGE, app.Main(context.Background(), []string{CMD_VERSION, "extra"}))
+}
+
+func configDumpTest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "atgraph.toml")
+	require.Nil(t, os.WriteFile(path, []byte("[bsky]\npage_size = 250\n[clickhouse]\npassword = \"hunter2\"\n"), 0o600))
+	t.Setenv(conf.ENV_ATGRAPH_CONFIG, path)
+	t.Setenv(bsky.ENV_BSKY_WORKER_COUNT, "7")
+	app, stdout, _ := appTest(nil)
+	require.Equal(t, EXIT_OK, app.Main(context.Background(), []string{CMD_CONFIG}))
+	assert.Contains(t, stdout.String(), "# "+conf.ENV_ATGRAPH_CONFIG+"="+path)
+	for _, section := range []string{"# bsky", "# archive", "# graph", "# clickhouse", "# neo4j", "# analyze", "# export", "# server", "# o11y"} {
+		assert.Contains(t, stdout.String(), section)
+	}
+	assert.Regexp(t, `BSKY_PAGE_SIZE=250\s+# file`, stdout.String())
+	assert.Regexp(t, `BSKY_WORKER_COUNT=7\s+# env`, stdout.String())
+	assert.Regexp(t, `CLICKHOUSE_PASSWORD=<redacted>\s+# file`, stdout.String())
+	assert.NotContains(t, stdout.String(), "hunter2")
+}
+
+func configInvalidTest(t *testing.T) {
+	t.Setenv(bsky.ENV_BSKY_PAGE_SIZE, "lots")
+	t.Setenv(clickhouse.ENV_CLICKHOUSE_COMPRESSION, "snappy")
+	t.Setenv(analyze.ENV_ATGRAPH_ANALYZE_DAMPING, "1.5")
+	app, stdout, stderr := appTest(nil)
+	assert.Equal(t, EXIT_CONFIG, app.Main(context.Background(), []string{"echo", "hello"}))
+	// the command never ran
+	assert.Empty(t, stdout.String())
+	assert.Contains(t, stderr.String(), "atgraph echo: invalid configuration")
+	assert.Contains(t, stderr.String(), `BSKY_PAGE_SIZE="lots" (env): invalid integer`)
+	assert.Contains(t, stderr.String(), `CLICKHOUSE_COMPRESSION="snappy" (env): expected one of lz4, zstd, none`)
+	assert.Contains(t, stderr.String(), `ATGRAPH_ANALYZE_DAMPING="1.5" (env): must be at most 1`)
+
+	app, _, _ = appTest(nil)
+	assert.Equal(t, EXIT_CONFIG, app.Main(context.Background(), []string{CMD_CONFIG, "-validate"}))
+	// version doesn't need a valid config
+	app, _, _ = appTest(nil)
+	assert.Equal(t, EXIT_OK, app.Main(context.Background(), []string{CMD_VERSION}))
+}
+
+func migrateUsageTest(t *testing.T) {
+	app, _, stderr := appTest(nil)
+	assert.Equal(t, EXIT_USAGE, app.Main(context.Background(), []string{CMD_MIGRATE, "sideways"}))
+	assert.Contains(t, stderr.String(), `unknown action "sideways"`)
+	app, _, _ = appTest(nil)
+	assert.Equal(t, EXIT_USAGE, app.Main(context.Background(), []string{CMD_MIGRATE, MIGRATE_UP, "extra"}))
+}
+
+func parseTimeTest(t *testing.T) {
+	zero, err := parseTime("")
+	require.Nil(t, err)
+	assert.True(t, zero.IsZero())
+	date, err := parseTime("2024-01-02")
+	require.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), date)
+	datetime, err := parseTime("2024-01-02T03:04:05Z")
+	require.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), datetime)
+	_, err = parseTime("yesterday")
+	assert.Error(t, err)
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mikeblum/atgraph.dev/archive"
+	"github.com/mikeblum/atgraph.dev/bsky"
+	"github.com/mikeblum/atgraph.dev/conf"
+	"github.com/mikeblum/atgraph.dev/graph"
+	"github.com/mikeblum/atgraph.dev/graph/analyze"
+	"github.com/mikeblum/atgraph.dev/graph/clickhouse"
+	"github.com/mikeblum/atgraph.dev/graph/export"
+	"github.com/mikeblum/atgraph.dev/graph/neo4j"
+	"github.com/mikeblum/atgraph.dev/o11y"
+	"github.com/mikeblum/atgraph.dev/server"
+)
+
+// section - a package's typed settings, validated before commands run
+type section struct {
+	name string
+	load func() (conf.EnvConf, any, error)
+}
+
+func sections() []section {
+	return []section{
+		{"bsky", func() (conf.EnvConf, any, error) {
+			c, err := bsky.NewConf()
+			return c.EnvConf, c.Config(), err
+		}},
+		{"archive", func() (conf.EnvConf, any, error) {
+			c, err := archive.NewConf()
+			return c.EnvConf, c.Config(), err
+		}},
+		{"graph", func() (conf.EnvConf, any, error) {
+			c, err := graph.NewConf()
+			return c.EnvConf, c.Config(), err
+		}},
+		{"clickhouse", func() (conf.EnvConf, any, error) {
+			c, err := clickhouse.NewConf()
+			return c.EnvConf, c.Config(), err
+		}},
+		{"neo4j", func() (conf.EnvConf, any, error) {
+			c, err := neo4j.NewConf()
+			return c.EnvConf, c.Config(), err
+		}},
+		{"analyze", func() (conf.EnvConf, any, error) {
+			c, err := analyze.NewConf()
+			return c.EnvConf, c.Config(), err
+		}},
+		{"export", func() (conf.EnvConf, any, error) {
+			c, err := export.NewConf()
+			return c.EnvConf, c.Config(), err
+		}},
+		{"server", func() (conf.EnvConf, any, error) {
+			c, err := server.NewConf()
+			return c.EnvConf, c.Config(), err
+		}},
+		{"o11y", func() (conf.EnvConf, any, error) {
+			c, err := o11y.NewConf()
+			return c.EnvConf, c.Config(), err
+		}},
+	}
+}
+
+// config - print the resolved settings, secrets redacted
+func configCommand() *Command {
+	return &Command{
+		Name:     CMD_CONFIG,
+		Summary:  "print the resolved settings and where they came from, secrets redacted",
+		NoConfig: true,
+		Setup: func(fs *flag.FlagSet) func(context.Context, io.Writer, []string) error {
+			validateOnly := fs.Bool("validate", false, "only check the settings")
+			return func(ctx context.Context, out io.Writer, args []string) error {
+				if len(args) > 0 {
+					return usageErrorf("unexpected arguments %q", args)
+				}
+				if *validateOnly {
+					return validateConfig()
+				}
+				return dumpConfig(out)
+			}
+		},
+	}
+}
+
+// validateConfig - ErrConfig listing every invalid setting across the sections
+func validateConfig() error {
+	errs := []error{conf.NewEnvConf().Load()}
+	for _, s := range sections() {
+		_, _, err := s.load()
+		errs = append(errs, err)
+	}
+	return configError(errors.Join(errs...))
+}
+
+func dumpConfig(out io.Writer) error {
+	env := conf.NewEnvConf()
+	errs := []error{env.Load()}
+	if path, source := env.Lookup(conf.ENV_ATGRAPH_CONFIG); source != conf.SOURCE_DEFAULT {
+		fmt.Fprintf(out, "# %s=%s (%s)\n", conf.ENV_ATGRAPH_CONFIG, path, source)
+	}
+	fmt.Fprintf(out, "# precedence: %s > %s > %s > %s > %s\n", conf.SOURCE_FLAG, conf.SOURCE_ENV, conf.SOURCE_DOTENV, conf.SOURCE_FILE, conf.SOURCE_DEFAULT)
+	for _, s := range sections() {
+		env, cfg, err := s.load()
+		errs = append(errs, err)
+		fmt.Fprintf(out, "\n# %s\n", s.name)
+		if err := conf.Dump(out, conf.Settings(env, cfg)); err != nil {
+			return err
+		}
+	}
+	return configError(errors.Join(errs...))
+}
+
+// configError - err wrapped in ErrConfig, one indented line per setting
+func configError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%w:\n  %s", ErrConfig, strings.ReplaceAll(err.Error(), "\n", "\n  "))
+}
@@ -0,0 +1,36 @@
+package cli
+
+import "errors"
+
+const (
+	APP_NAME = "atgraph"
+
+	// commands
+	CMD_SYNC     = "sync"
+	CMD_FIREHOSE = "firehose"
+	CMD_PROFILE  = "profile"
+	CMD_MIGRATE  = "migrate"
+	CMD_EXPORT   = "export"
+	CMD_ANALYZE  = "analyze"
+	CMD_PATH     = "path"
+	CMD_SERVE    = "serve"
+	CMD_VERSION  = "version"
+	CMD_CONFIG   = "config"
+	CMD_HELP     = "help"
+
+	// exit codes shared by every command
+	EXIT_OK = 0
+	// the command ran and failed
+	EXIT_ERROR = 1
+	// unknown command, bad flags or arguments
+	EXIT_USAGE = 2
+	// invalid settings or config file - sysexits EX_CONFIG
+	EXIT_CONFIG = 78
+	// SIGINT / SIGTERM - 128 + SIGINT as shells report it
+	EXIT_INTERRUPTED = 130
+)
+
+var (
+	ErrUsage  = errors.New("invalid usage")
+	ErrConfig = errors.New("invalid configuration")
+)
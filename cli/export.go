@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/mikeblum/atgraph.dev/bsky"
+	"github.com/mikeblum/atgraph.dev/conf"
+	"github.com/mikeblum/atgraph.dev/graph"
+	"github.com/mikeblum/atgraph.dev/graph/export"
+)
+
+// export - stream profiles, follows and blocks from the first engine selected
+// via ATGRAPH_ENGINES into Parquet, GraphML, GEXF or neo4j-admin CSV files
+func exportCommand() *Command {
+	return &Command{
+		Name:    CMD_EXPORT,
+		Summary: "export profiles, follows and blocks to Parquet, GraphML, GEXF or neo4j-admin CSV",
+		Setup: func(fs *flag.FlagSet) func(context.Context, io.Writer, []string) error {
+			var identifiers listFlag
+			envFlag(fs, "format", export.ENV_ATGRAPH_EXPORT_FORMAT, "one of "+strings.Join(export.Formats(), ", "))
+			envFlag(fs, "out", export.ENV_ATGRAPH_EXPORT_DIR, "directory the export files are written to")
+			envFlag(fs, "row-group", export.ENV_ATGRAPH_EXPORT_ROW_GROUP, "rows buffered per Parquet row group")
+			fs.Var(&identifiers, "did", "only export this account and edges between the accounts given (repeatable, DID or handle)")
+			didsFile := fs.String("dids", "", "file of DIDs or handles, one per line, added to -did")
+			since := fs.String("since", "", "only edges created at or after this date / datetime")
+			until := fs.String("until", "", "only edges created before this date / datetime")
+			current := fs.Bool("current", false, "skip edges removed from their repo")
+			dryRun := fs.Bool("dry-run", false, "count the rows that would be exported without writing files")
+			return func(ctx context.Context, out io.Writer, args []string) error {
+				if len(args) > 0 {
+					return usageErrorf("unexpected arguments %q", args)
+				}
+				filter := graph.ExportFilter{Current: *current}
+				var err error
+				if filter.Since, err = parseTime(*since); err != nil {
+					return usageErrorf("-since: %v", err)
+				}
+				if filter.Until, err = parseTime(*until); err != nil {
+					return usageErrorf("-until: %v", err)
+				}
+				if *didsFile != "" {
+					if err = readIdentifiers(*didsFile, &identifiers); err != nil {
+						return usageErrorf("-dids: %v", err)
+					}
+				}
+				for _, identifier := range identifiers {
+					did, err := bsky.ResolveDID(ctx, nil, identifier)
+					if err != nil {
+						return usageErrorf("-did %s: %v", identifier, err)
+					}
+					filter.DIDs = append(filter.DIDs, did.String())
+				}
+				return runExport(ctx, filter, *dryRun)
+			}
+		},
+	}
+}
+
+func runExport(ctx context.Context, filter graph.ExportFilter, dryRun bool) error {
+	log := conf.NewLog()
+	cfg, err := export.NewConf()
+	if err != nil {
+		return err
+	}
+	format, dir := cfg.Format(), cfg.Dir()
+
+	graphConf, err := graph.NewConf()
+	if err != nil {
+		return err
+	}
+	engine, err := graph.Open(ctx, graphConf)
+	if err != nil {
+		log.WithErrorMsg(err, "Error bootstrapping graph engines", "registered", graph.Engines())
+		return err
+	}
+	defer engine.Close(context.Background())
+
+	var sink export.Sink = discardSink{}
+	if !dryRun {
+		if sink, err = export.NewSink(format, dir, cfg.RowGroup()); err != nil {
+			log.WithErrorMsg(err, "Error creating export files", "format", format, "out", dir)
+			return err
+		}
+	}
+	summary, err := export.Run(ctx, engine, sink, filter)
+	if err != nil {
+		log.WithErrorMsg(err, "Error exporting graph ❌", "format", format, "out", dir)
+		return err
+	}
+	log.With(
+		"format", format,
+		"out", dir,
+		"profiles", summary.Profiles,
+		"follows", summary.Follows,
+		"blocks", summary.Blocks,
+		"elapsed", summary.Elapsed,
+		"dry-run", dryRun,
+	).Info("Graph export successful ✅")
+	return nil
+}
+
+// discardSink - counts rows for -dry-run without writing them
+type discardSink struct{}
+
+func (discardSink) Profile(graph.Profile) error { return nil }
+func (discardSink) Follow(graph.Follow) error   { return nil }
+func (discardSink) Block(graph.Block) error     { return nil }
+func (discardSink) Close() error                { return nil }
+
+// readIdentifiers - one identifier per line, blank lines and # comments skipped
+func readIdentifiers(path string, identifiers *listFlag) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		*identifiers = append(*identifiers, line)
+	}
+	return scanner.Err()
+}
+
+// parseTime - zero for an empty value, otherwise a date (UTC midnight) or datetime
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	dt, err := syntax.ParseDatetimeLenient(value)
+	if err != nil {
+		return time.Time{}, errors.New("expected a date (2006-01-02) or datetime (2006-01-02T15:04:05Z)")
+	}
+	return dt.Time(), nil
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"io"
+
+	"github.com/mikeblum/atgraph.dev/archive"
+	"github.com/mikeblum/atgraph.dev/bsky"
+	"github.com/mikeblum/atgraph.dev/conf"
+)
+
+// firehose - stream the relay firehose, optionally archiving every frame, or
+// replay an archived firehose instead of connecting
+func firehoseCommand() *Command {
+	return &Command{
+		Name:    CMD_FIREHOSE,
+		Summary: "stream (and archive) the relay firehose or replay an archived one",
+		Setup: func(fs *flag.FlagSet) func(context.Context, io.Writer, []string) error {
+			envFlag(fs, "archive", archive.ENV_ATGRAPH_ARCHIVE_DIR, "append firehose frames to segments in this directory")
+			envFlag(fs, "segment-size", archive.ENV_ATGRAPH_ARCHIVE_SEGMENT_SIZE, "bytes per archived firehose segment")
+			replay := fs.Bool("replay", false, "replay the frames archived in -archive instead of streaming")
+			since := fs.Int64("since", 0, "only replay frames after this seq")
+			return func(ctx context.Context, out io.Writer, args []string) error {
+				if len(args) > 0 {
+					return usageErrorf("unexpected arguments %q", args)
+				}
+				return runFirehose(ctx, *replay, *since)
+			}
+		},
+	}
+}
+
+func runFirehose(ctx context.Context, replay bool, since int64) error {
+	log := conf.NewLog()
+	cfg, err := archive.NewConf()
+	if err != nil {
+		return err
+	}
+	if replay && cfg.Dir() == "" {
+		return usageErrorf("-replay requires -archive or %s", archive.ENV_ATGRAPH_ARCHIVE_DIR)
+	}
+	firehose := bsky.NewFirehose()
+	var a *archive.Archive
+	if cfg.Dir() != "" {
+		if a, err = archive.Open(cfg.Dir()); err != nil {
+			log.WithErrorMsg(err, "Error opening archive", "archive", cfg.Dir())
+			return err
+		}
+	}
+
+	if replay {
+		if err = firehose.Replay(ctx, a, since); err != nil {
+			log.WithErrorMsg(err, "Error replaying archived firehose", "archive", cfg.Dir())
+		}
+		return err
+	}
+
+	if a != nil {
+		firehose.WithArchive(a.FirehoseLog(cfg.SegmentSize()))
+	}
+	if err = firehose.Stream(ctx); err != nil {
+		log.WithErrorMsg(err, "Error slurping from bsky firehose")
+	}
+	return err
+}
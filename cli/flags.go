@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"flag"
+	"strconv"
+	"strings"
+
+	"github.com/mikeblum/atgraph.dev/conf"
+	"github.com/mikeblum/atgraph.dev/graph"
+)
+
+// envValue - a flag backed by an env setting: the environment supplies the
+// default and setting the flag overrides it for every conf.EnvConf
+type envValue struct {
+	env    string
+	isBool bool
+}
+
+func (v *envValue) String() string {
+	if v == nil || v.env == "" {
+		return ""
+	}
+	return conf.NewEnvConf().GetEnv(v.env, "")
+}
+
+func (v *envValue) Set(value string) error {
+	if v.isBool {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return err
+		}
+	}
+	conf.Override(v.env, value)
+	return nil
+}
+
+func (v *envValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+// envFlag - flag name overriding env, usage notes the env var it replaces
+func envFlag(fs *flag.FlagSet, name, env, usage string) {
+	fs.Var(&envValue{env: env}, name, usage+" ("+env+")")
+}
+
+func envBoolFlag(fs *flag.FlagSet, name, env, usage string) {
+	fs.Var(&envValue{env: env, isBool: true}, name, usage+" ("+env+")")
+}
+
+// globalFlags - accepted ahead of the command and by every command
+func globalFlags(fs *flag.FlagSet) {
+	envFlag(fs, "engines", graph.ENV_ATGRAPH_ENGINES, "comma separated graph engines ie. clickhouse,neo4j")
+	envFlag(fs, "config", conf.ENV_ATGRAPH_CONFIG, "YAML or TOML config file, ie. bsky.page_size sets BSKY_PAGE_SIZE")
+	envFlag(fs, "log-level", conf.ENV_LOG_LEVEL, "slog level: -4 debug, 0 info, 4 warn, 8 error")
+}
+
+// listFlag - repeatable string flag
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"io"
+
+	"github.com/mikeblum/atgraph.dev/conf"
+	"github.com/mikeblum/atgraph.dev/graph"
+	"github.com/mikeblum/atgraph.dev/graph/migrate"
+)
+
+const (
+	MIGRATE_UP      = "up"
+	MIGRATE_STATUS  = "status"
+	MIGRATE_DRY_RUN = "dry-run"
+)
+
+// migrate - schema migrations for the engines selected via ATGRAPH_ENGINES
+func migrateCommand() *Command {
+	return &Command{
+		Name:    CMD_MIGRATE,
+		Args:    "[" + joinArgs(MIGRATE_STATUS, MIGRATE_UP, MIGRATE_DRY_RUN) + "]",
+		Summary: "show or apply schema migrations",
+		Setup: func(fs *flag.FlagSet) func(context.Context, io.Writer, []string) error {
+			dryRun := fs.Bool("dry-run", false, "with up, print the statements it would apply")
+			return func(ctx context.Context, out io.Writer, args []string) error {
+				action := MIGRATE_STATUS
+				switch len(args) {
+				case 0:
+				case 1:
+					action = args[0]
+				default:
+					return usageErrorf("unexpected arguments %q", args[1:])
+				}
+				switch action {
+				case MIGRATE_STATUS, MIGRATE_DRY_RUN:
+				case MIGRATE_UP:
+					// up -dry-run is the dry-run action
+					if *dryRun {
+						action = MIGRATE_DRY_RUN
+					}
+				default:
+					return usageErrorf("unknown action %q", action)
+				}
+				return runMigrate(ctx, action)
+			}
+		},
+	}
+}
+
+func runMigrate(ctx context.Context, action string) error {
+	log := conf.NewLog()
+	cfg, err := graph.NewConf()
+	if err != nil {
+		return err
+	}
+	engine, err := graph.Open(ctx, cfg)
+	if err != nil {
+		log.WithErrorMsg(err, "Error bootstrapping graph engines", "registered", graph.Engines())
+		return err
+	}
+	defer engine.Close(context.Background())
+
+	for _, migrator := range engine.Migrators() {
+		switch action {
+		case MIGRATE_STATUS:
+			err = migrateStatus(ctx, log, migrator)
+		case MIGRATE_DRY_RUN:
+			err = migrateDryRun(ctx, log, migrator)
+		case MIGRATE_UP:
+			err = migrateUp(ctx, log, migrator)
+		}
+		if err != nil {
+			log.WithErrorMsg(err, "Error running migrations", "engine", migrator.Engine(), "cmd", action)
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateStatus(ctx context.Context, log *conf.Log, migrator *migrate.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		args := []any{"engine", migrator.Engine(), "version", s.Version, "name", s.Name, "state", s.State}
+		if !s.Applied.IsZero() {
+			args = append(args, "applied", s.Applied)
+		}
+		log.With(args...).Info("Migration")
+	}
+	return nil
+}
+
+func migrateDryRun(ctx context.Context, log *conf.Log, migrator *migrate.Migrator) error {
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	for _, migration := range pending {
+		for _, stmt := range migration.Statements {
+			log.With("engine", migrator.Engine(), "version", migration.Version, "name", migration.Name, "statement", stmt).Info("Would apply")
+		}
+	}
+	log.With("engine", migrator.Engine(), "pending", len(pending)).Info("Dry run complete")
+	return nil
+}
+
+func migrateUp(ctx context.Context, log *conf.Log, migrator *migrate.Migrator) error {
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+	log.With("engine", migrator.Engine(), "applied", len(applied)).Info("Migrations complete")
+	return nil
+}
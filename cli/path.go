@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+
+	"github.com/mikeblum/atgraph.dev/bsky"
+	"github.com/mikeblum/atgraph.dev/conf"
+	"github.com/mikeblum/atgraph.dev/graph"
+)
+
+// path - shortest follow paths between two handles or DIDs as JSON
+func pathCommand() *Command {
+	return &Command{
+		Name:    CMD_PATH,
+		Args:    "<source> <target>",
+		Summary: "shortest follow paths between two handles or DIDs",
+		Setup: func(fs *flag.FlagSet) func(context.Context, io.Writer, []string) error {
+			mutual := fs.Bool("mutual", false, "only traverse follows that are followed back")
+			envFlag(fs, "max-depth", graph.ENV_ATGRAPH_PATH_MAX_DEPTH, "maximum degrees of separation")
+			envFlag(fs, "limit", graph.ENV_ATGRAPH_PATH_LIMIT, "maximum number of equally short paths")
+			envFlag(fs, "timeout", graph.ENV_ATGRAPH_PATH_TIMEOUT, "bound on the search")
+			return func(ctx context.Context, out io.Writer, args []string) error {
+				if len(args) != 2 {
+					return usageErrorf("expected <source> <target>")
+				}
+				return runPath(ctx, out, args[0], args[1], *mutual)
+			}
+		},
+	}
+}
+
+func runPath(ctx context.Context, out io.Writer, from, to string, mutual bool) error {
+	log := conf.NewLog()
+	cfg, err := graph.NewConf()
+	if err != nil {
+		return err
+	}
+	var source, target string
+	for i, identifier := range []string{from, to} {
+		did, err := bsky.ResolveDID(ctx, nil, identifier)
+		if err != nil {
+			log.WithErrorMsg(err, "Error resolving identity", "identifier", identifier)
+			return err
+		}
+		if i == 0 {
+			source = did.String()
+		} else {
+			target = did.String()
+		}
+	}
+
+	engine, err := graph.Open(ctx, cfg)
+	if err != nil {
+		log.WithErrorMsg(err, "Error bootstrapping graph engines", "registered", graph.Engines())
+		return err
+	}
+	defer engine.Close(context.Background())
+
+	q := graph.PathQuery{
+		From:     source,
+		To:       target,
+		MaxDepth: cfg.PathMaxDepth(),
+		Mutual:   mutual,
+		Limit:    cfg.PathLimit(),
+		Timeout:  cfg.PathTimeout(),
+	}
+	var paths []graph.Path
+	if paths, err = engine.ShortestPaths(ctx, q); err != nil {
+		log.WithErrorMsg(err, "Error finding shortest paths", "source", source, "target", target)
+		return err
+	}
+	degrees := -1
+	if len(paths) > 0 {
+		degrees = paths[0].Degrees()
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]any{
+		"source":  source,
+		"target":  target,
+		"degrees": degrees,
+		"paths":   paths,
+	})
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"io"
+
+	"github.com/mikeblum/atgraph.dev/bsky"
+	"github.com/mikeblum/atgraph.dev/conf"
+)
+
+// profile - fetch the profile of the authenticated account
+func profileCommand() *Command {
+	return &Command{
+		Name:    CMD_PROFILE,
+		Summary: "fetch the authenticated account's bsky profile",
+		Setup: func(fs *flag.FlagSet) func(context.Context, io.Writer, []string) error {
+			envFlag(fs, "identifier", bsky.ENV_BSKY_IDENTIFIER, "handle or DID to authenticate as")
+			return func(ctx context.Context, out io.Writer, args []string) error {
+				if len(args) > 0 {
+					return usageErrorf("unexpected arguments %q", args)
+				}
+				log := conf.NewLog()
+				client, err := bsky.NewSyncClient()
+				if err != nil {
+					log.WithErrorMsg(err, "Error creating bsky client")
+					return err
+				}
+				var profile *bsky.Profile
+				if profile, err = client.Profile(); err != nil {
+					log.WithErrorMsg(err, "Error fetching bsky profile")
+					return err
+				}
+				log.With("profile", profile).Info("Fetched bsky profiles")
+				return nil
+			}
+		},
+	}
+}
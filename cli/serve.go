@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"io"
+
+	"github.com/mikeblum/atgraph.dev/conf"
+	"github.com/mikeblum/atgraph.dev/graph"
+	"github.com/mikeblum/atgraph.dev/o11y"
+	"github.com/mikeblum/atgraph.dev/server"
+)
+
+// serve - dev.atgraph.* XRPC queries over the engines selected via ATGRAPH_ENGINES
+func serveCommand() *Command {
+	return &Command{
+		Name:    CMD_SERVE,
+		Summary: "serve dev.atgraph.* XRPC queries",
+		Setup: func(fs *flag.FlagSet) func(context.Context, io.Writer, []string) error {
+			envFlag(fs, "addr", server.ENV_ATGRAPH_SERVER_ADDR, "listen address")
+			envFlag(fs, "read-timeout", server.ENV_ATGRAPH_SERVER_READ_TIMEOUT, "request read timeout")
+			envFlag(fs, "write-timeout", server.ENV_ATGRAPH_SERVER_WRITE_TIMEOUT, "response write timeout")
+			envFlag(fs, "shutdown-timeout", server.ENV_ATGRAPH_SERVER_SHUTDOWN_TIMEOUT, "grace period for in-flight requests")
+			return func(ctx context.Context, out io.Writer, args []string) error {
+				if len(args) > 0 {
+					return usageErrorf("unexpected arguments %q", args)
+				}
+				return runServe(ctx)
+			}
+		},
+	}
+}
+
+func runServe(ctx context.Context) error {
+	log := conf.NewLog()
+	var err error
+
+	// configure o11y
+	if _, err = o11y.NewO11y(ctx, log); err != nil {
+		log.WithErrorMsg(err, "Error bootstrapping OTEL o11y")
+		return err
+	}
+	defer o11y.Cleanup(context.Background())
+
+	// reads are served by the first engine implementing graph.Reader
+	var graphConf *graph.Conf
+	if graphConf, err = graph.NewConf(); err != nil {
+		return err
+	}
+	var engine *graph.CompositeEngine
+	if engine, err = graph.Open(ctx, graphConf); err != nil {
+		log.WithErrorMsg(err, "Error bootstrapping graph engines", "registered", graph.Engines())
+		return err
+	}
+	log.With("engines", engine.Backends()).Info("Graph engines ready")
+	defer engine.Close(context.Background())
+
+	var serverConf *server.Conf
+	if serverConf, err = server.NewConf(); err != nil {
+		return err
+	}
+	var srv *server.Server
+	if srv, err = server.NewServer(ctx, serverConf, engine, engine); err != nil {
+		log.WithErrorMsg(err, "Error creating XRPC server")
+		return err
+	}
+	if err = srv.ListenAndServe(ctx); err != nil {
+		log.WithErrorMsg(err, "Error serving XRPC")
+		return err
+	}
+	log.Info("XRPC server stopped ✅")
+	return nil
+}
@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+
+	"github.com/bluesky-social/indigo/atproto/identity"
+	"github.com/mikeblum/atgraph.dev/archive"
+	"github.com/mikeblum/atgraph.dev/bsky"
+	"github.com/mikeblum/atgraph.dev/conf"
+	"github.com/mikeblum/atgraph.dev/graph"
+	_ "github.com/mikeblum/atgraph.dev/graph/clickhouse"
+	_ "github.com/mikeblum/atgraph.dev/graph/neo4j"
+	"github.com/mikeblum/atgraph.dev/o11y"
+)
+
+// sync - backfill every repo on the network, import repo CAR exports or
+// replay the repos archived by an earlier backfill
+func syncCommand() *Command {
+	return &Command{
+		Name:    CMD_SYNC,
+		Summary: "backfill repos from the network, CAR files or the archive into the graph engines",
+		Setup: func(fs *flag.FlagSet) func(context.Context, io.Writer, []string) error {
+			envFlag(fs, "car", bsky.ENV_BSKY_CAR_PATH, "import a .car file or directory of them instead of listing the network")
+			envBoolFlag(fs, "resolve", bsky.ENV_BSKY_CAR_RESOLVE, "resolve handles of imported CAR repos, -resolve=false imports offline with handle.invalid")
+			envFlag(fs, "archive", archive.ENV_ATGRAPH_ARCHIVE_DIR, "keep fetched repo CARs in this directory")
+			envFlag(fs, "repo-workers", bsky.ENV_BSKY_REPO_WORKER_COUNT, "repo fetch workers")
+			envFlag(fs, "ingest-workers", bsky.ENV_BSKY_INGEST_WORKER_COUNT, "engine ingest workers")
+			envFlag(fs, "page-size", bsky.ENV_BSKY_PAGE_SIZE, "repos listed per page")
+			envBoolFlag(fs, "autoscale", bsky.ENV_BSKY_AUTOSCALE, "resize workers within their bounds")
+			envFlag(fs, "status-file", bsky.ENV_BSKY_STATUS_FILE, "periodically write a JSON status snapshot here")
+			replay := fs.Bool("replay", false, "re-ingest the newest archived revision of every repo from -archive")
+			dryRun := fs.Bool("dry-run", false, "fetch and walk repos without opening the engines or writing the archive")
+			return func(ctx context.Context, out io.Writer, args []string) error {
+				if len(args) > 0 {
+					return usageErrorf("unexpected arguments %q", args)
+				}
+				return runSync(ctx, *replay, *dryRun)
+			}
+		},
+	}
+}
+
+func runSync(ctx context.Context, replay, dryRun bool) error {
+	log := conf.NewLog()
+	cfg, err := bsky.NewConf()
+	if err != nil {
+		return err
+	}
+	archiveConf, err := archive.NewConf()
+	if err != nil {
+		return err
+	}
+	archiveDir, carPath := archiveConf.Dir(), cfg.CARPath()
+	if replay && archiveDir == "" {
+		return usageErrorf("-replay requires -archive or %s", archive.ENV_ATGRAPH_ARCHIVE_DIR)
+	}
+	if replay && carPath != "" {
+		return usageErrorf("-replay and -car are mutually exclusive")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var client *bsky.Client
+
+	// configure o11y
+	if _, err = o11y.NewO11y(ctx, log); err != nil {
+		log.WithErrorMsg(err, "Error bootstrapping OTEL o11y")
+		return err
+	}
+	defer o11y.Cleanup(context.Background())
+
+	var engine *graph.CompositeEngine
+	if !dryRun {
+		if engine, err = openSyncEngine(ctx, log); err != nil {
+			return err
+		}
+		defer engine.Close(context.Background())
+	}
+
+	var repoArchive *archive.Archive
+	if archiveDir != "" {
+		if repoArchive, err = archive.Open(archiveDir); err != nil {
+			log.WithErrorMsg(err, "Error opening archive", "archive", archiveDir)
+			return err
+		}
+	}
+
+	if carPath != "" || replay {
+		// CAR imports don't list repos so no session is needed
+		if client, err = bsky.NewAPIClient(); err != nil {
+			log.WithErrorMsg(err, "Error creating bsky API client")
+			return err
+		}
+	} else if client, err = bsky.NewSyncClient(); err != nil {
+		// init authenticated bsky client
+		log.WithErrorMsg(err, "Error creating bsky sync client")
+		return err
+	}
+
+	// bootstrap worker pool
+	var pool *bsky.WorkerPool
+	if pool, err = bsky.NewWorkerPool(ctx, client, cfg); err != nil {
+		log.WithErrorMsg(err, "Error initing worker pool")
+		return err
+	}
+	pool.StartMonitor(ctx).StartProgress(ctx)
+	if dryRun {
+		pool.WithIngest(func(context.Context, int, bsky.RepoItem) error { return nil })
+	} else {
+		// repo syncs close the validity interval of follows / blocks no longer in the repo
+		pool.WithIngest(engine.Ingest).WithRepoSynced(engine.RepoSynced)
+	}
+	if cfg.CARResolve() {
+		pool.WithDirectory(identity.DefaultDirectory())
+	}
+	if repoArchive != nil && !replay && !dryRun {
+		pool.WithArchive(repoArchive)
+	}
+	go func() {
+		if err := pool.Start(ctx); err != nil {
+			log.WithErrorMsg(err, "Error starting bsky worker pool")
+			cancel() // cancel context if worker pool fails to start
+		}
+	}()
+
+	// Wait for pool and ingest to be ready
+	for _, ready := range []chan bool{pool.PoolReady(), pool.IngestReady()} {
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			log.WithErrorMsg(ctx.Err(), "Context cancelled before worker pool was ready")
+			return ctx.Err()
+		}
+	}
+	log.Info("Worker pool ready")
+
+	// Start backfill in the background
+	done := make(chan error, 1)
+	go func() {
+		backfill := func() error { return client.BackfillRepos(ctx, pool) }
+		switch {
+		case replay:
+			backfill = func() error { return client.ReplayArchive(ctx, pool, repoArchive) }
+		case carPath != "":
+			backfill = func() error { return client.ImportCARs(ctx, pool, carPath) }
+		}
+		err := backfill()
+		if err != nil {
+			log.WithErrorMsg(err, "Error backfilling bsky repos")
+			cancel()
+		}
+		done <- err
+	}()
+
+	// Await backfill to complete or be cancelled
+	var backfillErr error
+	select {
+	case backfillErr = <-done:
+	case <-ctx.Done():
+	}
+
+	summary, err := pool.Wait(ctx)
+	stats := []any{
+		"repos", summary.Repos,
+		"repo-failures", summary.RepoFailures,
+		"items", summary.Items,
+		"item-failures", summary.ItemFailures,
+		"dry-run", dryRun,
+	}
+	if err = errors.Join(backfillErr, err); err != nil {
+		log.WithErrorMsg(err, "Error backfilling bsky repos ❌", stats...)
+		return err
+	}
+	log.With(stats...).Info("Bsky backfill successful ✅")
+	return nil
+}
+
+// openSyncEngine - engines selected via ATGRAPH_ENGINES with their schema,
+// indexes and constraints in place
+func openSyncEngine(ctx context.Context, log *conf.Log) (*graph.CompositeEngine, error) {
+	cfg, err := graph.NewConf()
+	if err != nil {
+		return nil, err
+	}
+	engine, err := graph.Open(ctx, cfg)
+	if err != nil {
+		log.WithErrorMsg(err, "Error bootstrapping graph engines", "registered", graph.Engines())
+		return nil, err
+	}
+	log.With("engines", engine.Backends()).Info("Graph engines ready")
+
+	for _, step := range []struct {
+		name string
+		run  func(context.Context) error
+	}{
+		// apply pending schema migrations
+		{"migrating schema", engine.LoadSchema},
+		{"creating indexes", engine.CreateIndexes},
+		{"creating constraints", engine.CreateConstraints},
+	} {
+		if err = step.run(ctx); err != nil {
+			log.WithErrorMsg(err, "Error "+step.name)
+			engine.Close(context.Background())
+			return nil, err
+		}
+	}
+	return engine, nil
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"runtime"
+
+	"github.com/mikeblum/atgraph.dev/version"
+)
+
+// version - build version of the atgraph module
+func versionCommand() *Command {
+	return &Command{
+		Name:     CMD_VERSION,
+		Summary:  "print the build version",
+		NoConfig: true,
+		Setup: func(fs *flag.FlagSet) func(context.Context, io.Writer, []string) error {
+			return func(ctx context.Context, out io.Writer, args []string) error {
+				if len(args) > 0 {
+					return usageErrorf("unexpected arguments %q", args)
+				}
+				mod, _ := version.BuildVersion()
+				_, err := fmt.Fprintf(out, "%s %s %s %s/%s\n", APP_NAME, mod.Version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+				return err
+			}
+		},
+	}
+}
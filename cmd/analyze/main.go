@@ -0,0 +1,13 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/mikeblum/atgraph.dev/cli"
+)
+
+// analyze - same as atgraph analyze
+func main() {
+	os.Exit(cli.New().Run(context.Background(), cli.CMD_ANALYZE, os.Args[1:]))
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/mikeblum/atgraph.dev/cli"
+)
+
+// atgraph - sync, firehose, profile, migrate, export, analyze, path, serve and version:
+//
+//	atgraph [flags] <command> [flags] [args]
+func main() {
+	os.Exit(cli.New().Main(context.Background(), os.Args[1:]))
+}
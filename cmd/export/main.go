@@ -0,0 +1,13 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/mikeblum/atgraph.dev/cli"
+)
+
+// export - same as atgraph export
+func main() {
+	os.Exit(cli.New().Run(context.Background(), cli.CMD_EXPORT, os.Args[1:]))
+}
@@ -1,23 +1,13 @@
 package main
 
 import (
+	"context"
 	"os"
 
-	"github.com/mikeblum/atgraph.dev/bsky"
-	"github.com/mikeblum/atgraph.dev/conf"
+	"github.com/mikeblum/atgraph.dev/cli"
 )
 
+// firehose - same as atgraph firehose
 func main() {
-	log := conf.NewLog()
-	firehose := bsky.NewFirehose()
-	var err error
-
-	if err = firehose.Stream(); err != nil {
-		log.WithErrorMsg(err, "Error slurping from bsky firehose")
-		exit()
-	}
-}
-
-func exit() {
-	os.Exit(1)
+	os.Exit(cli.New().Run(context.Background(), cli.CMD_FIREHOSE, os.Args[1:]))
 }
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/mikeblum/atgraph.dev/cli"
+)
+
+// migrate - same as atgraph migrate
+func main() {
+	os.Exit(cli.New().Run(context.Background(), cli.CMD_MIGRATE, os.Args[1:]))
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/mikeblum/atgraph.dev/cli"
+)
+
+// path - same as atgraph path
+func main() {
+	os.Exit(cli.New().Run(context.Background(), cli.CMD_PATH, os.Args[1:]))
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/mikeblum/atgraph.dev/cli"
+)
+
+// server - same as atgraph serve
+func main() {
+	os.Exit(cli.New().Run(context.Background(), cli.CMD_SERVE, os.Args[1:]))
+}
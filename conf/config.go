@@ -0,0 +1,277 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	// struct tags read by Decode
+	TAG_ENV    = "env"
+	TAG_MIN    = "min"
+	TAG_MAX    = "max"
+	TAG_ONEOF  = "oneof"
+	TAG_SECRET = "secret"
+
+	// secret values as printed by Dump and in errors
+	REDACTED = "<redacted>"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// FieldError - a setting that failed to parse or validate
+type FieldError struct {
+	Env    string
+	Value  string
+	Source Source
+	Err    error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s=%q (%s): %v", e.Env, e.Value, e.Source, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Setting - a resolved setting as Dump prints it, secrets redacted
+type Setting struct {
+	Env    string
+	Value  string
+	Source Source
+	Secret bool
+}
+
+type field struct {
+	value  reflect.Value
+	env    string
+	secret bool
+	tag    reflect.StructTag
+}
+
+// Decode - resolve the env tagged fields of the struct cfg points to, which
+// already hold their defaults. Unset and empty settings keep their default,
+// as do invalid ones which are all reported in the returned error. Errors
+// reading the .env or config files are returned by EnvConf.Load.
+//
+//	PageSize    int           `env:"BSKY_PAGE_SIZE" min:"1"`
+//	Interval    time.Duration `env:"BSKY_PROGRESS_INTERVAL" min:"1ns"`
+//	Password    string        `env:"BSKY_PASSWORD" secret:"true"`
+//	Compression string        `env:"CLICKHOUSE_COMPRESSION" oneof:"lz4 zstd none"`
+//
+// Fields may be strings, bools, ints, int64s, uint64s, float64s, time.Durations or
+// comma separated []strings. oneof values are trimmed and lower cased.
+// Untagged struct fields are decoded recursively.
+func Decode(env EnvConf, cfg any) error {
+	var errs []error
+	for _, f := range fields(cfg) {
+		raw, source := env.Lookup(f.env)
+		if source == SOURCE_DEFAULT || (f.value.Kind() != reflect.String && strings.TrimSpace(raw) == "") {
+			continue
+		}
+		value := reflect.New(f.value.Type()).Elem()
+		err := parse(value, raw, f.tag)
+		if err == nil {
+			err = validate(value, f.tag)
+		}
+		if err != nil {
+			if f.secret {
+				raw = REDACTED
+			}
+			errs = append(errs, &FieldError{Env: f.env, Value: raw, Source: source, Err: err})
+			continue
+		}
+		f.value.Set(value)
+	}
+	return errors.Join(errs...)
+}
+
+// Settings - the env tagged fields of cfg as decoded and where they came from
+func Settings(env EnvConf, cfg any) []Setting {
+	var settings []Setting
+	for _, f := range fields(cfg) {
+		_, source := env.Lookup(f.env)
+		value := format(f.value)
+		if f.secret && value != "" {
+			value = REDACTED
+		}
+		settings = append(settings, Setting{Env: f.env, Value: value, Source: source, Secret: f.secret})
+	}
+	return settings
+}
+
+// Dump - settings as KEY=value lines annotated with their source
+func Dump(w io.Writer, settings []Setting) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, s := range settings {
+		if _, err := fmt.Fprintf(tw, "%s=%s\t# %s\n", s.Env, s.Value, s.Source); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+func fields(cfg any) []field {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("conf: expected a pointer to a struct, got %T", cfg))
+	}
+	return structFields(v.Elem())
+}
+
+func structFields(v reflect.Value) []field {
+	var fields []field
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		env, ok := sf.Tag.Lookup(TAG_ENV)
+		if !ok {
+			if sf.Type.Kind() == reflect.Struct {
+				fields = append(fields, structFields(v.Field(i))...)
+			}
+			continue
+		}
+		secret, _ := strconv.ParseBool(sf.Tag.Get(TAG_SECRET))
+		fields = append(fields, field{value: v.Field(i), env: env, secret: secret, tag: sf.Tag})
+	}
+	return fields
+}
+
+func parse(v reflect.Value, raw string, tag reflect.StructTag) error {
+	if v.Kind() != reflect.String {
+		raw = strings.TrimSpace(raw)
+	}
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return errors.New("invalid duration (expected ie. 500ms, 30s or 5m)")
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		if _, ok := tag.Lookup(TAG_ONEOF); ok {
+			raw = strings.ToLower(strings.TrimSpace(raw))
+		}
+		v.SetString(raw)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New("invalid boolean (expected true or false)")
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int || v.Kind() == reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("invalid integer")
+		}
+		v.SetInt(i)
+	case v.Kind() == reflect.Uint64:
+		u, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return errors.New("invalid unsigned integer")
+		}
+		v.SetUint(u)
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return errors.New("invalid number")
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		panic(fmt.Sprintf("conf: unsupported setting type %s", v.Type()))
+	}
+	return nil
+}
+
+func validate(v reflect.Value, tag reflect.StructTag) error {
+	if oneof, ok := tag.Lookup(TAG_ONEOF); ok {
+		allowed := strings.Fields(oneof)
+		for _, a := range allowed {
+			if v.String() == a {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected one of %s", strings.Join(allowed, ", "))
+	}
+	for _, bound := range []string{TAG_MIN, TAG_MAX} {
+		limit, ok := tag.Lookup(bound)
+		if !ok {
+			continue
+		}
+		b := reflect.New(v.Type()).Elem()
+		if err := parse(b, limit, ""); err != nil {
+			panic(fmt.Sprintf("conf: invalid %s tag %q: %v", bound, limit, err))
+		}
+		if bound == TAG_MIN && number(v) < number(b) {
+			return fmt.Errorf("must be at least %s", format(b))
+		}
+		if bound == TAG_MAX && number(v) > number(b) {
+			return fmt.Errorf("must be at most %s", format(b))
+		}
+	}
+	return nil
+}
+
+func number(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Float64:
+		return v.Float()
+	case reflect.Uint64:
+		return float64(v.Uint())
+	}
+	return float64(v.Int())
+}
+
+func format(v reflect.Value) string {
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.String:
+		return v.String()
+	case v.Kind() == reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case v.Kind() == reflect.Int || v.Kind() == reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case v.Kind() == reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case v.Kind() == reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case v.Kind() == reflect.Slice:
+		return strings.Join(v.Interface().([]string), ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// SettingError - the FieldError for env within an error returned by Decode, or nil
+func SettingError(err error, env string) error {
+	switch e := err.(type) {
+	case *FieldError:
+		if e.Env == env {
+			return e
+		}
+	case interface{ Unwrap() []error }:
+		for _, err := range e.Unwrap() {
+			if fieldErr := SettingError(err, env); fieldErr != nil {
+				return fieldErr
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package conf
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type configTest struct {
+	Name     string        `env:"CONF_TEST_NAME"`
+	Size     int           `env:"CONF_TEST_SIZE" min:"1" max:"10"`
+	Ratio    float64       `env:"CONF_TEST_RATIO" min:"0" max:"1"`
+	Interval time.Duration `env:"CONF_TEST_INTERVAL" min:"1ns"`
+	Enabled  bool          `env:"CONF_TEST_ENABLED"`
+	Hosts    []string      `env:"CONF_TEST_HOSTS"`
+	Mode     string        `env:"CONF_TEST_MODE" oneof:"fast slow"`
+	Token    string        `env:"CONF_TEST_TOKEN" secret:"true"`
+	Nested   struct {
+		Count int64  `env:"CONF_TEST_NESTED_COUNT"`
+		Seed  uint64 `env:"CONF_TEST_NESTED_SEED"`
+	}
+}
+
+func defaultConfigTest() *configTest {
+	return &configTest{Name: "default", Size: 5, Ratio: 0.5, Interval: time.Second, Mode: "fast"}
+}
+
+func TestConfig(t *testing.T) {
+	t.Run("defaults", decodeDefaultsTest)
+	t.Run("decode typed settings", decodeTest)
+	t.Run("invalid settings keep defaults", decodeErrorsTest)
+	t.Run("yaml file", yamlFileTest)
+	t.Run("toml file", tomlFileTest)
+	t.Run("toml errors", tomlErrorsTest)
+	t.Run("unsupported file", unsupportedFileTest)
+	t.Run("precedence", precedenceTest)
+	t.Run("dump redacts secrets", dumpTest)
+}
+
+func decodeDefaultsTest(t *testing.T) {
+	cfg := defaultConfigTest()
+	require.Nil(t, Decode(NewEnvConf(), cfg))
+	assert.Equal(t, defaultConfigTest(), cfg)
+}
+
+func decodeTest(t *testing.T) {
+	t.Setenv("CONF_TEST_NAME", "atgraph")
+	t.Setenv("CONF_TEST_SIZE", " 7 ")
+	t.Setenv("CONF_TEST_RATIO", "0.25")
+	t.Setenv("CONF_TEST_INTERVAL", "90s")
+	t.Setenv("CONF_TEST_ENABLED", "true")
+	t.Setenv("CONF_TEST_HOSTS", "a:1, b:2,,")
+	t.Setenv("CONF_TEST_MODE", " SLOW")
+	t.Setenv("CONF_TEST_NESTED_COUNT", "42")
+	t.Setenv("CONF_TEST_NESTED_SEED", "18446744073709551615")
+	cfg := defaultConfigTest()
+	require.Nil(t, Decode(NewEnvConf(), cfg))
+	assert.Equal(t, "atgraph", cfg.Name)
+	assert.Equal(t, 7, cfg.Size)
+	assert.Equal(t, 0.25, cfg.Ratio)
+	assert.Equal(t, 90*time.Second, cfg.Interval)
+	assert.True(t, cfg.Enabled)
+	assert.Equal(t, []string{"a:1", "b:2"}, cfg.Hosts)
+	assert.Equal(t, "slow", cfg.Mode)
+	assert.Equal(t, int64(42), cfg.Nested.Count)
+	assert.Equal(t, uint64(18446744073709551615), cfg.Nested.Seed)
+}
+
+func decodeErrorsTest(t *testing.T) {
+	t.Setenv("CONF_TEST_SIZE", "11")
+	t.Setenv("CONF_TEST_RATIO", "half")
+	t.Setenv("CONF_TEST_INTERVAL", "0s")
+	t.Setenv("CONF_TEST_ENABLED", "")
+	t.Setenv("CONF_TEST_MODE", "medium")
+	t.Setenv("CONF_TEST_NESTED_SEED", "-1")
+	cfg := defaultConfigTest()
+	err := Decode(NewEnvConf(), cfg)
+	require.Error(t, err)
+	// invalid and empty settings keep their defaults
+	assert.Equal(t, defaultConfigTest(), cfg)
+	assert.ErrorContains(t, err, `CONF_TEST_SIZE="11" (env): must be at most 10`)
+	assert.ErrorContains(t, err, `CONF_TEST_RATIO="half" (env): invalid number`)
+	assert.ErrorContains(t, err, `CONF_TEST_INTERVAL="0s" (env): must be at least 1ns`)
+	assert.ErrorContains(t, err, `CONF_TEST_MODE="medium" (env): expected one of fast, slow`)
+	assert.ErrorContains(t, err, `CONF_TEST_NESTED_SEED="-1" (env): invalid unsigned integer`)
+	assert.NotContains(t, err.Error(), "CONF_TEST_ENABLED")
+
+	var fieldErr *FieldError
+	require.True(t, errors.As(SettingError(err, "CONF_TEST_MODE"), &fieldErr))
+	assert.Equal(t, "medium", fieldErr.Value)
+	assert.Nil(t, SettingError(err, "CONF_TEST_NAME"))
+
+	// secrets are never echoed back
+	t.Setenv("CONF_TEST_TOKEN", "hunter2")
+	err = Decode(NewEnvConf(), &struct {
+		Token int `env:"CONF_TEST_TOKEN" secret:"true"`
+	}{})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "hunter2")
+	assert.Contains(t, err.Error(), REDACTED)
+}
+
+// configFileTest - path written to a temp dir and selected via ATGRAPH_CONFIG
+func configFileTest(t *testing.T, name, contents string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.Nil(t, os.WriteFile(path, []byte(contents), 0o600))
+	t.Setenv(ENV_ATGRAPH_CONFIG, path)
+	return path
+}
+
+func yamlFileTest(t *testing.T) {
+	configFileTest(t, "atgraph.yaml", `
+conf:
+  test:
+    name: from-yaml
+    size: 3
+    interval: 5m
+    enabled: true
+    hosts: [a:1, b:2]
+    nested:
+      count: 9
+`)
+	env := NewEnvConf()
+	require.Nil(t, env.Load())
+	cfg := defaultConfigTest()
+	require.Nil(t, Decode(env, cfg))
+	assert.Equal(t, "from-yaml", cfg.Name)
+	assert.Equal(t, 3, cfg.Size)
+	assert.Equal(t, 5*time.Minute, cfg.Interval)
+	assert.True(t, cfg.Enabled)
+	assert.Equal(t, []string{"a:1", "b:2"}, cfg.Hosts)
+	assert.Equal(t, int64(9), cfg.Nested.Count)
+	_, source := env.Lookup("CONF_TEST_NAME")
+	assert.Equal(t, SOURCE_FILE, source)
+}
+
+func tomlFileTest(t *testing.T) {
+	path := configFileTest(t, "atgraph.toml", `
+# atgraph settings
+[conf.test]
+name = "from \"toml\"" # trailing comment
+size = 1_0
+ratio = 0.75
+mode = 'SLOW'
+hosts = [
+  "a:1",
+  "b:2", # the second host
+]
+nested.count = 0x10
+token = """
+multi-line"""
+extra = { since = 1979-05-27, at = 07:32:00 }
+`)
+	values, err := ReadFile(path)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"CONF_TEST_NAME":         `from "toml"`,
+		"CONF_TEST_SIZE":         "10",
+		"CONF_TEST_RATIO":        "0.75",
+		"CONF_TEST_MODE":         "SLOW",
+		"CONF_TEST_HOSTS":        "a:1,b:2",
+		"CONF_TEST_NESTED_COUNT": "16",
+		"CONF_TEST_TOKEN":        "multi-line",
+		"CONF_TEST_EXTRA_SINCE":  "1979-05-27",
+		"CONF_TEST_EXTRA_AT":     "07:32:00",
+	}, values)
+	cfg := defaultConfigTest()
+	require.Nil(t, Decode(NewEnvConf(), cfg))
+	assert.Equal(t, "slow", cfg.Mode)
+	assert.Equal(t, 10, cfg.Size)
+}
+
+func tomlErrorsTest(t *testing.T) {
+	for contents, msg := range map[string]string{
+		"[[servers]]\nname = \"a\"": "SERVERS: arrays of tables are not supported",
+		"a = 1\na = 2":              "line 2 (last key \"a\"): Key 'a' has already been defined",
+		"[a]\nb = 1\n[a]\nc = 2":    "line 3: Key 'a' has already been defined",
+		"a = 010":                   "cannot have leading zeroes",
+		"a = \"\\x41\"":             "invalid escape in string '\\x'",
+		"a = \"open":                "expected '\"'",
+		"a = maybe":                 `found "maybe"`,
+		"a 1":                       "expected '.' or '='",
+		"a = 1 b = 2":               "to end with a newline",
+	} {
+		path := configFileTest(t, "atgraph.toml", contents)
+		_, err := ReadFile(path)
+		assert.ErrorContains(t, err, msg, contents)
+	}
+}
+
+func unsupportedFileTest(t *testing.T) {
+	path := configFileTest(t, "atgraph.ini", "size=1")
+	_, err := ReadFile(path)
+	assert.ErrorContains(t, err, "unsupported file")
+	assert.Error(t, NewEnvConf().Load())
+	configFileTest(t, "broken.yaml", "conf: [")
+	assert.ErrorContains(t, NewEnvConf().Load(), "error parsing")
+}
+
+func precedenceTest(t *testing.T) {
+	dir := t.TempDir()
+	configFileTest(t, "atgraph.yaml", `
+conf_test_name: file
+conf_test_size: 2
+conf_test_mode: slow
+conf_test_hosts: file
+`)
+	dotenv := filepath.Join(dir, ".env")
+	require.Nil(t, os.WriteFile(dotenv, []byte("CONF_TEST_NAME=dotenv\nCONF_TEST_SIZE=3\nCONF_TEST_MODE=fast\n"), 0o600))
+	t.Setenv("CONF_TEST_NAME", "env")
+	t.Setenv("CONF_TEST_SIZE", "4")
+	Override("CONF_TEST_NAME", "flag")
+	t.Cleanup(func() {
+		overrides.Delete("CONF_TEST_NAME")
+		// only set by the .env file
+		exported.Delete("CONF_TEST_MODE")
+		os.Unsetenv("CONF_TEST_MODE")
+	})
+
+	env := NewEnvConf(dotenv)
+	require.Nil(t, env.Load())
+	for key, want := range map[string]struct {
+		value  string
+		source Source
+	}{
+		"CONF_TEST_NAME":     {"flag", SOURCE_FLAG},
+		"CONF_TEST_SIZE":     {"4", SOURCE_ENV},
+		"CONF_TEST_MODE":     {"fast", SOURCE_DOTENV},
+		"CONF_TEST_HOSTS":    {"file", SOURCE_FILE},
+		"CONF_TEST_INTERVAL": {"", SOURCE_DEFAULT},
+	} {
+		value, source := env.Lookup(key)
+		assert.Equal(t, want.value, value, key)
+		assert.Equal(t, want.source, source, key)
+	}
+	assert.Equal(t, "1s", env.GetEnv("CONF_TEST_INTERVAL", "1s"))
+	// explicit .env files must exist
+	assert.Error(t, NewEnvConf(filepath.Join(dir, "missing.env")).Load())
+}
+
+func dumpTest(t *testing.T) {
+	t.Setenv("CONF_TEST_TOKEN", "hunter2")
+	t.Setenv("CONF_TEST_SIZE", "8")
+	env := NewEnvConf()
+	cfg := defaultConfigTest()
+	require.Nil(t, Decode(env, cfg))
+	var out bytes.Buffer
+	require.Nil(t, Dump(&out, Settings(env, cfg)))
+	assert.NotContains(t, out.String(), "hunter2")
+	assert.Regexp(t, `CONF_TEST_TOKEN=<redacted>\s+# env`, out.String())
+	assert.Regexp(t, `CONF_TEST_SIZE=8\s+# env`, out.String())
+	assert.Regexp(t, `CONF_TEST_INTERVAL=1s\s+# default`, out.String())
+	assert.Regexp(t, `CONF_TEST_NESTED_COUNT=0\s+# default`, out.String())
+}
@@ -1,40 +1,139 @@
 package conf
 
 import (
+	"errors"
 	"os"
+	"sync"
 
 	"github.com/joho/godotenv"
 )
 
+const (
+	// YAML or TOML config file, see ReadFile
+	ENV_ATGRAPH_CONFIG = "ATGRAPH_CONFIG"
+
+	// defaults
+	DOTENV_FILE = ".env"
+)
+
+// Source - where a setting was resolved from, highest precedence first
+type Source string
+
+const (
+	SOURCE_FLAG    Source = "flag"
+	SOURCE_ENV     Source = "env"
+	SOURCE_DOTENV  Source = "dotenv"
+	SOURCE_FILE    Source = "file"
+	SOURCE_DEFAULT Source = "default"
+)
+
 type EnvConf interface {
-	// Loads env variables from .env files and/or OS environment
+	// Loads env variables from .env files and the ATGRAPH_CONFIG file
 	Load() error
 	// Resolve env variables or fallback
 	GetEnv(env, fallback string) string
+	// Resolve env and where it came from, SOURCE_DEFAULT when unset
+	Lookup(env string) (string, Source)
 }
 
+// overrides - values set from command line flags, resolved ahead of the environment
+var overrides sync.Map
+
+// exported - .env values copied into the process environment so libraries
+// reading it directly (ie. the OTEL SDK) see them, as godotenv.Load does
+var exported sync.Map
+
+// Override - resolve env as value for every EnvConf in the process
+func Override(env, value string) {
+	overrides.Store(env, value)
+}
+
+// NewEnvConf - settings resolved in order from flags (Override), the process
+// environment, the .env files (default .env, earlier files win), the
+// ATGRAPH_CONFIG file and finally the caller's fallback
 func NewEnvConf(files ...string) EnvConf {
+	if len(files) == 0 {
+		files = []string{DOTENV_FILE}
+	}
 	return &envConf{
 		files: files,
 	}
 }
 
 type envConf struct {
-	loaded bool
+	once   sync.Once
+	err    error
 	files  []string
+	dotenv map[string]string
+	config map[string]string
 }
 
+// Load - read the .env and config files once, later calls return the first error.
+// A missing default .env is not an error.
 func (e *envConf) Load() error {
-	return godotenv.Load()
+	e.once.Do(func() {
+		e.dotenv = make(map[string]string)
+		var errs []error
+		for _, file := range e.files {
+			values, err := godotenv.Read(file)
+			if err != nil {
+				if !(file == DOTENV_FILE && errors.Is(err, os.ErrNotExist)) {
+					errs = append(errs, err)
+				}
+				continue
+			}
+			for key, value := range values {
+				if _, ok := e.dotenv[key]; ok {
+					continue
+				}
+				e.dotenv[key] = value
+				if _, ok := os.LookupEnv(key); !ok {
+					os.Setenv(key, value)
+					exported.Store(key, value)
+				}
+			}
+		}
+		if path, _ := e.lookup(ENV_ATGRAPH_CONFIG); path != "" {
+			var err error
+			if e.config, err = readFileOnce(path); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		e.err = errors.Join(errs...)
+	})
+	return e.err
 }
 
 func (e *envConf) GetEnv(env, fallback string) string {
-	if !e.loaded {
-		e.Load()
-		e.loaded = true
-	}
-	if value, ok := os.LookupEnv(env); ok {
+	if value, source := e.Lookup(env); source != SOURCE_DEFAULT {
 		return value
 	}
 	return fallback
 }
+
+func (e *envConf) Lookup(env string) (string, Source) {
+	e.Load()
+	if value, source := e.lookup(env); source != SOURCE_DEFAULT {
+		return value, source
+	}
+	if value, ok := e.config[env]; ok {
+		return value, SOURCE_FILE
+	}
+	return "", SOURCE_DEFAULT
+}
+
+// lookup - flags, env and .env, everything but the config file
+func (e *envConf) lookup(env string) (string, Source) {
+	if value, ok := overrides.Load(env); ok {
+		return value.(string), SOURCE_FLAG
+	}
+	if value, ok := os.LookupEnv(env); ok {
+		if dotenv, ok := exported.Load(env); !ok || dotenv.(string) != value {
+			return value, SOURCE_ENV
+		}
+	}
+	if value, ok := e.dotenv[env]; ok {
+		return value, SOURCE_DOTENV
+	}
+	return "", SOURCE_DEFAULT
+}
@@ -0,0 +1,135 @@
+package conf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// supported config file extensions
+	YAML_EXTENSION       = ".yaml"
+	YML_EXTENSION        = ".yml"
+	TOML_EXTENSION       = ".toml"
+	CONFIG_KEY_SEPARATOR = "_"
+)
+
+// files - config files are read once per process
+var files sync.Map
+
+type fileResult struct {
+	values map[string]string
+	err    error
+}
+
+func readFileOnce(path string) (map[string]string, error) {
+	if result, ok := files.Load(path); ok {
+		return result.(*fileResult).values, result.(*fileResult).err
+	}
+	values, err := ReadFile(path)
+	result, _ := files.LoadOrStore(path, &fileResult{values, err})
+	return result.(*fileResult).values, result.(*fileResult).err
+}
+
+// ReadFile - YAML or TOML config file flattened to env keys: nested tables are
+// joined by _ and upper cased so
+//
+//	bsky:
+//	  page_size: 500
+//
+// and
+//
+//	[bsky]
+//	page_size = 500
+//
+// both set BSKY_PAGE_SIZE=500. Lists are joined by commas.
+func ReadFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	tree := make(map[string]any)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case YAML_EXTENSION, YML_EXTENSION:
+		err = yaml.Unmarshal(data, &tree)
+	case TOML_EXTENSION:
+		tree, err = parseTOML(data)
+	default:
+		return nil, fmt.Errorf("config: unsupported file %s (expected %s, %s or %s)", path, YAML_EXTENSION, YML_EXTENSION, TOML_EXTENSION)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: error parsing %s: %w", path, err)
+	}
+	values := make(map[string]string)
+	if err = flatten(values, "", tree); err != nil {
+		return nil, fmt.Errorf("config: %s: %w", path, err)
+	}
+	return values, nil
+}
+
+func flatten(values map[string]string, prefix string, tree map[string]any) error {
+	keys := make([]string, 0, len(tree))
+	for key := range tree {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		env := strings.ToUpper(strings.ReplaceAll(key, "-", CONFIG_KEY_SEPARATOR))
+		if prefix != "" {
+			env = prefix + CONFIG_KEY_SEPARATOR + env
+		}
+		switch value := tree[key].(type) {
+		case nil:
+		case map[string]any:
+			if err := flatten(values, env, value); err != nil {
+				return err
+			}
+		case []map[string]any:
+			return fmt.Errorf("%s: arrays of tables are not supported", env)
+		case []any:
+			items := make([]string, 0, len(value))
+			for _, item := range value {
+				s, err := scalar(item)
+				if err != nil {
+					return fmt.Errorf("%s: %w", env, err)
+				}
+				items = append(items, s)
+			}
+			values[env] = strings.Join(items, ",")
+		default:
+			s, err := scalar(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", env, err)
+			}
+			values[env] = s
+		}
+	}
+	return nil
+}
+
+func scalar(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case time.Time:
+		return formatTime(v), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", value)
+}
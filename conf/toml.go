@@ -0,0 +1,33 @@
+package conf
+
+import (
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// tomlLocalLayouts - TOML local dates and times decode into time.Time with
+// these zones and are formatted back without the offset they don't have
+var tomlLocalLayouts = map[string]string{
+	"datetime-local": "2006-01-02T15:04:05.999999999",
+	"date-local":     time.DateOnly,
+	"time-local":     "15:04:05.999999999",
+}
+
+// parseTOML - a TOML config file decoded per the TOML 1.0 spec. Arrays of
+// tables decode but are rejected when flattened to env keys.
+func parseTOML(data []byte) (map[string]any, error) {
+	tree := make(map[string]any)
+	if _, err := toml.Decode(string(data), &tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// formatTime - RFC 3339 or the TOML local date / time layout t was decoded from
+func formatTime(t time.Time) string {
+	if layout, ok := tomlLocalLayouts[t.Location().String()]; ok {
+		return t.Format(layout)
+	}
+	return t.Format(time.RFC3339Nano)
+}
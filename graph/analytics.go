@@ -0,0 +1,43 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNoScanner = errors.New("graph: no engine supports follow scans")
+
+// FollowScanner - engines able to stream every follow edge for an in-memory snapshot
+type FollowScanner interface {
+	// ScanFollows - call fn once per (did)-[:FOLLOWS]->(subject) edge, stopping on the first error
+	ScanFollows(ctx context.Context, fn func(did, subject string) error) error
+}
+
+// ScoreWriter - engines storing the results of analytics jobs
+type ScoreWriter interface {
+	WriteScores(ctx context.Context, scores []Score) error
+	WriteRecommendations(ctx context.Context, recommendations []Recommendations) error
+}
+
+// Score - influence and community of a profile from a single analytics run
+type Score struct {
+	DID      string  `json:"did"`
+	PageRank float64 `json:"pagerank"`
+	// Community - DID of the profile whose label the community converged on
+	Community string    `json:"community"`
+	Computed  time.Time `json:"computed"`
+}
+
+// Recommendations - accounts DID may know, ranked by follows in common
+type Recommendations struct {
+	DID        string      `json:"did"`
+	Candidates []Candidate `json:"candidates"`
+	Computed   time.Time   `json:"computed"`
+}
+
+// Candidate - an account followed by Common of the accounts DID follows
+type Candidate struct {
+	DID    string `json:"did"`
+	Common int    `json:"common"`
+}
@@ -0,0 +1,150 @@
+package analyze
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mikeblum/atgraph.dev/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// edgesTest - two triangles joined by a single follow: a-b-c and x-y-z, c -> x
+var edgesTest = [][2]string{
+	{"a", "b"}, {"b", "c"}, {"c", "a"}, {"b", "a"},
+	{"x", "y"}, {"y", "z"}, {"z", "x"}, {"y", "x"},
+	{"c", "x"},
+	// duplicate follow record and a self follow
+	{"a", "b"}, {"a", "a"},
+}
+
+type scannerTest struct{}
+
+func (scannerTest) ScanFollows(ctx context.Context, fn func(did, subject string) error) error {
+	for _, edge := range edgesTest {
+		if err := fn(edge[0], edge[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type writerTest struct {
+	scores          []graph.Score
+	recommendations []graph.Recommendations
+}
+
+func (w *writerTest) WriteScores(ctx context.Context, scores []graph.Score) error {
+	w.scores = append(w.scores, scores...)
+	return nil
+}
+
+func (w *writerTest) WriteRecommendations(ctx context.Context, recommendations []graph.Recommendations) error {
+	w.recommendations = append(w.recommendations, recommendations...)
+	return nil
+}
+
+func TestAnalyze(t *testing.T) {
+	t.Run("snapshot de-duplicates follows", snapshotTest)
+	t.Run("pagerank", pageRankTest)
+	t.Run("communities", communitiesTest)
+	t.Run("recommendations", recommendTest)
+	t.Run("run writes every result", runTest)
+	t.Run("conf rejects damping outside (0, 1)", confDampingTest)
+}
+
+func snapshotFixture(t *testing.T) *Snapshot {
+	s, err := Load(context.Background(), scannerTest{})
+	require.Nil(t, err)
+	return s
+}
+
+func idTest(s *Snapshot, did string) int32 {
+	return s.index[did]
+}
+
+func snapshotTest(t *testing.T) {
+	s := snapshotFixture(t)
+	assert.Equal(t, 6, s.Nodes())
+	// self follow dropped, duplicate counted but compacted
+	assert.Equal(t, 10, s.Edges())
+	assert.Len(t, s.out[idTest(s, "a")], 1)
+}
+
+func pageRankTest(t *testing.T) {
+	s := snapshotFixture(t)
+	ranks := PageRank(s, DEFAULT_DAMPING, DEFAULT_ITERATIONS, DEFAULT_TOLERANCE)
+	var total float64
+	for _, rank := range ranks {
+		total += rank
+	}
+	assert.InDelta(t, 1, total, 1e-9)
+	// x is followed by y, z and c
+	x := ranks[idTest(s, "x")]
+	for _, did := range []string{"a", "b", "c", "y", "z"} {
+		assert.Greater(t, x, ranks[idTest(s, did)], did)
+	}
+	assert.Nil(t, PageRank(NewSnapshot(), DEFAULT_DAMPING, DEFAULT_ITERATIONS, DEFAULT_TOLERANCE))
+}
+
+func communitiesTest(t *testing.T) {
+	s := snapshotFixture(t)
+	labels := Communities(s, DEFAULT_COMMUNITY_ITERATIONS, DEFAULT_SEED)
+	assert.Equal(t, labels[idTest(s, "a")], labels[idTest(s, "b")])
+	assert.Equal(t, labels[idTest(s, "a")], labels[idTest(s, "c")])
+	assert.Equal(t, labels[idTest(s, "x")], labels[idTest(s, "y")])
+	assert.Equal(t, labels[idTest(s, "x")], labels[idTest(s, "z")])
+	assert.NotEqual(t, labels[idTest(s, "a")], labels[idTest(s, "x")])
+	// seeded runs are reproducible
+	assert.Equal(t, labels, Communities(s, DEFAULT_COMMUNITY_ITERATIONS, DEFAULT_SEED))
+}
+
+func recommendTest(t *testing.T) {
+	s := snapshotFixture(t)
+	// a follows b, b follows c and a: c is recommended, a itself is not
+	assert.Equal(t, []graph.Candidate{{DID: "c", Common: 1}}, Recommend(s, idTest(s, "a"), 10))
+	// b follows a and c: b itself and a (already followed) are excluded, leaving x
+	assert.Equal(t, []graph.Candidate{{DID: "x", Common: 1}}, Recommend(s, idTest(s, "b"), 10))
+	assert.Empty(t, Recommend(s, idTest(s, "a"), 0))
+}
+
+func runTest(t *testing.T) {
+	t.Setenv(ENV_ATGRAPH_ANALYZE_WRITE_CHUNK, "4")
+	cfg, err := NewConf()
+	require.Nil(t, err)
+	writer := &writerTest{}
+	summary, err := Run(context.Background(), cfg, scannerTest{}, writer)
+	require.Nil(t, err)
+	assert.Equal(t, 6, summary.Nodes)
+	assert.Equal(t, 2, summary.Communities)
+	require.Len(t, writer.scores, 6)
+	for _, score := range writer.scores {
+		assert.NotEmpty(t, score.Community)
+		assert.Positive(t, score.PageRank)
+		assert.False(t, score.Computed.IsZero())
+	}
+	// every DID is written, even without candidates, to clear a previous run
+	require.Len(t, writer.recommendations, 6)
+	var candidates, empty int
+	for _, recommendations := range writer.recommendations {
+		candidates += len(recommendations.Candidates)
+		if len(recommendations.Candidates) == 0 {
+			empty++
+		}
+	}
+	assert.Equal(t, summary.Recommendations, candidates)
+	assert.Positive(t, empty)
+}
+
+func confDampingTest(t *testing.T) {
+	for _, damping := range []string{"1.5", "1", "0", "-0.2"} {
+		t.Setenv(ENV_ATGRAPH_ANALYZE_DAMPING, damping)
+		cfg, err := NewConf()
+		assert.ErrorContains(t, err, ENV_ATGRAPH_ANALYZE_DAMPING+"=\""+damping+"\"", damping)
+		assert.Equal(t, DEFAULT_DAMPING, cfg.Damping())
+	}
+	t.Setenv(ENV_ATGRAPH_ANALYZE_DAMPING, "0.9")
+	cfg, err := NewConf()
+	require.Nil(t, err)
+	assert.Equal(t, 0.9, cfg.Damping())
+}
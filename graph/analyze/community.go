@@ -0,0 +1,48 @@
+package analyze
+
+import "math/rand/v2"
+
+// Communities - label propagation over follows treated as undirected edges.
+// Each node adopts the most frequent label among its neighbours, ties going
+// to the lowest label, visiting nodes in a seeded random order so runs are
+// reproducible. Returns the node id of each node's community label.
+func Communities(s *Snapshot, iterations int, seed uint64) []int32 {
+	n := s.Nodes()
+	labels := make([]int32, n)
+	order := make([]int32, n)
+	for i := range labels {
+		labels[i] = int32(i)
+		order[i] = int32(i)
+	}
+	random := rand.New(rand.NewPCG(seed, seed))
+	counts := make(map[int32]int)
+	for range iterations {
+		random.Shuffle(n, func(i, j int) {
+			order[i], order[j] = order[j], order[i]
+		})
+		changed := 0
+		for _, node := range order {
+			clear(counts)
+			for _, neighbour := range s.out[node] {
+				counts[labels[neighbour]]++
+			}
+			for _, neighbour := range s.in[node] {
+				counts[labels[neighbour]]++
+			}
+			best, bestCount := labels[node], 0
+			for label, count := range counts {
+				if count > bestCount || (count == bestCount && label < best) {
+					best, bestCount = label, count
+				}
+			}
+			if bestCount > 0 && best != labels[node] {
+				labels[node] = best
+				changed++
+			}
+		}
+		if changed == 0 {
+			break
+		}
+	}
+	return labels
+}
@@ -0,0 +1,93 @@
+package analyze
+
+import (
+	"errors"
+
+	"github.com/mikeblum/atgraph.dev/conf"
+)
+
+type Conf struct {
+	conf.EnvConf
+	cfg *Config
+}
+
+// NewConf - settings decoded once, invalid ones are left at their defaults
+// and reported in the returned error alongside the still usable Conf
+func NewConf() (*Conf, error) {
+	c := &Conf{EnvConf: conf.NewEnvConf()}
+	var err error
+	c.cfg, err = c.decode()
+	return c, err
+}
+
+// Config - typed analytics settings, Damping is exclusive of both bounds
+type Config struct {
+	Damping             float64 `env:"ATGRAPH_ANALYZE_DAMPING" min:"0" max:"1"`
+	Iterations          int     `env:"ATGRAPH_ANALYZE_ITERATIONS" min:"1"`
+	Tolerance           float64 `env:"ATGRAPH_ANALYZE_TOLERANCE" min:"0"`
+	CommunityIterations int     `env:"ATGRAPH_ANALYZE_COMMUNITY_ITERATIONS" min:"1"`
+	Seed                uint64  `env:"ATGRAPH_ANALYZE_SEED"`
+	Recommendations     int     `env:"ATGRAPH_ANALYZE_RECOMMENDATIONS" min:"0"`
+	WriteChunk          int     `env:"ATGRAPH_ANALYZE_WRITE_CHUNK" min:"1"`
+}
+
+// decode - defaults overridden by whatever conf.Decode can parse
+func (c *Conf) decode() (*Config, error) {
+	cfg := &Config{
+		Damping:             DEFAULT_DAMPING,
+		Iterations:          DEFAULT_ITERATIONS,
+		Tolerance:           DEFAULT_TOLERANCE,
+		CommunityIterations: DEFAULT_COMMUNITY_ITERATIONS,
+		Seed:                DEFAULT_SEED,
+		Recommendations:     DEFAULT_RECOMMENDATIONS,
+		WriteChunk:          DEFAULT_WRITE_CHUNK,
+	}
+	err := conf.Decode(c.EnvConf, cfg)
+	if cfg.Damping <= 0 || cfg.Damping >= 1 {
+		// min / max are inclusive: 0 never converges and 1 ignores the random jumps
+		raw, source := c.Lookup(ENV_ATGRAPH_ANALYZE_DAMPING)
+		err = errors.Join(err, &conf.FieldError{Env: ENV_ATGRAPH_ANALYZE_DAMPING, Value: raw, Source: source, Err: errors.New("must be between 0 and 1 exclusive")})
+		cfg.Damping = DEFAULT_DAMPING
+	}
+	return cfg, err
+}
+
+// Config - the settings decoded by NewConf
+func (c *Conf) Config() *Config {
+	return c.cfg
+}
+
+// Damping - PageRank damping factor in (0, 1)
+func (c *Conf) Damping() float64 {
+	return c.cfg.Damping
+}
+
+// Iterations - maximum PageRank iterations
+func (c *Conf) Iterations() int {
+	return c.cfg.Iterations
+}
+
+// Tolerance - PageRank stops early once the L1 change between iterations is below this
+func (c *Conf) Tolerance() float64 {
+	return c.cfg.Tolerance
+}
+
+// CommunityIterations - maximum label propagation passes
+func (c *Conf) CommunityIterations() int {
+	return c.cfg.CommunityIterations
+}
+
+// Seed - label propagation visit order seed
+func (c *Conf) Seed() uint64 {
+	return c.cfg.Seed
+}
+
+// Recommendations - recommendations kept per profile, 0 skips the job
+func (c *Conf) Recommendations() int {
+	return c.cfg.Recommendations
+}
+
+// WriteChunk - scores / recommendations handed to the engines per write
+func (c *Conf) WriteChunk() int {
+	return c.cfg.WriteChunk
+}
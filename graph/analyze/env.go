@@ -0,0 +1,22 @@
+package analyze
+
+const (
+	ENV_ATGRAPH_ANALYZE_DAMPING              = "ATGRAPH_ANALYZE_DAMPING"
+	ENV_ATGRAPH_ANALYZE_ITERATIONS           = "ATGRAPH_ANALYZE_ITERATIONS"
+	ENV_ATGRAPH_ANALYZE_TOLERANCE            = "ATGRAPH_ANALYZE_TOLERANCE"
+	ENV_ATGRAPH_ANALYZE_COMMUNITY_ITERATIONS = "ATGRAPH_ANALYZE_COMMUNITY_ITERATIONS"
+	ENV_ATGRAPH_ANALYZE_SEED                 = "ATGRAPH_ANALYZE_SEED"
+	ENV_ATGRAPH_ANALYZE_RECOMMENDATIONS      = "ATGRAPH_ANALYZE_RECOMMENDATIONS"
+	ENV_ATGRAPH_ANALYZE_WRITE_CHUNK          = "ATGRAPH_ANALYZE_WRITE_CHUNK"
+
+	// defaults
+	DEFAULT_DAMPING              = 0.85
+	DEFAULT_ITERATIONS           = 50
+	DEFAULT_TOLERANCE            = 1e-6
+	DEFAULT_COMMUNITY_ITERATIONS = 20
+	DEFAULT_SEED                 = 1
+	// recommendations per profile - 0 skips the job
+	DEFAULT_RECOMMENDATIONS = 10
+	// scores / recommendations handed to the engines per write
+	DEFAULT_WRITE_CHUNK = 10000
+)
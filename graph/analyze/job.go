@@ -0,0 +1,101 @@
+package analyze
+
+import (
+	"context"
+	"time"
+
+	"github.com/mikeblum/atgraph.dev/conf"
+	"github.com/mikeblum/atgraph.dev/graph"
+)
+
+// Summary - size of the snapshot and results written by Run
+type Summary struct {
+	Nodes           int           `json:"nodes"`
+	Edges           int           `json:"edges"`
+	Communities     int           `json:"communities"`
+	Recommendations int           `json:"recommendations"`
+	Elapsed         time.Duration `json:"elapsed"`
+}
+
+// Run - snapshot the follow graph then score influence, detect communities
+// and recommend follows, writing every result through writer
+func Run(ctx context.Context, cfg *Conf, scanner graph.FollowScanner, writer graph.ScoreWriter) (*Summary, error) {
+	log := conf.NewLog()
+	start := time.Now()
+	computed := start.UTC()
+
+	s, err := Load(ctx, scanner)
+	if err != nil {
+		return nil, err
+	}
+	summary := &Summary{Nodes: s.Nodes(), Edges: s.Edges()}
+	log.With("nodes", summary.Nodes, "edges", summary.Edges, "elapsed", time.Since(start)).Info("Loaded follow graph snapshot")
+
+	ranks := PageRank(s, cfg.Damping(), cfg.Iterations(), cfg.Tolerance())
+	log.With("elapsed", time.Since(start)).Info("Computed PageRank")
+
+	labels := Communities(s, cfg.CommunityIterations(), cfg.Seed())
+	communities := make(map[int32]bool)
+	for _, label := range labels {
+		communities[label] = true
+	}
+	summary.Communities = len(communities)
+	log.With("communities", summary.Communities, "elapsed", time.Since(start)).Info("Detected communities")
+
+	chunk := cfg.WriteChunk()
+	scores := make([]graph.Score, 0, chunk)
+	for id := range s.Nodes() {
+		scores = append(scores, graph.Score{
+			DID:       s.DID(int32(id)),
+			PageRank:  ranks[id],
+			Community: s.DID(labels[id]),
+			Computed:  computed,
+		})
+		if len(scores) == chunk {
+			if err = writer.WriteScores(ctx, scores); err != nil {
+				return summary, err
+			}
+			scores = scores[:0]
+		}
+	}
+	if len(scores) > 0 {
+		if err = writer.WriteScores(ctx, scores); err != nil {
+			return summary, err
+		}
+	}
+
+	k := cfg.Recommendations()
+	if k == 0 {
+		summary.Elapsed = time.Since(start)
+		return summary, nil
+	}
+	recommendations := make([]graph.Recommendations, 0, chunk)
+	for id := range s.Nodes() {
+		if err = ctx.Err(); err != nil {
+			return summary, err
+		}
+		// DIDs without candidates are still written so the writer clears
+		// whatever a previous run recommended to them
+		candidates := Recommend(s, int32(id), k)
+		recommendations = append(recommendations, graph.Recommendations{
+			DID:        s.DID(int32(id)),
+			Candidates: candidates,
+			Computed:   computed,
+		})
+		summary.Recommendations += len(candidates)
+		if len(recommendations) == chunk {
+			if err = writer.WriteRecommendations(ctx, recommendations); err != nil {
+				return summary, err
+			}
+			recommendations = recommendations[:0]
+		}
+	}
+	if len(recommendations) > 0 {
+		if err = writer.WriteRecommendations(ctx, recommendations); err != nil {
+			return summary, err
+		}
+	}
+	log.With("recommendations", summary.Recommendations, "elapsed", time.Since(start)).Info("Recommended follows")
+	summary.Elapsed = time.Since(start)
+	return summary, nil
+}
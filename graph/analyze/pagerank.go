@@ -0,0 +1,48 @@
+package analyze
+
+import "math"
+
+// PageRank - influence by power iteration. Rank held by accounts following
+// no one is spread evenly across every node so the scores always sum to 1.
+// Stops after iterations or once the L1 change drops below tolerance.
+func PageRank(s *Snapshot, damping float64, iterations int, tolerance float64) []float64 {
+	n := s.Nodes()
+	if n == 0 {
+		return nil
+	}
+	rank := make([]float64, n)
+	next := make([]float64, n)
+	for i := range rank {
+		rank[i] = 1 / float64(n)
+	}
+	for range iterations {
+		var dangling float64
+		for i, out := range s.out {
+			if len(out) == 0 {
+				dangling += rank[i]
+			}
+		}
+		base := (1-damping)/float64(n) + damping*dangling/float64(n)
+		for i := range next {
+			next[i] = base
+		}
+		for i, out := range s.out {
+			if len(out) == 0 {
+				continue
+			}
+			share := damping * rank[i] / float64(len(out))
+			for _, j := range out {
+				next[j] += share
+			}
+		}
+		var delta float64
+		for i := range rank {
+			delta += math.Abs(next[i] - rank[i])
+		}
+		rank, next = next, rank
+		if delta < tolerance {
+			break
+		}
+	}
+	return rank
+}
@@ -0,0 +1,40 @@
+package analyze
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/mikeblum/atgraph.dev/graph"
+)
+
+// Recommend - "people you may know" for node: the k accounts followed most
+// often by the accounts node follows, excluding node and accounts it already
+// follows. Ties go to the lowest DID.
+func Recommend(s *Snapshot, node int32, k int) []graph.Candidate {
+	if k <= 0 || len(s.out[node]) == 0 {
+		return nil
+	}
+	counts := make(map[int32]int)
+	for _, followed := range s.out[node] {
+		for _, c := range s.out[followed] {
+			if c == node {
+				continue
+			}
+			if _, following := slices.BinarySearch(s.out[node], c); following {
+				continue
+			}
+			counts[c]++
+		}
+	}
+	candidates := make([]graph.Candidate, 0, len(counts))
+	for id, common := range counts {
+		candidates = append(candidates, graph.Candidate{DID: s.dids[id], Common: common})
+	}
+	slices.SortFunc(candidates, func(a, b graph.Candidate) int {
+		if c := cmp.Compare(b.Common, a.Common); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.DID, b.DID)
+	})
+	return candidates[:min(k, len(candidates))]
+}
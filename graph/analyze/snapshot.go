@@ -0,0 +1,88 @@
+// Package analyze - influence, community and recommendation jobs computed in
+// Go over an in-memory snapshot of the follow graph, so they run against any
+// engine implementing graph.FollowScanner without Neo4j GDS.
+package analyze
+
+import (
+	"context"
+	"slices"
+
+	"github.com/mikeblum/atgraph.dev/graph"
+)
+
+// Snapshot - follow graph with DIDs interned to dense node ids
+type Snapshot struct {
+	dids  []string
+	index map[string]int32
+	out   [][]int32
+	in    [][]int32
+	edges int
+}
+
+func NewSnapshot() *Snapshot {
+	return &Snapshot{
+		index: make(map[string]int32),
+	}
+}
+
+// Load - snapshot every follow edge streamed by scanner
+func Load(ctx context.Context, scanner graph.FollowScanner) (*Snapshot, error) {
+	s := NewSnapshot()
+	if err := scanner.ScanFollows(ctx, func(did, subject string) error {
+		s.AddFollow(did, subject)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	s.compact()
+	return s, nil
+}
+
+// AddFollow - add a did -> subject edge; self follows are ignored
+func (s *Snapshot) AddFollow(did, subject string) {
+	if did == subject {
+		return
+	}
+	a, b := s.node(did), s.node(subject)
+	s.out[a] = append(s.out[a], b)
+	s.in[b] = append(s.in[b], a)
+	s.edges++
+}
+
+// Nodes - number of profiles in the snapshot
+func (s *Snapshot) Nodes() int {
+	return len(s.dids)
+}
+
+// Edges - number of follow edges, including duplicate follow records
+func (s *Snapshot) Edges() int {
+	return s.edges
+}
+
+// DID - DID of node id
+func (s *Snapshot) DID(id int32) string {
+	return s.dids[id]
+}
+
+func (s *Snapshot) node(did string) int32 {
+	if id, ok := s.index[did]; ok {
+		return id
+	}
+	id := int32(len(s.dids))
+	s.index[did] = id
+	s.dids = append(s.dids, did)
+	s.out = append(s.out, nil)
+	s.in = append(s.in, nil)
+	return id
+}
+
+// compact - sort and de-duplicate adjacency lists since an account can hold
+// several follow records for the same subject
+func (s *Snapshot) compact() {
+	for i := range s.out {
+		slices.Sort(s.out[i])
+		s.out[i] = slices.Compact(s.out[i])
+		slices.Sort(s.in[i])
+		s.in[i] = slices.Compact(s.in[i])
+	}
+}
@@ -0,0 +1,240 @@
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/ch-go"
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/mikeblum/atgraph.dev/conf"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	FlushReasonRows     = "rows"
+	FlushReasonBytes    = "bytes"
+	FlushReasonInterval = "interval"
+	FlushReasonClose    = "close"
+)
+
+// columns - columnar buffer for a single ClickHouse table
+type columns interface {
+	Input() proto.Input
+	Rows() int
+	Reset()
+}
+
+// inserter - executes a single INSERT block (satisfied by *chpool.Pool)
+type inserter interface {
+	Do(ctx context.Context, q ch.Query) error
+}
+
+type flusher interface {
+	Flush(ctx context.Context, reason string) error
+}
+
+// Batcher - buffers rows per table and flushes them as a single INSERT
+// by row count, approximate byte size or interval - whichever comes first
+type Batcher struct {
+	conn       inserter
+	log        *conf.Log
+	metrics    *BatchMetrics
+	maxRows    int
+	maxBytes   int
+	interval   time.Duration
+	maxRetries int
+	mu         sync.Mutex
+	tables     []flusher
+	stop       chan struct{}
+	stopped    sync.WaitGroup
+	closeOnce  sync.Once
+}
+
+func NewBatcher(ctx context.Context, conn inserter, cfg *Conf) (*Batcher, error) {
+	metrics, err := NewBatchMetrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Batcher{
+		conn:       conn,
+		log:        conf.NewLog(),
+		metrics:    metrics,
+		maxRows:    cfg.batchRows(),
+		maxBytes:   cfg.batchBytes(),
+		interval:   cfg.batchInterval(),
+		maxRetries: cfg.maxRetries(),
+		stop:       make(chan struct{}),
+	}, nil
+}
+
+// Start - flush every registered table on an interval until Close
+func (b *Batcher) Start(ctx context.Context) *Batcher {
+	b.stopped.Add(1)
+	go func() {
+		defer b.stopped.Done()
+		ticker := time.NewTicker(b.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-b.stop:
+				return
+			case <-ticker.C:
+				if err := b.Flush(ctx, FlushReasonInterval); err != nil {
+					b.log.WithErrorMsg(err, "Error flushing batches", "engine", "clickhouse", "reason", FlushReasonInterval)
+				}
+			}
+		}
+	}()
+	return b
+}
+
+// Flush - flush every registered table
+func (b *Batcher) Flush(ctx context.Context, reason string) error {
+	b.mu.Lock()
+	tables := append([]flusher(nil), b.tables...)
+	b.mu.Unlock()
+	var err error
+	for _, table := range tables {
+		err = errors.Join(err, table.Flush(ctx, reason))
+	}
+	return err
+}
+
+// Close - stop the interval flush and drain any buffered rows
+func (b *Batcher) Close(ctx context.Context) error {
+	var err error
+	b.closeOnce.Do(func() {
+		close(b.stop)
+		b.stopped.Wait()
+		err = b.Flush(ctx, FlushReasonClose)
+	})
+	return err
+}
+
+func (b *Batcher) register(table flusher) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tables = append(b.tables, table)
+}
+
+// TableBatch - buffered rows for a single table
+type TableBatch[T columns] struct {
+	batcher *Batcher
+	table   string
+	mu      sync.Mutex
+	cols    T
+	size    int
+	acks    []func(error)
+}
+
+func NewTableBatch[T columns](b *Batcher, table string, cols T) *TableBatch[T] {
+	t := &TableBatch[T]{
+		batcher: b,
+		table:   table,
+		cols:    cols,
+	}
+	b.register(t)
+	return t
+}
+
+// Append - buffer a row via fn, which returns the approximate bytes appended.
+// Once the batch is full the caller flushes it synchronously - ingest workers
+// block on the flush which backs up the WorkerPool items queue.
+//
+// ack, if set, is called with the outcome of the flush that writes the row,
+// whichever caller or interval triggers it, and that flush's error is not
+// returned here. Without an ack the caller only learns about the flush it
+// triggered itself.
+func (t *TableBatch[T]) Append(ctx context.Context, fn func(cols T) int, ack func(error)) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.size += fn(t.cols)
+	if ack != nil {
+		t.acks = append(t.acks, ack)
+	}
+	var err error
+	switch {
+	case t.cols.Rows() >= t.batcher.maxRows:
+		err = t.flush(ctx, FlushReasonRows)
+	case t.size >= t.batcher.maxBytes:
+		err = t.flush(ctx, FlushReasonBytes)
+	}
+	if ack != nil {
+		return nil
+	}
+	return err
+}
+
+func (t *TableBatch[T]) Flush(ctx context.Context, reason string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.flush(ctx, reason)
+}
+
+// Rows - number of rows currently buffered
+func (t *TableBatch[T]) Rows() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cols.Rows()
+}
+
+func (t *TableBatch[T]) flush(ctx context.Context, reason string) error {
+	rows := t.cols.Rows()
+	if rows == 0 {
+		return nil
+	}
+	b := t.batcher
+	input := t.cols.Input()
+	start := time.Now()
+
+	var err error
+retry:
+	for attempt := 0; ; attempt++ {
+		if err = b.conn.Do(ctx, ch.Query{
+			Body:  input.Into(t.table), // helper that generates INSERT INTO query with all columns
+			Input: input,
+		}); err == nil || attempt >= b.maxRetries {
+			break
+		}
+		b.log.With("error", err, "engine", "clickhouse", "table", t.table, "rows", rows, "attempt", attempt+1).Warn("Error flushing batch")
+		// 2^n backoff starting at 100ms
+		select {
+		case <-ctx.Done():
+			err = errors.Join(err, ctx.Err())
+			break retry
+		case <-time.After(100 * time.Millisecond * time.Duration(1<<uint(attempt))):
+		}
+	}
+
+	status := "ok"
+	if err != nil {
+		status = "err"
+		err = fmt.Errorf("flush %s: dropped %d rows after %d retries: %w", t.table, rows, b.maxRetries, err)
+		b.log.WithErrorMsg(err, "Error flushing batch", "engine", "clickhouse", "table", t.table, "reason", reason)
+	}
+	attrs := metric.WithAttributes(
+		attribute.String("table", t.table),
+		attribute.String("reason", reason),
+		attribute.String("status", status),
+	)
+	b.metrics.flushes.Add(ctx, 1, attrs)
+	b.metrics.rows.Record(ctx, int64(rows), attrs)
+	b.metrics.bytes.Record(ctx, int64(t.size), attrs)
+	b.metrics.latency.Record(ctx, time.Since(start).Seconds(), attrs)
+
+	// rows are only acknowledged once written - a dropped batch fails the
+	// repos it held rather than counting them as ingested
+	for _, ack := range t.acks {
+		ack(err)
+	}
+	t.acks = nil
+	t.cols.Reset()
+	t.size = 0
+	return err
+}
@@ -0,0 +1,72 @@
+package clickhouse
+
+import (
+	"context"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type BatchMetrics struct {
+	flushes metric.Int64Counter
+	rows    metric.Int64Histogram
+	bytes   metric.Int64Histogram
+	latency metric.Float64Histogram
+}
+
+func NewBatchMetrics(ctx context.Context) (*BatchMetrics, error) {
+	buildInfo, ok := debug.ReadBuildInfo()
+	version := "unknown"
+	if ok {
+		version = buildInfo.Main.Version
+	}
+
+	meter := otel.GetMeterProvider().Meter(
+		"clickhouse.batch",
+		metric.WithInstrumentationVersion(version),
+	)
+
+	flushes, err := meter.Int64Counter(
+		"clickhouse.batch.flushes",
+		metric.WithDescription("Batch flushes by table, reason and status"),
+		metric.WithUnit("{flushes}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := meter.Int64Histogram(
+		"clickhouse.batch.rows",
+		metric.WithDescription("Rows per flushed batch"),
+		metric.WithUnit("{rows}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := meter.Int64Histogram(
+		"clickhouse.batch.bytes",
+		metric.WithDescription("Approximate bytes per flushed batch"),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	latency, err := meter.Float64Histogram(
+		"clickhouse.batch.latency",
+		metric.WithDescription("Batch INSERT latency"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BatchMetrics{
+		flushes: flushes,
+		rows:    rows,
+		bytes:   bytes,
+		latency: latency,
+	}, nil
+}
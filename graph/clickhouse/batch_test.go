@@ -0,0 +1,206 @@
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/ch-go"
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatcher(t *testing.T) {
+	t.Run("flush by row count", flushRowsTest)
+	t.Run("flush by byte size", flushBytesTest)
+	t.Run("flush on interval", flushIntervalTest)
+	t.Run("flush on close", flushCloseTest)
+	t.Run("retry failed flush", flushRetryTest)
+	t.Run("drop batch after retries exhausted", flushDropTest)
+	t.Run("ack rows once flushed", flushAckTest)
+	t.Run("ack dropped rows with the error", flushAckDropTest)
+}
+
+type insertTest struct {
+	mu       sync.Mutex
+	failures int
+	queries  []string
+	rows     []int
+}
+
+func (i *insertTest) Do(ctx context.Context, q ch.Query) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.failures > 0 {
+		i.failures--
+		return errors.New("connection reset")
+	}
+	i.queries = append(i.queries, q.Body)
+	i.rows = append(i.rows, q.Input[0].Data.Rows())
+	return nil
+}
+
+func (i *insertTest) flushed() []int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return append([]int(nil), i.rows...)
+}
+
+type columnsTest struct {
+	id proto.ColStr
+}
+
+func (c *columnsTest) Input() proto.Input {
+	return proto.Input{{Name: "id", Data: &c.id}}
+}
+
+func (c *columnsTest) Rows() int {
+	return c.id.Rows()
+}
+
+func (c *columnsTest) Reset() {
+	c.id.Reset()
+}
+
+func batcherTest(t *testing.T, conn inserter) (*Batcher, *TableBatch[*columnsTest]) {
+	batcher, err := NewBatcher(context.TODO(), conn, confTest(t))
+	require.Nil(t, err)
+	batcher.maxRows = 3
+	batcher.maxBytes = 1 << 20
+	batcher.interval = time.Hour
+	batcher.maxRetries = 2
+	return batcher, NewTableBatch(batcher, "test", &columnsTest{})
+}
+
+func appendTest(t *testing.T, table *TableBatch[*columnsTest], id string) {
+	require.Nil(t, table.Append(context.TODO(), func(cols *columnsTest) int {
+		cols.id.Append(id)
+		return len(id)
+	}, nil))
+}
+
+// ackTest - records the outcome each acked row was settled with
+type ackTest struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (a *ackTest) ack(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.errs = append(a.errs, err)
+}
+
+func (a *ackTest) settled() []error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]error(nil), a.errs...)
+}
+
+func appendAckTest(t *testing.T, table *TableBatch[*columnsTest], id string, acks *ackTest) {
+	require.Nil(t, table.Append(context.TODO(), func(cols *columnsTest) int {
+		cols.id.Append(id)
+		return len(id)
+	}, acks.ack))
+}
+
+func flushRowsTest(t *testing.T) {
+	conn := &insertTest{}
+	_, table := batcherTest(t, conn)
+	for _, id := range []string{"a", "b", "c", "d"} {
+		appendTest(t, table, id)
+	}
+	assert.Equal(t, []int{3}, conn.flushed())
+	assert.Equal(t, 1, table.Rows())
+	assert.Contains(t, conn.queries[0], `INSERT INTO "test"`)
+}
+
+func flushBytesTest(t *testing.T) {
+	conn := &insertTest{}
+	batcher, table := batcherTest(t, conn)
+	batcher.maxBytes = 8
+	appendTest(t, table, "abcd")
+	assert.Empty(t, conn.flushed())
+	appendTest(t, table, "efgh")
+	assert.Equal(t, []int{2}, conn.flushed())
+}
+
+func flushIntervalTest(t *testing.T) {
+	conn := &insertTest{}
+	batcher, table := batcherTest(t, conn)
+	batcher.interval = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	batcher.Start(ctx)
+	appendTest(t, table, "a")
+	assert.Eventually(t, func() bool {
+		return len(conn.flushed()) == 1
+	}, time.Second, 5*time.Millisecond)
+	require.Nil(t, batcher.Close(context.TODO()))
+}
+
+func flushCloseTest(t *testing.T) {
+	conn := &insertTest{}
+	batcher, table := batcherTest(t, conn)
+	batcher.Start(context.TODO())
+	appendTest(t, table, "a")
+	appendTest(t, table, "b")
+	require.Nil(t, batcher.Close(context.TODO()))
+	assert.Equal(t, []int{2}, conn.flushed())
+	// idempotent
+	require.Nil(t, batcher.Close(context.TODO()))
+}
+
+func flushRetryTest(t *testing.T) {
+	conn := &insertTest{failures: 2}
+	_, table := batcherTest(t, conn)
+	for _, id := range []string{"a", "b", "c"} {
+		appendTest(t, table, id)
+	}
+	assert.Equal(t, []int{3}, conn.flushed())
+}
+
+func flushDropTest(t *testing.T) {
+	conn := &insertTest{failures: 3}
+	_, table := batcherTest(t, conn)
+	appendTest(t, table, "a")
+	appendTest(t, table, "b")
+	err := table.Append(context.TODO(), func(cols *columnsTest) int {
+		cols.id.Append("c")
+		return 1
+	}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dropped 3 rows after 2 retries")
+	assert.Equal(t, 0, table.Rows())
+}
+
+func flushAckTest(t *testing.T) {
+	conn := &insertTest{}
+	batcher, table := batcherTest(t, conn)
+	acks := &ackTest{}
+	appendAckTest(t, table, "a", acks)
+	appendAckTest(t, table, "b", acks)
+	// buffered rows aren't acknowledged until written
+	assert.Empty(t, acks.settled())
+	require.Nil(t, batcher.Flush(context.TODO(), FlushReasonInterval))
+	assert.Equal(t, []error{nil, nil}, acks.settled())
+}
+
+func flushAckDropTest(t *testing.T) {
+	conn := &insertTest{failures: 3}
+	_, table := batcherTest(t, conn)
+	acks := &ackTest{}
+	for _, id := range []string{"a", "b", "c"} {
+		// the flush error reaches every row's ack rather than the caller
+		appendAckTest(t, table, id, acks)
+	}
+	settled := acks.settled()
+	require.Len(t, settled, 3)
+	for _, err := range settled {
+		assert.ErrorContains(t, err, "dropped 3 rows after 2 retries")
+	}
+	assert.Equal(t, 0, table.Rows())
+}
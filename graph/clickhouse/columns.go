@@ -0,0 +1,317 @@
+package clickhouse
+
+import (
+	"time"
+
+	"github.com/ClickHouse/ch-go/proto"
+	bskyItem "github.com/bluesky-social/indigo/api/bsky"
+	"github.com/mikeblum/atgraph.dev/bsky"
+	"github.com/mikeblum/atgraph.dev/graph"
+)
+
+const (
+	TABLE_BLOCKS          = "blocks"
+	TABLE_FOLLOWS         = "follows"
+	TABLE_PROFILES        = "profiles"
+	TABLE_PROFILE_SCORES  = "profile_scores"
+	TABLE_RECOMMENDATIONS = "follow_recommendations"
+	TABLE_REPO_SYNCS      = "repo_syncs"
+)
+
+// atgraph.profiles columns
+type profileColumns struct {
+	did         proto.ColStr
+	lexicon     *proto.ColLowCardinality[string]
+	handle      proto.ColStr
+	created     *proto.ColDateTime64
+	rev         proto.ColStr
+	revVersion  proto.ColUInt64
+	sig         proto.ColStr
+	version     proto.ColUInt8
+	description proto.ColStr
+	rkey        proto.ColStr
+	uri         proto.ColStr
+	cid         proto.ColStr
+	displayName proto.ColStr
+	avatarCID   proto.ColStr
+	avatarMime  *proto.ColLowCardinality[string]
+	avatarSize  proto.ColInt64
+	bannerCID   proto.ColStr
+	bannerMime  *proto.ColLowCardinality[string]
+	bannerSize  proto.ColInt64
+	labels      *proto.ColArr[string]
+	pinnedURI   proto.ColStr
+	pinnedCID   proto.ColStr
+	packURI     proto.ColStr
+	packCID     proto.ColStr
+}
+
+func newProfileColumns() *profileColumns {
+	return &profileColumns{
+		lexicon:    proto.NewLowCardinality(new(proto.ColStr)),
+		created:    new(proto.ColDateTime64).WithPrecision(proto.PrecisionNano),
+		avatarMime: proto.NewLowCardinality(new(proto.ColStr)),
+		bannerMime: proto.NewLowCardinality(new(proto.ColStr)),
+		labels:     proto.NewArray[string](new(proto.ColStr)),
+	}
+}
+
+func (c *profileColumns) Input() proto.Input {
+	return proto.Input{
+		{Name: "did", Data: &c.did},
+		{Name: "lexicon", Data: c.lexicon},
+		{Name: "handle", Data: &c.handle},
+		{Name: "created", Data: c.created},
+		{Name: "rev", Data: &c.rev},
+		{Name: "rev_version", Data: &c.revVersion},
+		{Name: "sig", Data: &c.sig},
+		{Name: "version", Data: &c.version},
+		{Name: "description", Data: &c.description},
+		{Name: "rkey", Data: &c.rkey},
+		{Name: "uri", Data: &c.uri},
+		{Name: "cid", Data: &c.cid},
+		{Name: "display_name", Data: &c.displayName},
+		{Name: "avatar_cid", Data: &c.avatarCID},
+		{Name: "avatar_mime_type", Data: c.avatarMime},
+		{Name: "avatar_size", Data: &c.avatarSize},
+		{Name: "banner_cid", Data: &c.bannerCID},
+		{Name: "banner_mime_type", Data: c.bannerMime},
+		{Name: "banner_size", Data: &c.bannerSize},
+		{Name: "labels", Data: c.labels},
+		{Name: "pinned_post_uri", Data: &c.pinnedURI},
+		{Name: "pinned_post_cid", Data: &c.pinnedCID},
+		{Name: "starter_pack_uri", Data: &c.packURI},
+		{Name: "starter_pack_cid", Data: &c.packCID},
+	}
+}
+
+func (c *profileColumns) Rows() int {
+	return c.did.Rows()
+}
+
+func (c *profileColumns) Reset() {
+	c.Input().Reset()
+}
+
+// append - buffer a single profile row, returning the approximate bytes appended
+func (c *profileColumns) append(item *bsky.RepoItem, actor *bskyItem.ActorProfile, created time.Time) int {
+	handle := item.Ident.Handle.String()
+	content := bsky.NewProfileContent(actor)
+	c.did.Append(item.DID.String())
+	c.lexicon.Append(actor.LexiconTypeID)
+	c.handle.Append(handle)
+	c.created.Append(created)
+	c.rev.Append(item.Rev)
+	c.revVersion.Append(item.RevVersion())
+	c.sig.Append(item.Sig)
+	c.version.Append(uint8(item.Version))
+	c.description.Append(content.Description)
+	c.rkey.Append(item.RKey)
+	c.uri.Append(item.URI.String())
+	c.cid.Append(item.CID)
+	c.displayName.Append(content.DisplayName)
+	c.avatarCID.Append(content.Avatar.CID)
+	c.avatarMime.Append(content.Avatar.MimeType)
+	c.avatarSize.Append(content.Avatar.Size)
+	c.bannerCID.Append(content.Banner.CID)
+	c.bannerMime.Append(content.Banner.MimeType)
+	c.bannerSize.Append(content.Banner.Size)
+	c.labels.Append(content.Labels)
+	c.pinnedURI.Append(content.PinnedPost.URI)
+	c.pinnedCID.Append(content.PinnedPost.CID)
+	c.packURI.Append(content.JoinedViaStarterPack.URI)
+	c.packCID.Append(content.JoinedViaStarterPack.CID)
+	var labels int
+	for _, label := range content.Labels {
+		labels += len(label)
+	}
+	// DateTime64 + UInt64 + UInt8 + 2x Int64
+	return len(item.DID) + len(actor.LexiconTypeID) + len(handle) + len(item.Rev) + len(item.Sig) + len(content.Description) +
+		len(item.RKey) + len(item.URI) + len(item.CID) + len(content.DisplayName) +
+		len(content.Avatar.CID) + len(content.Avatar.MimeType) + len(content.Banner.CID) + len(content.Banner.MimeType) + labels +
+		len(content.PinnedPost.URI) + len(content.PinnedPost.CID) + len(content.JoinedViaStarterPack.URI) + len(content.JoinedViaStarterPack.CID) +
+		8 + 8 + 1 + 8 + 8
+}
+
+// atgraph.follows / atgraph.blocks columns - both are DID -> subject edges
+type edgeColumns struct {
+	did        proto.ColStr
+	rkey       proto.ColStr
+	subject    proto.ColStr
+	created    *proto.ColDateTime64
+	rev        proto.ColStr
+	revVersion proto.ColUInt64
+	uri        proto.ColStr
+	cid        proto.ColStr
+}
+
+func newEdgeColumns() *edgeColumns {
+	return &edgeColumns{
+		created: new(proto.ColDateTime64).WithPrecision(proto.PrecisionNano),
+	}
+}
+
+func (c *edgeColumns) Input() proto.Input {
+	return proto.Input{
+		{Name: "did", Data: &c.did},
+		{Name: "rkey", Data: &c.rkey},
+		{Name: "subject", Data: &c.subject},
+		{Name: "created", Data: c.created},
+		{Name: "rev", Data: &c.rev},
+		{Name: "rev_version", Data: &c.revVersion},
+		{Name: "uri", Data: &c.uri},
+		{Name: "cid", Data: &c.cid},
+	}
+}
+
+func (c *edgeColumns) Rows() int {
+	return c.did.Rows()
+}
+
+func (c *edgeColumns) Reset() {
+	c.Input().Reset()
+}
+
+// append - buffer a single follow / block row, returning the approximate bytes appended
+func (c *edgeColumns) append(item *bsky.RepoItem, subject string, created time.Time) int {
+	c.did.Append(item.DID.String())
+	c.rkey.Append(item.RKey)
+	c.subject.Append(subject)
+	c.created.Append(created)
+	c.rev.Append(item.Rev)
+	c.revVersion.Append(item.RevVersion())
+	c.uri.Append(item.URI.String())
+	c.cid.Append(item.CID)
+	// DateTime64 + UInt64
+	return len(item.DID) + len(item.RKey) + len(subject) + len(item.Rev) + len(item.URI) + len(item.CID) + 8 + 8
+}
+
+// atgraph.repo_syncs columns
+type syncColumns struct {
+	did        proto.ColStr
+	rev        proto.ColStr
+	revVersion proto.ColUInt64
+	synced     *proto.ColDateTime64
+}
+
+func newSyncColumns() *syncColumns {
+	return &syncColumns{
+		synced: new(proto.ColDateTime64).WithPrecision(proto.PrecisionNano),
+	}
+}
+
+func (c *syncColumns) Input() proto.Input {
+	return proto.Input{
+		{Name: "did", Data: &c.did},
+		{Name: "rev", Data: &c.rev},
+		{Name: "rev_version", Data: &c.revVersion},
+		{Name: "synced", Data: c.synced},
+	}
+}
+
+func (c *syncColumns) Rows() int {
+	return c.did.Rows()
+}
+
+func (c *syncColumns) Reset() {
+	c.Input().Reset()
+}
+
+// append - buffer a single repo sync row, returning the approximate bytes appended
+func (c *syncColumns) append(did, rev string) int {
+	c.did.Append(did)
+	c.rev.Append(rev)
+	c.revVersion.Append(bsky.RevVersion(rev))
+	c.synced.Append(bsky.RevTime(rev))
+	// UInt64 + DateTime64
+	return len(did) + len(rev) + 8 + 8
+}
+
+// atgraph.profile_scores columns
+type scoreColumns struct {
+	did       proto.ColStr
+	pagerank  proto.ColFloat64
+	community proto.ColStr
+	computed  *proto.ColDateTime64
+}
+
+func newScoreColumns() *scoreColumns {
+	return &scoreColumns{
+		computed: new(proto.ColDateTime64).WithPrecision(proto.PrecisionNano),
+	}
+}
+
+func (c *scoreColumns) Input() proto.Input {
+	return proto.Input{
+		{Name: "did", Data: &c.did},
+		{Name: "pagerank", Data: &c.pagerank},
+		{Name: "community", Data: &c.community},
+		{Name: "computed", Data: c.computed},
+	}
+}
+
+func (c *scoreColumns) Rows() int {
+	return c.did.Rows()
+}
+
+func (c *scoreColumns) Reset() {
+	c.Input().Reset()
+}
+
+// append - buffer a single score row, returning the approximate bytes appended
+func (c *scoreColumns) append(score graph.Score) int {
+	c.did.Append(score.DID)
+	c.pagerank.Append(score.PageRank)
+	c.community.Append(score.Community)
+	c.computed.Append(score.Computed)
+	// Float64 + DateTime64
+	return len(score.DID) + len(score.Community) + 8 + 8
+}
+
+// atgraph.follow_recommendations columns
+type recommendationColumns struct {
+	did       proto.ColStr
+	rank      proto.ColUInt16
+	candidate proto.ColStr
+	common    proto.ColUInt32
+	computed  *proto.ColDateTime64
+}
+
+func newRecommendationColumns() *recommendationColumns {
+	return &recommendationColumns{
+		computed: new(proto.ColDateTime64).WithPrecision(proto.PrecisionNano),
+	}
+}
+
+func (c *recommendationColumns) Input() proto.Input {
+	return proto.Input{
+		{Name: "did", Data: &c.did},
+		{Name: "rank", Data: &c.rank},
+		{Name: "candidate", Data: &c.candidate},
+		{Name: "common", Data: &c.common},
+		{Name: "computed", Data: c.computed},
+	}
+}
+
+func (c *recommendationColumns) Rows() int {
+	return c.did.Rows()
+}
+
+func (c *recommendationColumns) Reset() {
+	c.Input().Reset()
+}
+
+// append - buffer one row per candidate, returning the approximate bytes appended
+func (c *recommendationColumns) append(recommendations graph.Recommendations) int {
+	var size int
+	for i, candidate := range recommendations.Candidates {
+		c.did.Append(recommendations.DID)
+		c.rank.Append(uint16(i + 1))
+		c.candidate.Append(candidate.DID)
+		c.common.Append(uint32(candidate.Common))
+		c.computed.Append(recommendations.Computed)
+		// UInt16 + UInt32 + DateTime64
+		size += len(recommendations.DID) + len(candidate.DID) + 2 + 4 + 8
+	}
+	return size
+}
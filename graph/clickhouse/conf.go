@@ -1,11 +1,157 @@
 package clickhouse
 
-import "github.com/mikeblum/atgraph.dev/conf"
+import (
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/mikeblum/atgraph.dev/conf"
+)
 
 type Conf struct {
 	conf.EnvConf
+	cfg *Config
+}
+
+// NewConf - settings decoded once, invalid ones are left at their defaults
+// and reported in the returned error alongside the still usable Conf
+func NewConf() (*Conf, error) {
+	c := &Conf{EnvConf: conf.NewEnvConf()}
+	var err error
+	c.cfg, err = c.decode()
+	return c, err
+}
+
+// Config - typed ClickHouse settings
+type Config struct {
+	Hosts                 []string      `env:"CLICKHOUSE_HOSTS"`
+	Database              string        `env:"CLICKHOUSE_DATABASE"`
+	Username              string        `env:"CLICKHOUSE_USERNAME"`
+	Password              string        `env:"CLICKHOUSE_PASSWORD" secret:"true"`
+	Compression           string        `env:"CLICKHOUSE_COMPRESSION" oneof:"lz4 zstd none"`
+	DialTimeout           time.Duration `env:"CLICKHOUSE_DIAL_TIMEOUT" min:"1ns"`
+	PoolSize              int           `env:"CLICKHOUSE_POOL_SIZE" min:"1"`
+	TLS                   bool          `env:"CLICKHOUSE_TLS"`
+	TLSCAFile             string        `env:"CLICKHOUSE_TLS_CA_FILE"`
+	TLSCertFile           string        `env:"CLICKHOUSE_TLS_CERT_FILE"`
+	TLSKeyFile            string        `env:"CLICKHOUSE_TLS_KEY_FILE"`
+	TLSServerName         string        `env:"CLICKHOUSE_TLS_SERVER_NAME"`
+	TLSInsecureSkipVerify bool          `env:"CLICKHOUSE_TLS_INSECURE_SKIP_VERIFY"`
+	BatchRows             int           `env:"CLICKHOUSE_BATCH_ROWS" min:"1"`
+	BatchBytes            int           `env:"CLICKHOUSE_BATCH_BYTES" min:"1"`
+	BatchInterval         time.Duration `env:"CLICKHOUSE_BATCH_INTERVAL" min:"1ns"`
+	MaxRetries            int           `env:"CLICKHOUSE_MAX_RETRIES" min:"0"`
+	MigrationsDir         string        `env:"CLICKHOUSE_MIGRATIONS_DIR"`
+}
+
+// decode - defaults overridden by whatever conf.Decode can parse
+func (c *Conf) decode() (*Config, error) {
+	cfg := &Config{
+		Hosts:         []string{CLICKHOUSE_HOSTS},
+		Database:      CLICKHOUSE_DATABASE,
+		Username:      CLICKHOUSE_USERNAME,
+		Compression:   CLICKHOUSE_COMPRESSION,
+		DialTimeout:   CLICKHOUSE_DIAL_TIMEOUT,
+		PoolSize:      CLICKHOUSE_POOL_SIZE,
+		BatchRows:     CLICKHOUSE_BATCH_ROWS,
+		BatchBytes:    CLICKHOUSE_BATCH_BYTES,
+		BatchInterval: CLICKHOUSE_BATCH_INTERVAL,
+		MaxRetries:    CLICKHOUSE_MAX_RETRIES,
+	}
+	return cfg, conf.Decode(c.EnvConf, cfg)
+}
+
+// Config - the settings decoded by NewConf
+func (c *Conf) Config() *Config {
+	return c.cfg
+}
+
+// hosts - comma separated host[:port] list, tried in order on dial
+func (c *Conf) hosts() []string {
+	port := CLICKHOUSE_PORT
+	if c.tlsEnabled() {
+		port = CLICKHOUSE_TLS_PORT
+	}
+	var hosts []string
+	for _, host := range c.cfg.Hosts {
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(host, port)
+		}
+		hosts = append(hosts, host)
+	}
+	if len(hosts) == 0 {
+		return []string{CLICKHOUSE_HOSTS}
+	}
+	return hosts
+}
+
+func (c *Conf) database() string {
+	return c.cfg.Database
+}
+
+func (c *Conf) username() string {
+	return c.cfg.Username
+}
+
+func (c *Conf) password() string {
+	return c.cfg.Password
+}
+
+// compression - one of lz4, zstd or none
+func (c *Conf) compression() string {
+	return c.cfg.Compression
+}
+
+func (c *Conf) dialTimeout() time.Duration {
+	return c.cfg.DialTimeout
+}
+
+// poolSize - max open connections per engine
+func (c *Conf) poolSize() int {
+	return c.cfg.PoolSize
+}
+
+// tlsEnabled - explicitly enabled or implied by any TLS file being set
+func (c *Conf) tlsEnabled() bool {
+	cfg := c.cfg
+	return cfg.TLS || cfg.TLSCAFile != "" || cfg.TLSCertFile != ""
+}
+
+// tlsConfig - nil when TLS is disabled
+func (c *Conf) tlsConfig() (*tls.Config, error) {
+	if !c.tlsEnabled() {
+		return nil, nil
+	}
+	cfg := c.cfg
+	return conf.TLSOptions{
+		CAFile:             cfg.TLSCAFile,
+		CertFile:           cfg.TLSCertFile,
+		KeyFile:            cfg.TLSKeyFile,
+		ServerName:         cfg.TLSServerName,
+		InsecureSkipVerify: cfg.TLSInsecureSkipVerify,
+	}.Config()
+}
+
+// batchRows - flush a table's buffer once it holds this many rows
+func (c *Conf) batchRows() int {
+	return c.cfg.BatchRows
+}
+
+// batchBytes - flush a table's buffer once it holds roughly this many bytes
+func (c *Conf) batchBytes() int {
+	return c.cfg.BatchBytes
+}
+
+// batchInterval - flush every table's buffer at least this often
+func (c *Conf) batchInterval() time.Duration {
+	return c.cfg.BatchInterval
+}
+
+func (c *Conf) maxRetries() int {
+	return c.cfg.MaxRetries
 }
 
-func NewConf() *Conf {
-	return &Conf{conf.NewEnvConf()}
+// migrationsDir - override the embedded migrations with a directory on disk
+func (c *Conf) migrationsDir() string {
+	return c.cfg.MigrationsDir
 }
@@ -0,0 +1,76 @@
+package clickhouse
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConf(t *testing.T) {
+	t.Run("defaults", confDefaultsTest)
+	t.Run("host list with default ports", confHostsTest)
+	t.Run("tls implies secure port", confTLSPortTest)
+	t.Run("unsupported compression", confCompressionTest)
+	t.Run("missing CA file", confTLSErrorTest)
+}
+
+// confTest - Conf decoded from the current env without errors
+func confTest(t *testing.T) *Conf {
+	cfg, err := NewConf()
+	require.Nil(t, err)
+	return cfg
+}
+
+func confDefaultsTest(t *testing.T) {
+	cfg := confTest(t)
+	assert.Equal(t, []string{CLICKHOUSE_HOSTS}, cfg.hosts())
+	assert.Equal(t, CLICKHOUSE_DATABASE, cfg.database())
+	assert.Equal(t, CLICKHOUSE_USERNAME, cfg.username())
+	assert.Equal(t, CLICKHOUSE_POOL_SIZE, cfg.poolSize())
+	assert.Equal(t, CLICKHOUSE_DIAL_TIMEOUT, cfg.dialTimeout())
+	assert.Equal(t, COMPRESSION_LZ4, cfg.compression())
+	tlsConfig, err := cfg.tlsConfig()
+	require.Nil(t, err)
+	assert.Nil(t, tlsConfig)
+}
+
+func confHostsTest(t *testing.T) {
+	t.Setenv(ENV_CLICKHOUSE_HOSTS, "ch-1.internal, ch-2.internal:9001,,")
+	assert.Equal(t, []string{"ch-1.internal:9000", "ch-2.internal:9001"}, confTest(t).hosts())
+}
+
+func confTLSPortTest(t *testing.T) {
+	t.Setenv(ENV_CLICKHOUSE_HOSTS, "ch-1.internal")
+	t.Setenv(ENV_CLICKHOUSE_TLS, "true")
+	t.Setenv(ENV_CLICKHOUSE_TLS_SERVER_NAME, "clickhouse.internal")
+	cfg := confTest(t)
+	assert.Equal(t, []string{"ch-1.internal:9440"}, cfg.hosts())
+	tlsConfig, err := cfg.tlsConfig()
+	require.Nil(t, err)
+	require.NotNil(t, tlsConfig)
+	assert.Equal(t, "clickhouse.internal", tlsConfig.ServerName)
+}
+
+func confCompressionTest(t *testing.T) {
+	t.Setenv(ENV_CLICKHOUSE_COMPRESSION, "ZSTD")
+	assert.Equal(t, COMPRESSION_ZSTD, confTest(t).compression())
+	t.Setenv(ENV_CLICKHOUSE_COMPRESSION, "snappy")
+	cfg, err := NewConf()
+	assert.ErrorContains(t, err, ENV_CLICKHOUSE_COMPRESSION)
+	// the invalid method is left at its default
+	assert.Equal(t, COMPRESSION_LZ4, cfg.compression())
+}
+
+func confTLSErrorTest(t *testing.T) {
+	t.Setenv(ENV_CLICKHOUSE_TLS_CA_FILE, filepath.Join(t.TempDir(), "missing.pem"))
+	_, err := confTest(t).tlsConfig()
+	assert.Error(t, err)
+	ca := filepath.Join(t.TempDir(), "empty.pem")
+	require.Nil(t, os.WriteFile(ca, []byte("not a certificate"), 0o600))
+	t.Setenv(ENV_CLICKHOUSE_TLS_CA_FILE, ca)
+	_, err = confTest(t).tlsConfig()
+	assert.ErrorContains(t, err, "no certificates found")
+}
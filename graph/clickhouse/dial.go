@@ -0,0 +1,164 @@
+package clickhouse
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ClickHouse/ch-go"
+	"github.com/ClickHouse/ch-go/chpool"
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// failoverDialer - ch-go only dials a single Address, so rotate across
+// every configured host and fall through to the next one on error
+type failoverDialer struct {
+	hosts  []string
+	dialer *net.Dialer
+	tls    *tls.Config
+	next   atomic.Uint32
+}
+
+func (d *failoverDialer) DialContext(ctx context.Context, network, _ string) (net.Conn, error) {
+	start := int(d.next.Add(1)-1) % len(d.hosts)
+	var err error
+	for i := range d.hosts {
+		host := d.hosts[(start+i)%len(d.hosts)]
+		var conn net.Conn
+		if conn, err = d.dial(ctx, network, host); err == nil {
+			return conn, nil
+		}
+		err = fmt.Errorf("dial %s: %w", host, err)
+	}
+	return nil, errors.Join(fmt.Errorf("clickhouse: all %d hosts unreachable", len(d.hosts)), err)
+}
+
+func (d *failoverDialer) dial(ctx context.Context, network, host string) (net.Conn, error) {
+	if d.tls == nil {
+		return d.dialer.DialContext(ctx, network, host)
+	}
+	cfg := d.tls.Clone()
+	if cfg.ServerName == "" {
+		cfg.ServerName, _, _ = net.SplitHostPort(host)
+	}
+	return (&tls.Dialer{NetDialer: d.dialer, Config: cfg}).DialContext(ctx, network, host)
+}
+
+// clientOptions - ch-go options resolved from Conf
+func clientOptions(cfg *Conf) (ch.Options, error) {
+	var opts ch.Options
+	tlsConfig, err := cfg.tlsConfig()
+	if err != nil {
+		return opts, err
+	}
+	hosts := cfg.hosts()
+	opts = ch.Options{
+		ClientName:  APP_INGEST,
+		Address:     hosts[0],
+		Database:    cfg.database(),
+		User:        cfg.username(),
+		Password:    cfg.password(),
+		Compression: chCompression(cfg.compression()),
+		DialTimeout: cfg.dialTimeout(),
+		Dialer: &failoverDialer{
+			hosts:  hosts,
+			dialer: &net.Dialer{Timeout: cfg.dialTimeout()},
+			tls:    tlsConfig,
+		},
+	}
+	return opts, nil
+}
+
+const (
+	// Atomic is the default ClickHouse database engine - non-blocking
+	// DROP / RENAME and UUID based table paths
+	schemaCreateDb = `CREATE DATABASE IF NOT EXISTS %s ENGINE = Atomic COMMENT 'atgraph.dev ClickHouse database'`
+)
+
+// ensureDatabase - create the configured database over a connection to
+// the server's default database since the handshake fails if it's missing
+func ensureDatabase(ctx context.Context, cfg *Conf) error {
+	opts, err := clientOptions(cfg)
+	if err != nil {
+		return err
+	}
+	opts.Database = ""
+	var client *ch.Client
+	if client, err = ch.Dial(ctx, opts); err != nil {
+		return err
+	}
+	defer client.Close()
+	if err = client.Do(ctx, ch.Query{
+		Body: fmt.Sprintf(schemaCreateDb, quoteIdent(cfg.database())),
+	}); err != nil {
+		return fmt.Errorf("error creating database %s: %w", cfg.database(), err)
+	}
+	return nil
+}
+
+// quoteIdent - backtick quote a ClickHouse identifier
+func quoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
+}
+
+// newPool - pooled ch-go connections shared by batch flushes
+func newPool(ctx context.Context, cfg *Conf) (*chpool.Pool, error) {
+	opts, err := clientOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return chpool.Dial(ctx, chpool.Options{
+		ClientOptions: opts,
+		MaxConns:      int32(cfg.poolSize()),
+	})
+}
+
+// openOptions - clickhouse-go (database/sql) options resolved from Conf
+func openOptions(cfg *Conf) (*clickhouse.Options, error) {
+	tlsConfig, err := cfg.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &clickhouse.Options{
+		Addr: cfg.hosts(),
+		Auth: clickhouse.Auth{
+			Database: cfg.database(),
+			Username: cfg.username(),
+			Password: cfg.password(),
+		},
+		TLS:         tlsConfig,
+		DialTimeout: cfg.dialTimeout(),
+		Compression: &clickhouse.Compression{
+			Method: goCompression(cfg.compression()),
+		},
+		MaxOpenConns:     cfg.poolSize(),
+		MaxIdleConns:     min(5, cfg.poolSize()),
+		ConnOpenStrategy: clickhouse.ConnOpenInOrder,
+	}, nil
+}
+
+func chCompression(method string) ch.Compression {
+	switch method {
+	case COMPRESSION_ZSTD:
+		return ch.CompressionZSTD
+	case COMPRESSION_NONE:
+		return ch.CompressionDisabled
+	default:
+		return ch.CompressionLZ4
+	}
+}
+
+func goCompression(method string) clickhouse.CompressionMethod {
+	switch method {
+	case COMPRESSION_ZSTD:
+		return clickhouse.CompressionZSTD
+	case COMPRESSION_NONE:
+		return clickhouse.CompressionNone
+	default:
+		return clickhouse.CompressionLZ4
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"os"
 	"runtime/debug"
 	"time"
 
@@ -12,13 +11,16 @@ import (
 	"github.com/mikeblum/atgraph.dev/bsky"
 	"github.com/mikeblum/atgraph.dev/conf"
 	"github.com/mikeblum/atgraph.dev/graph"
+	"github.com/mikeblum/atgraph.dev/graph/migrate"
 	"github.com/mikeblum/atgraph.dev/version"
 )
 
 type Engine struct {
-	conf *Conf
-	db   *sql.DB
-	log  *conf.Log
+	reader
+	conf     *Conf
+	db       *sql.DB
+	log      *conf.Log
+	migrator *migrate.Migrator
 }
 
 func NewEngine(ctx context.Context) (graph.Engine, error) {
@@ -27,76 +29,82 @@ func NewEngine(ctx context.Context) (graph.Engine, error) {
 	if build, ok = version.BuildVersion(); !ok {
 
 	}
-	conn := clickhouse.OpenDB(&clickhouse.Options{
-		Addr: []string{"127.0.0.1:9000"},
-		Auth: clickhouse.Auth{
-			Database: "atgraph",
-			Username: "default",
-			Password: "",
-		},
-		// TLS: &tls.Config{
-		// 	InsecureSkipVerify: true,
-		// },
-		Settings: clickhouse.Settings{
-			"max_execution_time": time.Second * 60,
-		},
-		DialTimeout: time.Second * 30,
-		Compression: &clickhouse.Compression{
-			Method: clickhouse.CompressionLZ4,
-		},
-		Debug:                true,
-		BlockBufferSize:      10,
-		MaxCompressionBuffer: 10240,
-		ClientInfo: clickhouse.ClientInfo{
-			Products: []struct {
-				Name    string
-				Version string
-			}{
-				{Name: "atgraph", Version: build.Version},
-			},
+	cfg, err := NewConf()
+	if err != nil {
+		return nil, err
+	}
+	if err = ensureDatabase(ctx, cfg); err != nil {
+		return nil, err
+	}
+	opts, err := openOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	opts.Settings = clickhouse.Settings{
+		"max_execution_time": time.Second * 60,
+	}
+	opts.Debug = true
+	opts.BlockBufferSize = 10
+	opts.MaxCompressionBuffer = 10240
+	opts.ClientInfo = clickhouse.ClientInfo{
+		Products: []struct {
+			Name    string
+			Version string
+		}{
+			{Name: "atgraph", Version: build.Version},
 		},
-	})
-	conn.SetMaxIdleConns(5)
-	conn.SetMaxOpenConns(10)
+	}
+	conn := clickhouse.OpenDB(opts)
+	conn.SetMaxIdleConns(opts.MaxIdleConns)
+	conn.SetMaxOpenConns(opts.MaxOpenConns)
 	// conn.SetConnMaxLifetime(time.Hour)
 
 	if err := conn.Ping(); err != nil {
 		return nil, err
 	}
 
+	var migrator *migrate.Migrator
+	if migrator, err = newMigrator(&sqlMigrationStore{db: conn}, cfg); err != nil {
+		conn.Close()
+		return nil, err
+	}
 	engine := &Engine{
-		conf: NewConf(),
-		db:   conn,
-		log:  conf.NewLog(),
+		reader:   newReader(conn),
+		conf:     cfg,
+		db:       conn,
+		log:      conf.NewLog(),
+		migrator: migrator,
 	}
 	return engine, engine.LoadSchema(ctx)
 }
 
+// LoadSchema - apply any pending embedded migrations
 func (e *Engine) LoadSchema(ctx context.Context) error {
-	schemaBytes, err := os.ReadFile("./sql/schema-clickhouse.sql")
-	if err != nil {
-		return fmt.Errorf("failed to read ClickHouse schema file: %w", err)
-	}
-
-	// Execute the schema
-	_, err = e.db.Exec(string(schemaBytes))
-	if err != nil {
+	if _, err := e.migrator.Up(ctx); err != nil {
 		return fmt.Errorf("failed to initialize ClickHouse schema: %w", err)
 	}
-
 	return nil
 }
 
+// Migrator - versioned schema migrations for the atgraph database
+func (e *Engine) Migrator() *migrate.Migrator {
+	return e.migrator
+}
+
 func (e *Engine) CreateIndexes(ctx context.Context) error {
-	// no-op as sqlc generates the schema
+	// no-op as migrations manage the schema
 	return nil
 }
 
 func (e *Engine) CreateConstraints(ctx context.Context) error {
-	// no-op as sqlc generates the schema
+	// no-op as migrations manage the schema
 	return nil
 }
 
+func (e *Engine) Ping(ctx context.Context) error {
+	return e.db.PingContext(ctx)
+}
+
 func (e *Engine) Close(ctx context.Context) error {
 	return e.db.Close()
 }
@@ -105,5 +113,14 @@ func (e *Engine) Ingest(ctx context.Context, workerID int, item bsky.RepoItem) e
 	return fmt.Errorf("ingest not supported - use IngestEngine")
 }
 
-// validate graph.Engine interface is implemented
-var _ graph.Engine = &Engine{}
+// validate graph.Engine, graph.Exporter, graph.History, graph.Migratable, graph.PathFinder,
+// graph.Pinger and graph.Reader interfaces are implemented
+var (
+	_ graph.Engine     = &Engine{}
+	_ graph.Exporter   = &Engine{}
+	_ graph.History    = &Engine{}
+	_ graph.Migratable = &Engine{}
+	_ graph.PathFinder = &Engine{}
+	_ graph.Pinger     = &Engine{}
+	_ graph.Reader     = &Engine{}
+)
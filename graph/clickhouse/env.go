@@ -0,0 +1,43 @@
+package clickhouse
+
+import "time"
+
+const (
+	ENV_CLICKHOUSE_BATCH_BYTES              = "CLICKHOUSE_BATCH_BYTES"
+	ENV_CLICKHOUSE_BATCH_INTERVAL           = "CLICKHOUSE_BATCH_INTERVAL"
+	ENV_CLICKHOUSE_BATCH_ROWS               = "CLICKHOUSE_BATCH_ROWS"
+	ENV_CLICKHOUSE_COMPRESSION              = "CLICKHOUSE_COMPRESSION"
+	ENV_CLICKHOUSE_DATABASE                 = "CLICKHOUSE_DATABASE"
+	ENV_CLICKHOUSE_DIAL_TIMEOUT             = "CLICKHOUSE_DIAL_TIMEOUT"
+	ENV_CLICKHOUSE_HOSTS                    = "CLICKHOUSE_HOSTS"
+	ENV_CLICKHOUSE_MAX_RETRIES              = "CLICKHOUSE_MAX_RETRIES"
+	ENV_CLICKHOUSE_MIGRATIONS_DIR           = "CLICKHOUSE_MIGRATIONS_DIR"
+	ENV_CLICKHOUSE_PASSWORD                 = "CLICKHOUSE_PASSWORD"
+	ENV_CLICKHOUSE_POOL_SIZE                = "CLICKHOUSE_POOL_SIZE"
+	ENV_CLICKHOUSE_TLS                      = "CLICKHOUSE_TLS"
+	ENV_CLICKHOUSE_TLS_CA_FILE              = "CLICKHOUSE_TLS_CA_FILE"
+	ENV_CLICKHOUSE_TLS_CERT_FILE            = "CLICKHOUSE_TLS_CERT_FILE"
+	ENV_CLICKHOUSE_TLS_INSECURE_SKIP_VERIFY = "CLICKHOUSE_TLS_INSECURE_SKIP_VERIFY"
+	ENV_CLICKHOUSE_TLS_KEY_FILE             = "CLICKHOUSE_TLS_KEY_FILE"
+	ENV_CLICKHOUSE_TLS_SERVER_NAME          = "CLICKHOUSE_TLS_SERVER_NAME"
+	ENV_CLICKHOUSE_USERNAME                 = "CLICKHOUSE_USERNAME"
+
+	// defaults
+	CLICKHOUSE_BATCH_BYTES    = 16 << 20 // 16MiB
+	CLICKHOUSE_BATCH_INTERVAL = time.Second * 5
+	CLICKHOUSE_BATCH_ROWS     = 10_000
+	CLICKHOUSE_COMPRESSION    = COMPRESSION_LZ4
+	CLICKHOUSE_DATABASE       = "atgraph"
+	CLICKHOUSE_DIAL_TIMEOUT   = time.Second * 30
+	CLICKHOUSE_HOSTS          = "127.0.0.1:9000"
+	CLICKHOUSE_MAX_RETRIES    = 3
+	CLICKHOUSE_POOL_SIZE      = 10
+	CLICKHOUSE_PORT           = "9000"
+	CLICKHOUSE_TLS_PORT       = "9440"
+	CLICKHOUSE_USERNAME       = "default"
+
+	// supported compression methods
+	COMPRESSION_LZ4  = "lz4"
+	COMPRESSION_ZSTD = "zstd"
+	COMPRESSION_NONE = "none"
+)
@@ -0,0 +1,33 @@
+package clickhouse
+
+import (
+	"context"
+
+	"github.com/mikeblum/atgraph.dev/graph"
+	"github.com/mikeblum/atgraph.dev/graph/clickhouse/internal/db"
+)
+
+// ExportProfiles - stored profiles then a DID-only profile per account
+// that is only known as the end of a follow or block
+func (r reader) ExportProfiles(ctx context.Context, filter graph.ExportFilter, fn func(graph.Profile) error) error {
+	if err := r.queries.ScanProfiles(ctx, filter, func(p db.Profile) error {
+		return fn(toProfile(p))
+	}); err != nil {
+		return err
+	}
+	return r.queries.ScanAccounts(ctx, filter, func(did string) error {
+		return fn(graph.Profile{DID: did})
+	})
+}
+
+func (r reader) ExportFollows(ctx context.Context, filter graph.ExportFilter, fn func(graph.Follow) error) error {
+	return r.queries.ScanEdges(ctx, db.TABLE_FOLLOWS, filter, func(f db.Follow) error {
+		return fn(toFollow(f))
+	})
+}
+
+func (r reader) ExportBlocks(ctx context.Context, filter graph.ExportFilter, fn func(graph.Block) error) error {
+	return r.queries.ScanEdges(ctx, db.TABLE_BLOCKS, filter, func(b db.Block) error {
+		return fn(toBlock(b))
+	})
+}
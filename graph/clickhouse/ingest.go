@@ -6,13 +6,9 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/ClickHouse/ch-go"
-	"github.com/ClickHouse/ch-go/proto"
 	bskyItem "github.com/bluesky-social/indigo/api/bsky"
 	"github.com/bluesky-social/indigo/atproto/syntax"
 	"github.com/mikeblum/atgraph.dev/bsky"
-	"github.com/mikeblum/atgraph.dev/graph/clickhouse/internal/db"
-	"golang.org/x/sync/errgroup"
 )
 
 const (
@@ -22,7 +18,7 @@ const (
 func (e *IngestEngine) Ingest(ctx context.Context, workerID int, item bsky.RepoItem) error {
 	var err error
 
-	var records chan *db.AtgraphProfile
+	var records chan *batchedRecord
 	if records, err = e.ingestItem(ctx, &item); records == nil || err != nil {
 		err = errors.Join(err, e.ingestionErr(&item))
 		return err
@@ -56,9 +52,9 @@ func (e *IngestEngine) Ingest(ctx context.Context, workerID int, item bsky.RepoI
 	}
 }
 
-func (e *IngestEngine) ingestItem(ctx context.Context, item *bsky.RepoItem) (chan *db.AtgraphProfile, error) {
+func (e *IngestEngine) ingestItem(ctx context.Context, item *bsky.RepoItem) (chan *batchedRecord, error) {
 	e.log.With("nsid", item.NSID.String(), "did", item.DID.String(), "action", "ingest", "engine", "clickhouse").Info("Ingesting bsky item")
-	records := make(chan *db.AtgraphProfile, 1)
+	records := make(chan *batchedRecord, 1)
 	defer close(records)
 	var err error
 	var ok bool
@@ -76,10 +72,12 @@ func (e *IngestEngine) ingestItem(ctx context.Context, item *bsky.RepoItem) (cha
 		}
 		return e.ingestProfile(ctx, item, data)
 	case bsky.ITEM_GRAPH_FOLLOW:
-		if _, ok = item.Data.(*bskyItem.GraphFollow); !ok {
+		var data *bskyItem.GraphFollow
+		if data, ok = item.Data.(*bskyItem.GraphFollow); !ok {
 			e.ingestionErr(item)
 			return records, nil
 		}
+		return e.ingestFollow(ctx, item, data)
 	case bsky.ITEM_FEED_REPOST:
 		if _, ok = item.Data.(*bskyItem.FeedRepost); !ok {
 			e.ingestionErr(item)
@@ -91,10 +89,12 @@ func (e *IngestEngine) ingestItem(ctx context.Context, item *bsky.RepoItem) (cha
 			return records, nil
 		}
 	case bsky.ITEM_GRAPH_BLOCK:
-		if _, ok = item.Data.(*bskyItem.GraphBlock); !ok {
+		var data *bskyItem.GraphBlock
+		if data, ok = item.Data.(*bskyItem.GraphBlock); !ok {
 			e.ingestionErr(item)
 			return records, nil
 		}
+		return e.ingestBlock(ctx, item, data)
 	case bsky.ITEM_GRAPH_LIST_BLOCK:
 		if _, ok = item.Data.(*bskyItem.GraphListblock); !ok {
 			e.ingestionErr(item)
@@ -123,78 +123,83 @@ func (e *IngestEngine) ingestionErr(item *bsky.RepoItem) error {
 	return err
 }
 
-func (e *IngestEngine) ingestProfile(ctx context.Context, item *bsky.RepoItem, actor *bskyItem.ActorProfile) (chan *db.AtgraphProfile, error) {
-	var conn *ch.Client
-	var err error
-	records := make(chan *db.AtgraphProfile, 1)
-	group, ctx := errgroup.WithContext(ctx)
-	group.Go(func() error {
+func (e *IngestEngine) ingestProfile(ctx context.Context, item *bsky.RepoItem, actor *bskyItem.ActorProfile) (chan *batchedRecord, error) {
+	records := make(chan *batchedRecord, 1)
+	defer close(records)
 
-		defer close(records)
+	created, err := datetimeMust(item.DID, actor.CreatedAt)
+	if err != nil {
+		e.log.WithError(err).Error("Missing created timestamp", "did", item.DID, "lexicon", actor.LexiconTypeID)
+		// columns must stay aligned - fall back to the unix epoch
+		epoch := time.Unix(0, 0).UTC()
+		created = &epoch
+	}
 
-		// open ch-db conn
-		if conn, err = newConn(ctx); err != nil {
-			return err
-		}
-		defer conn.Close()
-
-		// atgraph.profiles headers
-		var (
-			did         proto.ColStr
-			lexicon     = proto.NewLowCardinality(new(proto.ColStr))
-			handle      proto.ColStr
-			createdTs   = new(proto.ColDateTime64).WithPrecision(proto.PrecisionNano)
-			rev         proto.ColStr
-			sig         proto.ColStr
-			version     proto.ColUInt8
-			description proto.ColStr
-		)
-
-		// load data
-		did.Append(item.DID.String())
-		lexicon.Append(actor.LexiconTypeID)
-		handle.Append(item.Ident.Handle.String())
-
-		if ts, err := datetimeMust(item.DID, actor.CreatedAt); ts != nil {
-			createdTs.Append(*ts)
-		} else {
-			e.log.WithError(err).Error("Missing created timestamp", "did", item.DID, "lexicon", actor.LexiconTypeID)
-		}
+	if err = e.profiles.Append(ctx, func(cols *profileColumns) int {
+		return cols.append(item, actor, *created)
+	}, item.Defer()); err != nil {
+		e.log.WithErrorMsg(err, fmt.Sprintf("Error ingesting %s", actor.LexiconTypeID), "id", item.DID.String(), "action", "ingest", "lexicon", actor.LexiconTypeID)
+		return records, err
+	}
+	records <- newBatchedRecord(item)
+	return records, nil
+}
 
-		rev.Append(item.Rev)
-		sig.Append(item.Sig)
-		version.Append(uint8(item.Version))
-		if actor.Description != nil {
-			description.Append(*actor.Description)
-		} else {
-			description.Append("")
-		}
+func (e *IngestEngine) ingestFollow(ctx context.Context, item *bsky.RepoItem, follow *bskyItem.GraphFollow) (chan *batchedRecord, error) {
+	records := make(chan *batchedRecord, 1)
+	defer close(records)
 
-		input := proto.Input{
-			{Name: "did", Data: did},
-			{Name: "lexicon", Data: lexicon},
-			{Name: "handle", Data: handle},
-			{Name: "created", Data: createdTs},
-			{Name: "rev", Data: rev},
-			{Name: "sig", Data: sig},
-			{Name: "version", Data: version},
-			{Name: "description", Data: description},
-		}
+	created, err := time.Parse(time.RFC3339, follow.CreatedAt)
+	if err != nil {
+		e.log.WithError(err).Error("Invalid created timestamp", "did", item.DID, "rkey", item.RKey, "lexicon", follow.LexiconTypeID)
+		// columns must stay aligned - fall back to the unix epoch
+		created = time.Unix(0, 0).UTC()
+	}
 
-		if err = conn.Do(ctx, ch.Query{
-			Body:  input.Into("profiles"), // helper that generates INSERT INTO query with all columns
-			Input: input,
-		}); err != nil {
-			e.log.WithErrorMsg(err, fmt.Sprintf("Error ingesting %s", actor.LexiconTypeID), "id", item.DID.String(), "action", "ingest", "lexicon", actor.LexiconTypeID)
-			return err
-		}
-		records <- &db.AtgraphProfile{
-			Did: item.DID.String(),
-		}
-		return nil
-	})
+	if err = e.follows.Append(ctx, func(cols *edgeColumns) int {
+		return cols.append(item, follow.Subject, created)
+	}, item.Defer()); err != nil {
+		e.log.WithErrorMsg(err, fmt.Sprintf("Error ingesting %s", follow.LexiconTypeID), "id", item.DID.String(), "action", "ingest", "lexicon", follow.LexiconTypeID)
+		return records, err
+	}
+	records <- newBatchedRecord(item)
+	return records, nil
+}
+
+func (e *IngestEngine) ingestBlock(ctx context.Context, item *bsky.RepoItem, block *bskyItem.GraphBlock) (chan *batchedRecord, error) {
+	records := make(chan *batchedRecord, 1)
+	defer close(records)
+
+	created, err := time.Parse(time.RFC3339, block.CreatedAt)
+	if err != nil {
+		e.log.WithError(err).Error("Invalid created timestamp", "did", item.DID, "rkey", item.RKey, "lexicon", block.LexiconTypeID)
+		// columns must stay aligned - fall back to the unix epoch
+		created = time.Unix(0, 0).UTC()
+	}
 
-	return records, group.Wait()
+	if err = e.blocks.Append(ctx, func(cols *edgeColumns) int {
+		return cols.append(item, block.Subject, created)
+	}, item.Defer()); err != nil {
+		e.log.WithErrorMsg(err, fmt.Sprintf("Error ingesting %s", block.LexiconTypeID), "id", item.DID.String(), "action", "ingest", "lexicon", block.LexiconTypeID)
+		return records, err
+	}
+	records <- newBatchedRecord(item)
+	return records, nil
+}
+
+// batchedRecord - placeholder record for an item queued in a pending batch
+type batchedRecord struct {
+	DID  string `json:"did"`
+	NSID string `json:"nsid"`
+	RKey string `json:"rkey"`
+}
+
+func newBatchedRecord(item *bsky.RepoItem) *batchedRecord {
+	return &batchedRecord{
+		DID:  item.DID.String(),
+		NSID: item.NSID.String(),
+		RKey: item.RKey,
+	}
 }
 
 func datetimeMust(did syntax.DID, datetime *string) (*time.Time, error) {
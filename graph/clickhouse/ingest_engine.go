@@ -2,89 +2,173 @@ package clickhouse
 
 import (
 	"context"
-	"fmt"
-	"os"
+	"database/sql"
 
-	"github.com/ClickHouse/ch-go"
+	"github.com/ClickHouse/ch-go/chpool"
+	"github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/mikeblum/atgraph.dev/conf"
 	"github.com/mikeblum/atgraph.dev/graph"
-)
-
-const (
-	// Clickhouse database schema (incompat with sqlc)
-	schemaCreateDb = `CREATE DATABASE IF NOT EXISTS atgraph ENGINE = postgresql COMMENT 'atgraph.dev ClickHouse database';`
+	"github.com/mikeblum/atgraph.dev/graph/migrate"
 )
 
 type IngestEngine struct {
-	conf *Conf
-	log  *conf.Log
+	reader
+	conf     *Conf
+	db       *sql.DB
+	log      *conf.Log
+	pool     *chpool.Pool
+	batcher  *Batcher
+	migrator *migrate.Migrator
+	profiles *TableBatch[*profileColumns]
+	follows  *TableBatch[*edgeColumns]
+	blocks   *TableBatch[*edgeColumns]
+	syncs    *TableBatch[*syncColumns]
+	scores   *TableBatch[*scoreColumns]
+	recs     *TableBatch[*recommendationColumns]
+}
+
+// ENGINE - name used to select this engine via ATGRAPH_ENGINES
+const ENGINE = "clickhouse"
+
+func init() {
+	graph.Register(ENGINE, NewIngestEngine)
 }
 
 // NewIngestEngine: low-level ch-go impl for bulk inserts
 // https://clickhouse.com/docs/integrations/go#choosing-a-client
 func NewIngestEngine(ctx context.Context) (graph.Engine, error) {
-	engine := &IngestEngine{
-		conf: NewConf(),
-		log:  conf.NewLog(),
+	cfg, err := NewConf()
+	if err != nil {
+		return nil, err
 	}
-	return engine, engine.LoadSchema(ctx)
-}
-
-func newConn(ctx context.Context) (*ch.Client, error) {
-	var conn *ch.Client
-	var err error
-	if conn, err = ch.Dial(ctx, ch.Options{
-		ClientName:  "atgraph.dev:ingest",
-		Database:    "atgraph",
-		Compression: ch.CompressionLZ4,
-	}); err != nil {
+	var pool *chpool.Pool
+	if err = ensureDatabase(ctx, cfg); err != nil {
 		return nil, err
 	}
-	if err := conn.Ping(ctx); err != nil {
+	if pool, err = newPool(ctx, cfg); err != nil {
 		return nil, err
 	}
-	return conn, err
-}
-
-func (e *IngestEngine) LoadSchema(ctx context.Context) error {
-	var conn *ch.Client
-	var err error
-	if conn, err = newConn(ctx); err != nil {
-		return err
+	var batcher *Batcher
+	if batcher, err = NewBatcher(ctx, pool, cfg); err != nil {
+		pool.Close()
+		return nil, err
 	}
-	defer conn.Close()
-	if err = conn.Do(ctx, ch.Query{
-		Body: schemaCreateDb,
-	}); err != nil {
-		e.log.WithError(err).Error("Error creating ClickHouse db")
-		return err
+	var migrator *migrate.Migrator
+	if migrator, err = newMigrator(&migrationStore{conn: pool}, cfg); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	// reads go over database/sql - connections are opened on first use
+	var opts *clickhouse.Options
+	if opts, err = openOptions(cfg); err != nil {
+		pool.Close()
+		return nil, err
 	}
+	conn := clickhouse.OpenDB(opts)
+	engine := &IngestEngine{
+		reader:   newReader(conn),
+		conf:     cfg,
+		db:       conn,
+		log:      conf.NewLog(),
+		pool:     pool,
+		batcher:  batcher,
+		migrator: migrator,
+		profiles: NewTableBatch(batcher, TABLE_PROFILES, newProfileColumns()),
+		follows:  NewTableBatch(batcher, TABLE_FOLLOWS, newEdgeColumns()),
+		blocks:   NewTableBatch(batcher, TABLE_BLOCKS, newEdgeColumns()),
+		syncs:    NewTableBatch(batcher, TABLE_REPO_SYNCS, newSyncColumns()),
+		scores:   NewTableBatch(batcher, TABLE_PROFILE_SCORES, newScoreColumns()),
+		recs:     NewTableBatch(batcher, TABLE_RECOMMENDATIONS, newRecommendationColumns()),
+	}
+	batcher.Start(ctx)
+	return engine, nil
+}
 
-	var schemaBytes []byte
-	if schemaBytes, err = os.ReadFile("./sql/schema-clickhouse.sql"); err != nil {
-		e.log.WithError(err).Error("failed to read ClickHouse schema file")
+// LoadSchema - apply any pending migrations
+func (e *IngestEngine) LoadSchema(ctx context.Context) error {
+	if _, err := e.migrator.Up(ctx); err != nil {
+		e.log.WithErrorMsg(err, "Error migrating ClickHouse schema")
 		return err
 	}
+	return nil
+}
 
-	return conn.Do(ctx, ch.Query{
-		Body: string(schemaBytes),
-	})
+// Migrator - versioned schema migrations for the atgraph database
+func (e *IngestEngine) Migrator() *migrate.Migrator {
+	return e.migrator
 }
 
 func (e *IngestEngine) CreateIndexes(ctx context.Context) error {
-	// no-op as sqlc generates the schema
+	// no-op as migrations manage the schema
 	return nil
 }
 
 func (e *IngestEngine) CreateConstraints(ctx context.Context) error {
-	// no-op as sqlc generates the schema
+	// no-op as migrations manage the schema
+	return nil
+}
+
+// WriteScores - buffer analytics scores into profile_scores
+func (e *IngestEngine) WriteScores(ctx context.Context, scores []graph.Score) error {
+	for _, score := range scores {
+		if err := e.scores.Append(ctx, func(cols *scoreColumns) int {
+			return cols.append(score)
+		}, nil); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// WriteRecommendations - buffer ranked candidates into follow_recommendations,
+// versioned by the run's computed so latest_follow_recommendations only shows
+// the latest run. DIDs without candidates need no rows.
+func (e *IngestEngine) WriteRecommendations(ctx context.Context, recommendations []graph.Recommendations) error {
+	for _, r := range recommendations {
+		if err := e.recs.Append(ctx, func(cols *recommendationColumns) int {
+			return cols.append(r)
+		}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RepoSynced - record that every record of did at rev was ingested. Edges
+// aren't rewritten: removal is derived at read time by db.intervals from the
+// edges present at rev, so this row must not land before them - an edge still
+// in the repo would read as removed. The WorkerPool only calls RepoSynced once
+// every row the repo's items deferred has been flushed.
+func (e *IngestEngine) RepoSynced(ctx context.Context, did, rev string) error {
+	return e.syncs.Append(ctx, func(cols *syncColumns) int {
+		return cols.append(did, rev)
+	}, nil)
+}
+
+// Ping - check the native protocol pool can reach the server
+func (e *IngestEngine) Ping(ctx context.Context) error {
+	return e.pool.Ping(ctx)
+}
+
+// Close - flush any buffered rows before closing the connection pool
 func (e *IngestEngine) Close(ctx context.Context) error {
-	// ch-db client connections should be closed via defer
-	return fmt.Errorf("IngestEngine.Close(context.Context) error not supported")
+	defer e.pool.Close()
+	defer e.db.Close()
+	return e.batcher.Close(ctx)
 }
 
-// validate graph.Engine interface is implemented
-var _ graph.Engine = &IngestEngine{}
+// validate graph.Engine, graph.Exporter, graph.FollowScanner, graph.History,
+// graph.Migratable, graph.PathFinder, graph.Pinger, graph.Reader,
+// graph.RepoSyncer and graph.ScoreWriter interfaces are implemented
+var (
+	_ graph.Engine        = &IngestEngine{}
+	_ graph.Exporter      = &IngestEngine{}
+	_ graph.FollowScanner = &IngestEngine{}
+	_ graph.History       = &IngestEngine{}
+	_ graph.Migratable    = &IngestEngine{}
+	_ graph.PathFinder    = &IngestEngine{}
+	_ graph.Pinger        = &IngestEngine{}
+	_ graph.Reader        = &IngestEngine{}
+	_ graph.RepoSyncer    = &IngestEngine{}
+	_ graph.ScoreWriter   = &IngestEngine{}
+)
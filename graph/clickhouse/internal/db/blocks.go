@@ -0,0 +1,23 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const TABLE_BLOCKS = "blocks"
+
+// BlockColumns - blocks columns in Block field order
+var BlockColumns = FollowColumns
+
+// SelectBlocks - a page of blocks by did after rkey
+func (q *Queries) SelectBlocks(ctx context.Context, did, rkey string, limit int) ([]Block, error) {
+	return q.selectIntervals(ctx, TABLE_BLOCKS, `did = ? AND rkey > ?`, []any{did, rkey},
+		current+` ORDER BY rkey LIMIT ?`, limit)
+}
+
+// SelectBlocksAmong - blocks between dids that were valid at at
+func (q *Queries) SelectBlocksAmong(ctx context.Context, dids []string, at time.Time) ([]Block, error) {
+	return q.selectIntervals(ctx, TABLE_BLOCKS, `did IN (?) AND subject IN (?) AND created <= ?`, []any{dids, dids, at},
+		validAt+` ORDER BY did, rkey`, at, at)
+}
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/ext"
+	"github.com/mikeblum/atgraph.dev/graph"
+)
+
+// TABLE_EXPORT_DIDS - external table carrying an export's DID filter
+const TABLE_EXPORT_DIDS = "export_dids"
+
+var (
+	selectExportProfiles = `SELECT ` + strings.Join(ProfileColumns, ", ") + ` FROM profiles FINAL`
+
+	// accounts only known as the end of an edge - every such account, or
+	// the filtered DIDs without a profile
+	selectEdgeAccounts = `SELECT DISTINCT account FROM (
+    SELECT arrayJoin([did, subject]) AS account FROM ` + TABLE_FOLLOWS + `
+    UNION ALL
+    SELECT arrayJoin([did, subject]) AS account FROM ` + TABLE_BLOCKS + `
+) WHERE account NOT IN (SELECT did FROM profiles)`
+	selectFilteredAccounts = `SELECT did FROM ` + TABLE_EXPORT_DIDS + ` WHERE did NOT IN (SELECT did FROM profiles)`
+)
+
+// exportContext - exports stream for longer than the connection's
+// max_execution_time and ship their DID filter as an external table so
+// it isn't bound into the query text, which appears several times per
+// intervals() query and would outgrow max_query_size
+func exportContext(ctx context.Context, dids []string) (context.Context, error) {
+	opts := []clickhouse.QueryOption{
+		clickhouse.WithSettings(clickhouse.Settings{"max_execution_time": 0}),
+	}
+	if len(dids) > 0 {
+		table, err := ext.NewTable(TABLE_EXPORT_DIDS, ext.Column("did", "String"))
+		if err != nil {
+			return nil, err
+		}
+		for _, did := range dids {
+			if err = table.Append(did); err != nil {
+				return nil, err
+			}
+		}
+		opts = append(opts, clickhouse.WithExternalTable(table))
+	}
+	return clickhouse.Context(ctx, opts...), nil
+}
+
+// ScanProfiles - call fn with every stored profile matching filter.DIDs
+func (q *Queries) ScanProfiles(ctx context.Context, filter graph.ExportFilter, fn func(Profile) error) error {
+	ctx, err := exportContext(ctx, filter.DIDs)
+	if err != nil {
+		return err
+	}
+	query := selectExportProfiles
+	if len(filter.DIDs) > 0 {
+		query += ` WHERE did IN ` + TABLE_EXPORT_DIDS
+	}
+	rows, err := q.db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	return stream(rows, scanProfile, fn)
+}
+
+// ScanAccounts - call fn with the DID of every account matching filter.DIDs
+// that has no stored profile
+func (q *Queries) ScanAccounts(ctx context.Context, filter graph.ExportFilter, fn func(did string) error) error {
+	ctx, err := exportContext(ctx, filter.DIDs)
+	if err != nil {
+		return err
+	}
+	query := selectEdgeAccounts
+	if len(filter.DIDs) > 0 {
+		query = selectFilteredAccounts
+	}
+	rows, err := q.db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	return stream(rows, func(row scanner) (string, error) {
+		var did string
+		err := row.Scan(&did)
+		return did, err
+	}, fn)
+}
+
+// ScanEdges - call fn with every follows or blocks row matching filter
+func (q *Queries) ScanEdges(ctx context.Context, table string, filter graph.ExportFilter, fn func(Follow) error) error {
+	ctx, err := exportContext(ctx, filter.DIDs)
+	if err != nil {
+		return err
+	}
+	where := []string{`1`}
+	var args []any
+	if len(filter.DIDs) > 0 {
+		where = append(where, `did IN `+TABLE_EXPORT_DIDS+` AND subject IN `+TABLE_EXPORT_DIDS)
+	}
+	if !filter.Since.IsZero() {
+		where = append(where, `created >= ?`)
+		args = append(args, filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		where = append(where, `created < ?`)
+		args = append(args, filter.Until)
+	}
+	query, bound := intervals(table, strings.Join(where, ` AND `), args...)
+	query = `SELECT ` + strings.Join(FollowColumns, ", ") + `, removed FROM (` + query + `)`
+	if filter.Current {
+		query += ` WHERE ` + current
+	}
+	rows, err := q.db.QueryContext(ctx, query, bound...)
+	if err != nil {
+		return err
+	}
+	return stream(rows, scanFollow, fn)
+}
@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const TABLE_FOLLOWS = "follows"
+
+// FollowColumns - follows columns in Follow field order
+var FollowColumns = []string{
+	"did",
+	"rkey",
+	"subject",
+	"created",
+	"ingested",
+	"rev",
+	"rev_version",
+	"uri",
+	"cid",
+}
+
+// scanFollow - FollowColumns then removed
+func scanFollow(row scanner) (Follow, error) {
+	var f Follow
+	err := row.Scan(
+		&f.Did,
+		&f.RKey,
+		&f.Subject,
+		&f.Created,
+		&f.Ingested,
+		&f.Rev,
+		&f.RevVersion,
+		&f.URI,
+		&f.CID,
+		&f.Removed,
+	)
+	return f, err
+}
+
+// keyset pagination: each page starts after the sort key of the previous page's last row
+
+// SelectFollowing - a page of accounts did follows after rkey
+func (q *Queries) SelectFollowing(ctx context.Context, did, rkey string, limit int) ([]Follow, error) {
+	return q.selectIntervals(ctx, TABLE_FOLLOWS, `did = ? AND rkey > ?`, []any{did, rkey},
+		current+` ORDER BY rkey LIMIT ?`, limit)
+}
+
+// SelectFollowers - a page of follows whose subject is did after (follower, rkey)
+func (q *Queries) SelectFollowers(ctx context.Context, did, follower, rkey string, limit int) ([]Follow, error) {
+	return q.selectIntervals(ctx, TABLE_FOLLOWS, `subject = ? AND (did, rkey) > (?, ?)`, []any{did, follower, rkey},
+		current+` ORDER BY did, rkey LIMIT ?`, limit)
+}
+
+// SelectFollowersAsOf - a page of follows whose subject is did that were valid at at, after (follower, rkey)
+func (q *Queries) SelectFollowersAsOf(ctx context.Context, did string, at time.Time, follower, rkey string, limit int) ([]Follow, error) {
+	return q.selectIntervals(ctx, TABLE_FOLLOWS, `subject = ? AND created <= ? AND (did, rkey) > (?, ?)`, []any{did, at, follower, rkey},
+		validAt+` ORDER BY did, rkey LIMIT ?`, at, at, limit)
+}
+
+// SelectMutualFollows - a page of follows by did whose subject follows did back after (subject, rkey)
+func (q *Queries) SelectMutualFollows(ctx context.Context, did, subject, rkey string, limit int) ([]Follow, error) {
+	followers, args := intervals(TABLE_FOLLOWS, `subject = ?`, did)
+	return q.selectIntervals(ctx, TABLE_FOLLOWS,
+		`did = ? AND (subject, rkey) > (?, ?) AND subject IN (SELECT did FROM (`+followers+`) WHERE `+current+`)`,
+		append([]any{did, subject, rkey}, args...),
+		current+` ORDER BY subject, rkey LIMIT ?`, limit)
+}
+
+// SelectFollowsAmong - follows between dids that were valid at at
+func (q *Queries) SelectFollowsAmong(ctx context.Context, dids []string, at time.Time) ([]Follow, error) {
+	return q.selectIntervals(ctx, TABLE_FOLLOWS, `did IN (?) AND subject IN (?) AND created <= ?`, []any{dids, dids, at},
+		validAt+` ORDER BY did, rkey`, at, at)
+}
+
+// SelectFollowCounts - current follower / following totals for did, follows
+// deleted from their repo are excluded unlike in the follower_counts and
+// following_counts views
+func (q *Queries) SelectFollowCounts(ctx context.Context, did string) (FollowCounts, error) {
+	followers, followerArgs := intervals(TABLE_FOLLOWS, `subject = ?`, did)
+	following, followingArgs := intervals(TABLE_FOLLOWS, `did = ?`, did)
+	query := `SELECT
+    ?,
+    (SELECT uniqExact(did) FROM (` + followers + `) WHERE ` + current + `),
+    (SELECT uniqExact(subject) FROM (` + following + `) WHERE ` + current + `)`
+	var counts FollowCounts
+	err := q.db.QueryRowContext(ctx, query, append(append([]any{did}, followerArgs...), followingArgs...)...).Scan(
+		&counts.Did,
+		&counts.Followers,
+		&counts.Following,
+	)
+	return counts, err
+}
+
+// SelectFollowDeltas - followers gained and lost per day since the given day:
+// a follow is gained on the day it was created and lost on the day its removal
+// was synced
+func (q *Queries) SelectFollowDeltas(ctx context.Context, did string, since time.Time) ([]FollowDelta, error) {
+	query, args := intervals(TABLE_FOLLOWS, `subject = ?`, did)
+	rows, err := q.db.QueryContext(ctx, `SELECT day, uniqExactIf(did, lost = 0), uniqExactIf(did, lost = 1) FROM (
+    SELECT toDate(created) AS day, did, 0 AS lost FROM (`+query+`)
+    UNION ALL
+    SELECT toDate(assumeNotNull(removed)) AS day, did, 1 AS lost FROM (`+query+`) WHERE removed IS NOT NULL
+) WHERE day >= toDate(?) GROUP BY day ORDER BY day`, append(append(append([]any{}, args...), args...), since)...)
+	if err != nil {
+		return nil, err
+	}
+	return collect(rows, func(row scanner) (FollowDelta, error) {
+		var d FollowDelta
+		err := row.Scan(&d.Day, &d.Gained, &d.Lost)
+		return d, err
+	})
+}
+
+// ScanFollowEdges - call fn with every current (did, subject) follow edge, streaming rows
+func (q *Queries) ScanFollowEdges(ctx context.Context, fn func(did, subject string) error) error {
+	query, args := intervals(TABLE_FOLLOWS, `1`)
+	rows, err := q.db.QueryContext(ctx, `SELECT did, subject FROM (`+query+`) WHERE `+current, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	var did, subject string
+	for rows.Next() {
+		if err = rows.Scan(&did, &subject); err != nil {
+			return err
+		}
+		if err = fn(did, subject); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// SelectNeighbours - accounts each of dids currently follows, or is followed
+// by if reverse, keyed by DID. mutual only keeps follows that are followed back.
+// slices bind as comma separated lists for IN (?)
+func (q *Queries) SelectNeighbours(ctx context.Context, dids []string, reverse, mutual bool) (map[string][]string, error) {
+	var query string
+	var args []any
+	switch {
+	case mutual:
+		followers, followerArgs := intervals(TABLE_FOLLOWS, `subject IN (?)`, dids)
+		query, args = intervals(TABLE_FOLLOWS,
+			`did IN (?) AND (subject, did) IN (SELECT did, subject FROM (`+followers+`) WHERE `+current+`)`,
+			append([]any{dids}, followerArgs...)...)
+		query = `SELECT did, subject FROM (` + query + `) WHERE ` + current + ` ORDER BY did, subject`
+	case reverse:
+		query, args = intervals(TABLE_FOLLOWS, `subject IN (?)`, dids)
+		query = `SELECT subject, did FROM (` + query + `) WHERE ` + current + ` ORDER BY subject, did`
+	default:
+		query, args = intervals(TABLE_FOLLOWS, `did IN (?)`, dids)
+		query = `SELECT did, subject FROM (` + query + `) WHERE ` + current + ` ORDER BY did, subject`
+	}
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	neighbours := make(map[string][]string, len(dids))
+	var did, neighbour string
+	for rows.Next() {
+		if err = rows.Scan(&did, &neighbour); err != nil {
+			return nil, err
+		}
+		neighbours[did] = append(neighbours[did], neighbour)
+	}
+	return neighbours, rows.Err()
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"strings"
+)
+
+const (
+	// validAt - interval filter over intervals() rows: ? is the point in time
+	validAt = `created <= ? AND (removed IS NULL OR removed > ?)`
+	// current - edges still present in their repo
+	current = `removed IS NULL`
+)
+
+// intervalColumns - FollowColumns qualified for the left side of the ASOF JOIN
+var intervalColumns = func() string {
+	cols := make([]string, 0, len(FollowColumns))
+	for _, col := range FollowColumns {
+		cols = append(cols, "e."+col+" AS "+col)
+	}
+	return strings.Join(cols, ", ")
+}()
+
+// intervals - follows or blocks rows matching where, each with the time it
+// was removed from its repo: the commit time of the first repo sync newer
+// than the rev the edge was last seen at, NULL if there is none. where
+// restricts both sides of the ASOF JOIN so its args are bound twice.
+func intervals(table, where string, args ...any) (string, []any) {
+	query := `SELECT ` + intervalColumns + `, if(s.rev_version > 0, s.synced, NULL) AS removed
+FROM (SELECT * FROM ` + table + ` FINAL WHERE ` + where + `) AS e
+ASOF LEFT JOIN (
+    SELECT did, rev_version, synced FROM repo_syncs
+    WHERE did IN (SELECT did FROM ` + table + ` WHERE ` + where + `)
+) AS s ON e.did = s.did AND e.rev_version < s.rev_version`
+	return query, append(append([]any{}, args...), args...)
+}
+
+// selectIntervals - intervals() rows matching filter ie. current or validAt plus ORDER BY / LIMIT
+func (q *Queries) selectIntervals(ctx context.Context, table, where string, args []any, filter string, filterArgs ...any) ([]Follow, error) {
+	query, bound := intervals(table, where, args...)
+	rows, err := q.db.QueryContext(ctx,
+		`SELECT `+strings.Join(FollowColumns, ", ")+`, removed FROM (`+query+`) WHERE `+filter,
+		append(bound, filterArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	return collect(rows, scanFollow)
+}
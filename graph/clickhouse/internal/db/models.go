@@ -1,21 +1,67 @@
-// Code generated by sqlc. DO NOT EDIT.
-// versions:
-//   sqlc v1.28.0
-
 package db
 
 import (
-	"database/sql"
+	"time"
 )
 
-type AtgraphProfile struct {
-	Did      string
-	Lexicon  string
-	Handle   sql.NullString
-	Created  interface{}
-	Ingested interface{}
-	Updated  interface{}
-	Rev      sql.NullString
-	Sig      sql.NullString
-	Version  interface{}
+// Profile - profiles row (app.bsky.actor.profile)
+type Profile struct {
+	Did         string
+	Lexicon     string
+	Handle      string
+	Created     time.Time
+	Ingested    time.Time
+	Updated     *time.Time
+	Rev         string
+	RevVersion  uint64
+	Sig         string
+	Version     uint8
+	Description string
+	RKey        string
+	URI         string
+	CID         string
+	DisplayName string
+	AvatarCID   string
+	AvatarMime  string
+	AvatarSize  int64
+	BannerCID   string
+	BannerMime  string
+	BannerSize  int64
+	Labels      []string
+	PinnedURI   string
+	PinnedCID   string
+	PackURI     string
+	PackCID     string
+}
+
+// Follow - follows row (app.bsky.graph.follow)
+type Follow struct {
+	Did        string
+	RKey       string
+	Subject    string
+	Created    time.Time
+	Ingested   time.Time
+	Rev        string
+	RevVersion uint64
+	URI        string
+	CID        string
+	// Removed - first later sync of the repo without this edge, nil if none
+	Removed *time.Time
+}
+
+// Block - blocks row (app.bsky.graph.block), same layout as follows
+type Block = Follow
+
+// FollowCounts - current follower / following totals
+type FollowCounts struct {
+	Did       string
+	Followers uint64
+	Following uint64
+}
+
+// FollowDelta - distinct followers gained and lost on a UTC day
+type FollowDelta struct {
+	Day    time.Time
+	Gained uint64
+	Lost   uint64
 }
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"strings"
+)
+
+// ProfileColumns - profiles columns in Profile field order
+var ProfileColumns = []string{
+	"did",
+	"lexicon",
+	"handle",
+	"created",
+	"ingested",
+	"updated",
+	"rev",
+	"rev_version",
+	"sig",
+	"version",
+	"description",
+	"rkey",
+	"uri",
+	"cid",
+	"display_name",
+	"avatar_cid",
+	"avatar_mime_type",
+	"avatar_size",
+	"banner_cid",
+	"banner_mime_type",
+	"banner_size",
+	"labels",
+	"pinned_post_uri",
+	"pinned_post_cid",
+	"starter_pack_uri",
+	"starter_pack_cid",
+}
+
+var (
+	// FINAL collapses ReplacingMergeTree duplicates at read time
+	selectProfiles = `SELECT ` + strings.Join(ProfileColumns, ", ") + ` FROM profiles FINAL ORDER BY did LIMIT ? OFFSET ?`
+	selectProfile  = `SELECT ` + strings.Join(ProfileColumns, ", ") + ` FROM profiles FINAL WHERE did = ? LIMIT 1`
+	countProfiles  = `SELECT count() FROM profiles FINAL`
+)
+
+func scanProfile(row scanner) (Profile, error) {
+	var p Profile
+	err := row.Scan(
+		&p.Did,
+		&p.Lexicon,
+		&p.Handle,
+		&p.Created,
+		&p.Ingested,
+		&p.Updated,
+		&p.Rev,
+		&p.RevVersion,
+		&p.Sig,
+		&p.Version,
+		&p.Description,
+		&p.RKey,
+		&p.URI,
+		&p.CID,
+		&p.DisplayName,
+		&p.AvatarCID,
+		&p.AvatarMime,
+		&p.AvatarSize,
+		&p.BannerCID,
+		&p.BannerMime,
+		&p.BannerSize,
+		&p.Labels,
+		&p.PinnedURI,
+		&p.PinnedCID,
+		&p.PackURI,
+		&p.PackCID,
+	)
+	return p, err
+}
+
+// SelectProfiles - a page of profiles ordered by DID
+func (q *Queries) SelectProfiles(ctx context.Context, limit, offset int) ([]Profile, error) {
+	rows, err := q.db.QueryContext(ctx, selectProfiles, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return collect(rows, scanProfile)
+}
+
+// SelectProfile - a single profile by DID, sql.ErrNoRows if missing
+func (q *Queries) SelectProfile(ctx context.Context, did string) (Profile, error) {
+	return scanProfile(q.db.QueryRowContext(ctx, selectProfile, did))
+}
+
+func (q *Queries) CountProfiles(ctx context.Context) (uint64, error) {
+	var count uint64
+	err := q.db.QueryRowContext(ctx, countProfiles).Scan(&count)
+	return count, err
+}
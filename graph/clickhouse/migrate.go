@@ -0,0 +1,149 @@
+package clickhouse
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/ch-go"
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/mikeblum/atgraph.dev/graph/migrate"
+)
+
+const (
+	TABLE_SCHEMA_MIGRATIONS = "schema_migrations"
+
+	schemaMigrations = `CREATE TABLE IF NOT EXISTS schema_migrations
+(
+    version  UInt32                                       COMMENT 'version: migration file number',
+    name     String                                       COMMENT 'name: migration file name without version or extension',
+    checksum String                                       COMMENT 'checksum: sha256 of the migration file when applied',
+    applied  DateTime64(9, 'UTC') NOT NULL DEFAULT now64(9) COMMENT 'applied: timestamp the migration finished'
+)
+ENGINE = ReplacingMergeTree(applied)
+ORDER BY version`
+	selectMigrations = `SELECT version, name, checksum, applied FROM schema_migrations FINAL ORDER BY version`
+	insertMigration  = `INSERT INTO schema_migrations (version, name, checksum) VALUES (?, ?, ?)`
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// migrationStore - records applied migrations in schema_migrations.
+// ClickHouse DDL is not transactional so statements must be idempotent.
+type migrationStore struct {
+	conn inserter
+}
+
+func (s *migrationStore) Init(ctx context.Context) error {
+	return s.conn.Do(ctx, ch.Query{Body: schemaMigrations})
+}
+
+func (s *migrationStore) Applied(ctx context.Context) ([]migrate.Applied, error) {
+	var (
+		version  proto.ColUInt32
+		name     proto.ColStr
+		checksum proto.ColStr
+		applied  = new(proto.ColDateTime64).WithPrecision(proto.PrecisionNano)
+		rows     []migrate.Applied
+	)
+	err := s.conn.Do(ctx, ch.Query{
+		Body: selectMigrations,
+		Result: proto.Results{
+			{Name: "version", Data: &version},
+			{Name: "name", Data: &name},
+			{Name: "checksum", Data: &checksum},
+			{Name: "applied", Data: applied},
+		},
+		OnResult: func(ctx context.Context, block proto.Block) error {
+			for i := range version.Rows() {
+				rows = append(rows, migrate.Applied{
+					Version:  int(version.Row(i)),
+					Name:     name.Row(i),
+					Checksum: checksum.Row(i),
+					Applied:  applied.Row(i),
+				})
+			}
+			return nil
+		},
+	})
+	return rows, err
+}
+
+func (s *migrationStore) Apply(ctx context.Context, migration migrate.Migration) error {
+	for i, stmt := range migration.Statements {
+		if err := s.conn.Do(ctx, ch.Query{Body: stmt}); err != nil {
+			return fmt.Errorf("statement %d: %w", i+1, err)
+		}
+	}
+	var (
+		version  proto.ColUInt32
+		name     proto.ColStr
+		checksum proto.ColStr
+		applied  = new(proto.ColDateTime64).WithPrecision(proto.PrecisionNano)
+	)
+	version.Append(uint32(migration.Version))
+	name.Append(migration.Name)
+	checksum.Append(migration.Checksum)
+	applied.Append(time.Now().UTC())
+	input := proto.Input{
+		{Name: "version", Data: &version},
+		{Name: "name", Data: &name},
+		{Name: "checksum", Data: &checksum},
+		{Name: "applied", Data: applied},
+	}
+	return s.conn.Do(ctx, ch.Query{
+		Body:  input.Into(TABLE_SCHEMA_MIGRATIONS),
+		Input: input,
+	})
+}
+
+// sqlMigrationStore - migrationStore over database/sql for Engine
+type sqlMigrationStore struct {
+	db *sql.DB
+}
+
+func (s *sqlMigrationStore) Init(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, schemaMigrations)
+	return err
+}
+
+func (s *sqlMigrationStore) Applied(ctx context.Context) ([]migrate.Applied, error) {
+	rows, err := s.db.QueryContext(ctx, selectMigrations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var applied []migrate.Applied
+	for rows.Next() {
+		var a migrate.Applied
+		var version uint32
+		if err = rows.Scan(&version, &a.Name, &a.Checksum, &a.Applied); err != nil {
+			return nil, err
+		}
+		a.Version = int(version)
+		applied = append(applied, a)
+	}
+	return applied, rows.Err()
+}
+
+func (s *sqlMigrationStore) Apply(ctx context.Context, migration migrate.Migration) error {
+	for i, stmt := range migration.Statements {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("statement %d: %w", i+1, err)
+		}
+	}
+	_, err := s.db.ExecContext(ctx, insertMigration, uint32(migration.Version), migration.Name, migration.Checksum)
+	return err
+}
+
+// newMigrator - embedded (or CLICKHOUSE_MIGRATIONS_DIR) migrations recorded by store
+func newMigrator(store migrate.Store, cfg *Conf) (*migrate.Migrator, error) {
+	loaded, err := migrate.LoadFrom(migrations, "migrations", cfg.migrationsDir())
+	if err != nil {
+		return nil, err
+	}
+	return migrate.NewMigrator(ENGINE, store, loaded), nil
+}
@@ -0,0 +1,22 @@
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/mikeblum/atgraph.dev/graph/migrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations(t *testing.T) {
+	loaded, err := migrate.Load(migrations, "migrations")
+	require.Nil(t, err)
+	require.NotEmpty(t, loaded)
+	for i, migration := range loaded {
+		assert.Equal(t, i+1, migration.Version, "migrations must be numbered without gaps")
+		for _, stmt := range migration.Statements {
+			// ch-go executes a single statement per query
+			assert.NotContains(t, stmt, ";\n", "%04d_%s", migration.Version, migration.Name)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+-- app.bsky.actor.profile description
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS description String DEFAULT '' COMMENT 'description: (string, optional): free-form profile description text';
@@ -0,0 +1,22 @@
+-- app.bsky.graph.follow
+-- ReplacingMergeTree(rev_version): the row read from the newest repo rev wins
+-- regardless of insert order
+CREATE TABLE IF NOT EXISTS follows
+(
+    did         String                                         COMMENT 'did: account DID of the follower (repo owner)',
+    rkey        String                                         COMMENT 'rkey: record key of the follow within the repo, unique per did',
+    subject     String                                         COMMENT 'subject: DID of the followed account',
+    created     DateTime64(9, 'UTC')                           COMMENT 'created: app.bsky.graph.follow created timestamp',
+    ingested    DateTime64(9, 'UTC') DEFAULT now64(9)          COMMENT 'ingested: timestamp for tracking ingestion lag time',
+    rev         String                                         COMMENT 'rev: (string, TID format): revision of the repo the follow was read from',
+    rev_version UInt64 DEFAULT 0                               COMMENT 'rev_version: rev TID as an integer - ReplacingMergeTree version',
+    uri         String DEFAULT ''                              COMMENT 'uri: at://<did>/<collection>/<rkey>',
+    cid         String DEFAULT ''                              COMMENT 'cid: CID of the record block'
+)
+ENGINE = ReplacingMergeTree(rev_version)
+PRIMARY KEY(did, rkey)
+ORDER BY (did, rkey);
+
+-- reverse lookups: who follows subject
+ALTER TABLE follows
+    ADD INDEX IF NOT EXISTS idx_follows_subject subject TYPE bloom_filter GRANULARITY 4;
@@ -0,0 +1,54 @@
+-- follower / following counts per DID and daily follow deltas, maintained on insert into follows.
+-- uniqExact dedupes re-ingested follows so backfill retries don't inflate counts.
+-- Read with uniqExactMerge(...) and GROUP BY.
+--
+-- These are "ever followed" totals: the views only see inserts, so a follow
+-- deleted from its repo is never subtracted and unfollows never show up as a
+-- daily loss. Current counts have to be derived from the follows rows still
+-- present in their repo.
+CREATE TABLE IF NOT EXISTS follower_counts
+(
+    did         String                                         COMMENT 'did: followed account',
+    followers   AggregateFunction(uniqExact, String)           COMMENT 'followers: distinct DIDs that ever followed did'
+)
+ENGINE = AggregatingMergeTree
+ORDER BY did;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS follower_counts_mv TO follower_counts AS
+SELECT
+    subject AS did,
+    uniqExactState(did) AS followers
+FROM follows
+GROUP BY subject;
+
+CREATE TABLE IF NOT EXISTS following_counts
+(
+    did         String                                         COMMENT 'did: following account',
+    following   AggregateFunction(uniqExact, String)           COMMENT 'following: distinct DIDs did ever followed'
+)
+ENGINE = AggregatingMergeTree
+ORDER BY did;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS following_counts_mv TO following_counts AS
+SELECT
+    did,
+    uniqExactState(subject) AS following
+FROM follows
+GROUP BY did;
+
+CREATE TABLE IF NOT EXISTS follow_deltas_daily
+(
+    day         Date                                           COMMENT 'day: UTC day the follow was created',
+    did         String                                         COMMENT 'did: followed account',
+    gained      AggregateFunction(uniqExact, String)           COMMENT 'gained: distinct followers gained that day'
+)
+ENGINE = AggregatingMergeTree
+ORDER BY (did, day);
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS follow_deltas_daily_mv TO follow_deltas_daily AS
+SELECT
+    toDate(created) AS day,
+    subject AS did,
+    uniqExactState(did) AS gained
+FROM follows
+GROUP BY day, subject;
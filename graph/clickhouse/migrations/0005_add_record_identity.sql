@@ -0,0 +1,11 @@
+-- record level identity: rkey, AT-URI and CID of the record block
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS rkey String DEFAULT '' COMMENT 'rkey: record key within the repo (self for app.bsky.actor.profile)';
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS uri String DEFAULT '' COMMENT 'uri: at://<did>/<collection>/<rkey>';
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS cid String DEFAULT '' COMMENT 'cid: CID of the record block';
+ALTER TABLE follows
+    ADD COLUMN IF NOT EXISTS uri String DEFAULT '' COMMENT 'uri: at://<did>/<collection>/<rkey>';
+ALTER TABLE follows
+    ADD COLUMN IF NOT EXISTS cid String DEFAULT '' COMMENT 'cid: CID of the record block';
@@ -0,0 +1,41 @@
+-- Rebuild profiles as ReplacingMergeTree(rev_version) so the newest repo rev
+-- wins regardless of insert order. follows is created that way by 0003. The
+-- engine of an existing table can't be altered: copy into profiles_next,
+-- RENAME it into place and drop the old table as profiles_v1. Re-running after
+-- a failure reuses a leftover profiles_next, rows copied twice are collapsed by
+-- the new engine. The only step that doesn't retry cleanly is a failure
+-- between the RENAME and the DROP: the next RENAME then fails on the existing
+-- profiles_v1, which has to be dropped by hand.
+--
+-- Copied rows get rev_version 0 so any re-ingest of the repo replaces them.
+-- Stop ingestion while this runs: profiles inserted after the copy but before
+-- the RENAME stay in the dropped profiles_v1 table.
+
+CREATE TABLE IF NOT EXISTS profiles_next
+(
+    did         String                                         COMMENT 'did: (string, required): the account DID associated with the repo, in strictly normalized form (eg, lowercase as appropriate)',
+    lexicon     LowCardinality(String)                         COMMENT 'lexicon: atproto lexicon type ex. app.bsky.actor.profile',
+    handle      String                                         COMMENT 'handle: atproto handle (changes when using custom domains)',
+    created     DateTime64(9, 'UTC')                           COMMENT 'created: app.bsky.actor.profile created timestamp',
+    ingested    DateTime64(9, 'UTC') DEFAULT now64(9)          COMMENT 'ingested: timestamp for tracking ingestion lag time',
+    updated     Nullable(DateTime64(9, 'UTC')) DEFAULT NULL    COMMENT 'updated: timestamp for tracking ingestion lag time',
+    rev         String                                         COMMENT '(string, TID format, required): revision of the repo, used as a logical clock. Must increase monotonically. Recommend using current timestamp as TID; rev values in the "future" (beyond a fudge factor) should be ignored and not processed.',
+    rev_version UInt64 DEFAULT 0                               COMMENT 'rev_version: rev TID as an integer - ReplacingMergeTree version',
+    sig         String                                         COMMENT 'sig: (byte array, required): cryptographic signature of this commit, as raw bytes',
+    version     UInt8                                          COMMENT 'version: (integer, required): fixed value of 3 for this repo format version',
+    description String DEFAULT ''                              COMMENT 'description: (string, optional): free-form profile description text',
+    rkey        String DEFAULT ''                              COMMENT 'rkey: record key within the repo (self for app.bsky.actor.profile)',
+    uri         String DEFAULT ''                              COMMENT 'uri: at://<did>/<collection>/<rkey>',
+    cid         String DEFAULT ''                              COMMENT 'cid: CID of the record block'
+)
+ENGINE = ReplacingMergeTree(rev_version)
+PRIMARY KEY(did)
+ORDER BY did;
+
+INSERT INTO profiles_next (did, lexicon, handle, created, ingested, updated, rev, sig, version, description, rkey, uri, cid)
+SELECT did, lexicon, handle, created, ingested, updated, rev, sig, version, description, rkey, uri, cid
+FROM profiles;
+
+RENAME TABLE profiles TO profiles_v1, profiles_next TO profiles;
+
+DROP TABLE IF EXISTS profiles_v1;
@@ -0,0 +1,30 @@
+-- app.bsky.actor.profile content: display name, avatar / banner blobs, self-labels,
+-- pinned post and the starter pack the account joined via (profile -> starter pack edge)
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS display_name String DEFAULT '' COMMENT 'display_name: (string, optional): profile display name';
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS avatar_cid String DEFAULT '' COMMENT 'avatar_cid: CID of the avatar blob';
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS avatar_mime_type LowCardinality(String) DEFAULT '' COMMENT 'avatar_mime_type: mime type of the avatar blob ex. image/jpeg';
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS avatar_size Int64 DEFAULT 0 COMMENT 'avatar_size: size of the avatar blob in bytes';
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS banner_cid String DEFAULT '' COMMENT 'banner_cid: CID of the banner blob';
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS banner_mime_type LowCardinality(String) DEFAULT '' COMMENT 'banner_mime_type: mime type of the banner blob ex. image/jpeg';
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS banner_size Int64 DEFAULT 0 COMMENT 'banner_size: size of the banner blob in bytes';
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS labels Array(String) DEFAULT [] COMMENT 'labels: self-label values on the account ex. !no-unauthenticated';
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS pinned_post_uri String DEFAULT '' COMMENT 'pinned_post_uri: AT-URI of the pinned app.bsky.feed.post';
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS pinned_post_cid String DEFAULT '' COMMENT 'pinned_post_cid: CID of the pinned app.bsky.feed.post';
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS starter_pack_uri String DEFAULT '' COMMENT 'starter_pack_uri: AT-URI of the app.bsky.graph.starterpack the account joined via';
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS starter_pack_cid String DEFAULT '' COMMENT 'starter_pack_cid: CID of the app.bsky.graph.starterpack the account joined via';
+
+-- accounts that joined via a starter pack
+ALTER TABLE profiles
+    ADD INDEX IF NOT EXISTS idx_profiles_starter_pack starter_pack_uri TYPE bloom_filter GRANULARITY 4;
@@ -0,0 +1,39 @@
+-- analytics results written by cmd/analyze. A run replaces the score of every
+-- profile in its follow snapshot, profiles no longer in it keep their last one.
+CREATE TABLE IF NOT EXISTS profile_scores
+(
+    did         String                                         COMMENT 'did: account DID',
+    pagerank    Float64                                        COMMENT 'pagerank: influence score over the follow graph, summing to 1 across every profile',
+    community   String                                         COMMENT 'community: DID of the profile whose label the community converged on',
+    computed    DateTime64(9, 'UTC')                           COMMENT 'computed: start of the analytics run - ReplacingMergeTree version'
+)
+ENGINE = ReplacingMergeTree(computed)
+PRIMARY KEY(did)
+ORDER BY did;
+
+-- accounts sharing a community
+ALTER TABLE profile_scores
+    ADD INDEX IF NOT EXISTS idx_profile_scores_community community TYPE bloom_filter GRANULARITY 4;
+
+-- "people you may know": rank 1 has the most follows in common. A run inserts
+-- a complete set of rows under its own computed rather than replacing rows in
+-- place, which would leave a previous run's higher ranks and DIDs without any
+-- candidates behind. Read latest_follow_recommendations, not this table.
+-- Older runs can be removed with ALTER TABLE ... DELETE WHERE computed < ...
+CREATE TABLE IF NOT EXISTS follow_recommendations
+(
+    did         String                                         COMMENT 'did: account DID the recommendation is for',
+    rank        UInt16                                         COMMENT 'rank: 1-based position ordered by follows in common',
+    candidate   String                                         COMMENT 'candidate: DID of the recommended account',
+    common      UInt32                                         COMMENT 'common: accounts did follows that follow candidate',
+    computed    DateTime64(9, 'UTC')                           COMMENT 'computed: start of the analytics run the row belongs to'
+)
+ENGINE = ReplacingMergeTree
+PRIMARY KEY(did, computed, rank)
+ORDER BY (did, computed, rank);
+
+-- recommendations of the latest run only
+CREATE VIEW IF NOT EXISTS latest_follow_recommendations AS
+SELECT did, rank, candidate, common, computed
+FROM follow_recommendations
+WHERE computed = (SELECT max(computed) FROM follow_recommendations);
@@ -0,0 +1,33 @@
+-- app.bsky.graph.block: same layout as follows
+CREATE TABLE IF NOT EXISTS blocks
+(
+    did         String                                         COMMENT 'did: account DID of the blocker (repo owner)',
+    rkey        String                                         COMMENT 'rkey: record key of the block within the repo, unique per did',
+    subject     String                                         COMMENT 'subject: DID of the blocked account',
+    created     DateTime64(9, 'UTC')                           COMMENT 'created: app.bsky.graph.block created timestamp',
+    ingested    DateTime64(9, 'UTC') DEFAULT now64(9)          COMMENT 'ingested: timestamp for tracking ingestion lag time',
+    rev         String                                         COMMENT 'rev: (string, TID format): revision of the repo the block was read from',
+    rev_version UInt64 DEFAULT 0                               COMMENT 'rev_version: rev TID as an integer - ReplacingMergeTree version',
+    uri         String DEFAULT ''                              COMMENT 'uri: at://<did>/<collection>/<rkey>',
+    cid         String DEFAULT ''                              COMMENT 'cid: CID of the record block',
+    INDEX idx_blocks_subject subject TYPE bloom_filter GRANULARITY 4
+)
+ENGINE = ReplacingMergeTree(rev_version)
+PRIMARY KEY(did, rkey)
+ORDER BY (did, rkey);
+
+-- one row per repo whose records were all ingested at rev. A follow / block
+-- re-ingested from a newer rev replaces its row, so an edge whose rev_version
+-- is behind a later sync of its repo was removed by that sync's commit time:
+-- validity intervals are [created, first later sync) - see db.intervals
+CREATE TABLE IF NOT EXISTS repo_syncs
+(
+    did         String                                         COMMENT 'did: account DID of the repo',
+    rev         String                                         COMMENT 'rev: (string, TID format): revision every record was ingested from',
+    rev_version UInt64                                         COMMENT 'rev_version: rev TID as an integer',
+    synced      DateTime64(9, 'UTC')                           COMMENT 'synced: commit time encoded in the rev TID',
+    ingested    DateTime64(9, 'UTC') DEFAULT now64(9)          COMMENT 'ingested: timestamp for tracking ingestion lag time'
+)
+ENGINE = ReplacingMergeTree(ingested)
+PRIMARY KEY(did, rev_version)
+ORDER BY (did, rev_version);
@@ -0,0 +1,206 @@
+package clickhouse
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/mikeblum/atgraph.dev/bsky"
+	"github.com/mikeblum/atgraph.dev/graph"
+	"github.com/mikeblum/atgraph.dev/graph/clickhouse/internal/db"
+)
+
+// reader - graph.Reader over the typed database/sql read layer, shared by
+// Engine and IngestEngine since ch-go's native protocol has no row scanning
+type reader struct {
+	queries *db.Queries
+}
+
+func newReader(conn *sql.DB) reader {
+	return reader{queries: db.New(conn)}
+}
+
+func (r reader) GetProfile(ctx context.Context, did string) (*graph.Profile, error) {
+	p, err := r.queries.SelectProfile(ctx, did)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, graph.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	profile := toProfile(p)
+	return &profile, nil
+}
+
+func (r reader) Followers(ctx context.Context, did string, page graph.Page) (*graph.Results[graph.Follow], error) {
+	keys, err := page.Keys(2)
+	if err != nil {
+		return nil, err
+	}
+	follows, err := r.queries.SelectFollowers(ctx, did, keys[0], keys[1], page.Size()+1)
+	if err != nil {
+		return nil, err
+	}
+	return graph.NewResults(toFollows(follows), page, func(f graph.Follow) []string {
+		return []string{f.DID, f.RKey}
+	}), nil
+}
+
+func (r reader) Following(ctx context.Context, did string, page graph.Page) (*graph.Results[graph.Follow], error) {
+	keys, err := page.Keys(1)
+	if err != nil {
+		return nil, err
+	}
+	follows, err := r.queries.SelectFollowing(ctx, did, keys[0], page.Size()+1)
+	if err != nil {
+		return nil, err
+	}
+	return graph.NewResults(toFollows(follows), page, func(f graph.Follow) []string {
+		return []string{f.RKey}
+	}), nil
+}
+
+func (r reader) MutualFollows(ctx context.Context, did string, page graph.Page) (*graph.Results[graph.Follow], error) {
+	keys, err := page.Keys(2)
+	if err != nil {
+		return nil, err
+	}
+	follows, err := r.queries.SelectMutualFollows(ctx, did, keys[0], keys[1], page.Size()+1)
+	if err != nil {
+		return nil, err
+	}
+	return graph.NewResults(toFollows(follows), page, func(f graph.Follow) []string {
+		return []string{f.Subject, f.RKey}
+	}), nil
+}
+
+func (r reader) Blocks(ctx context.Context, did string, page graph.Page) (*graph.Results[graph.Block], error) {
+	keys, err := page.Keys(1)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := r.queries.SelectBlocks(ctx, did, keys[0], page.Size()+1)
+	if err != nil {
+		return nil, err
+	}
+	return graph.NewResults(toBlocks(blocks), page, func(b graph.Block) []string {
+		return []string{b.RKey}
+	}), nil
+}
+
+func (r reader) FollowersAsOf(ctx context.Context, did string, at time.Time, page graph.Page) (*graph.Results[graph.Follow], error) {
+	keys, err := page.Keys(2)
+	if err != nil {
+		return nil, err
+	}
+	follows, err := r.queries.SelectFollowersAsOf(ctx, did, at, keys[0], keys[1], page.Size()+1)
+	if err != nil {
+		return nil, err
+	}
+	return graph.NewResults(toFollows(follows), page, func(f graph.Follow) []string {
+		return []string{f.DID, f.RKey}
+	}), nil
+}
+
+func (r reader) SubgraphAsOf(ctx context.Context, dids []string, at time.Time) (*graph.Subgraph, error) {
+	follows, err := r.queries.SelectFollowsAmong(ctx, dids, at)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := r.queries.SelectBlocksAmong(ctx, dids, at)
+	if err != nil {
+		return nil, err
+	}
+	return &graph.Subgraph{
+		At:      at,
+		DIDs:    dids,
+		Follows: toFollows(follows),
+		Blocks:  toBlocks(blocks),
+	}, nil
+}
+
+// ScanFollows - stream every follow edge for an analytics snapshot
+func (r reader) ScanFollows(ctx context.Context, fn func(did, subject string) error) error {
+	return r.queries.ScanFollowEdges(ctx, fn)
+}
+
+// ShortestPaths - bidirectional BFS fallback, one query per frontier
+func (r reader) ShortestPaths(ctx context.Context, q graph.PathQuery) ([]graph.Path, error) {
+	if q.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, q.Timeout)
+		defer cancel()
+	}
+	return graph.ShortestPathsBFS(ctx, q, func(ctx context.Context, dids []string, reverse bool) (map[string][]string, error) {
+		return r.queries.SelectNeighbours(ctx, dids, reverse, q.Mutual)
+	})
+}
+
+func toProfile(p db.Profile) graph.Profile {
+	return graph.Profile{
+		DID:         p.Did,
+		Handle:      p.Handle,
+		DisplayName: p.DisplayName,
+		Description: p.Description,
+		Avatar: bsky.Blob{
+			CID:      p.AvatarCID,
+			MimeType: p.AvatarMime,
+			Size:     p.AvatarSize,
+		},
+		Banner: bsky.Blob{
+			CID:      p.BannerCID,
+			MimeType: p.BannerMime,
+			Size:     p.BannerSize,
+		},
+		Labels:               p.Labels,
+		PinnedPost:           bsky.StrongRef{URI: p.PinnedURI, CID: p.PinnedCID},
+		JoinedViaStarterPack: bsky.StrongRef{URI: p.PackURI, CID: p.PackCID},
+		Created:              p.Created,
+		Rev:                  p.Rev,
+		URI:                  p.URI,
+		CID:                  p.CID,
+	}
+}
+
+func toFollows(rows []db.Follow) []graph.Follow {
+	follows := make([]graph.Follow, 0, len(rows))
+	for _, f := range rows {
+		follows = append(follows, toFollow(f))
+	}
+	return follows
+}
+
+func toFollow(f db.Follow) graph.Follow {
+	return graph.Follow{
+		DID:     f.Did,
+		Subject: f.Subject,
+		RKey:    f.RKey,
+		Created: f.Created,
+		Removed: f.Removed,
+		Rev:     f.Rev,
+		URI:     f.URI,
+		CID:     f.CID,
+	}
+}
+
+func toBlocks(rows []db.Block) []graph.Block {
+	blocks := make([]graph.Block, 0, len(rows))
+	for _, b := range rows {
+		blocks = append(blocks, toBlock(b))
+	}
+	return blocks
+}
+
+func toBlock(b db.Block) graph.Block {
+	return graph.Block{
+		DID:     b.Did,
+		Subject: b.Subject,
+		RKey:    b.RKey,
+		Created: b.Created,
+		Removed: b.Removed,
+		Rev:     b.Rev,
+		URI:     b.URI,
+		CID:     b.CID,
+	}
+}
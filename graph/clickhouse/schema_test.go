@@ -0,0 +1,175 @@
+package clickhouse
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/mikeblum/atgraph.dev/graph/clickhouse/internal/db"
+	"github.com/mikeblum/atgraph.dev/graph/migrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	createTable = regexp.MustCompile(`(?is)^CREATE TABLE (?:IF NOT EXISTS )?([\w.]+)\s*\(`)
+	addColumn   = regexp.MustCompile(`(?is)^ALTER TABLE ([\w.]+)\s+ADD COLUMN (?:IF NOT EXISTS )?(.*)$`)
+	renameTable = regexp.MustCompile(`(?is)^RENAME TABLE (.*)$`)
+	renamePair  = regexp.MustCompile(`(?is)^([\w.]+)\s+TO\s+([\w.]+)$`)
+	dropTable   = regexp.MustCompile(`(?is)^DROP TABLE (?:IF EXISTS )?([\w.]+)$`)
+)
+
+// TestSchemaDrift - the insert columns and read models must match the
+// tables produced by applying every migration in order
+func TestSchemaDrift(t *testing.T) {
+	schema := schemaTest(t)
+
+	t.Run("insert columns", func(t *testing.T) {
+		inserts := map[string]proto.Input{
+			TABLE_PROFILES:        newProfileColumns().Input(),
+			TABLE_BLOCKS:          newEdgeColumns().Input(),
+			TABLE_FOLLOWS:         newEdgeColumns().Input(),
+			TABLE_REPO_SYNCS:      newSyncColumns().Input(),
+			TABLE_PROFILE_SCORES:  newScoreColumns().Input(),
+			TABLE_RECOMMENDATIONS: newRecommendationColumns().Input(),
+		}
+		for table, input := range inserts {
+			columns, ok := schema[table]
+			require.True(t, ok, "table %s missing from migrations", table)
+			for _, col := range input {
+				schemaType, ok := columns[col.Name]
+				if assert.True(t, ok, "%s.%s inserted but missing from migrations", table, col.Name) {
+					assert.Equal(t, normalizeTypeTest(schemaType), normalizeTypeTest(string(col.Data.Type())), "%s.%s", table, col.Name)
+				}
+			}
+		}
+	})
+
+	t.Run("read models", func(t *testing.T) {
+		reads := map[string][]string{
+			TABLE_PROFILES: db.ProfileColumns,
+			TABLE_BLOCKS:   db.BlockColumns,
+			TABLE_FOLLOWS:  db.FollowColumns,
+		}
+		for table, read := range reads {
+			columns, ok := schema[table]
+			require.True(t, ok, "table %s missing from migrations", table)
+			var names []string
+			for name := range columns {
+				names = append(names, name)
+			}
+			assert.ElementsMatch(t, names, read, "db read model for %s has drifted from the migrations", table)
+		}
+	})
+}
+
+// schemaTest - table -> column -> type after applying the embedded migrations
+func schemaTest(t *testing.T) map[string]map[string]string {
+	loaded, err := migrate.Load(migrations, "migrations")
+	require.Nil(t, err)
+	schema := make(map[string]map[string]string)
+	for _, migration := range loaded {
+		for _, stmt := range migration.Statements {
+			if match := createTable.FindStringSubmatch(stmt); match != nil {
+				table := match[1]
+				body, ok := enclosedTest(stmt[len(match[0])-1:])
+				require.True(t, ok, "unbalanced CREATE TABLE %s", table)
+				schema[table] = make(map[string]string)
+				for _, def := range splitTopLevelTest(body) {
+					name, colType := columnDefTest(def)
+					if slices.Contains([]string{"INDEX", "PROJECTION", "CONSTRAINT"}, strings.ToUpper(name)) {
+						continue
+					}
+					schema[table][name] = colType
+				}
+			} else if match := addColumn.FindStringSubmatch(stmt); match != nil {
+				name, colType := columnDefTest(match[2])
+				require.Contains(t, schema, match[1])
+				schema[match[1]][name] = colType
+			} else if match := renameTable.FindStringSubmatch(stmt); match != nil {
+				// renames apply left to right ie. a TO a_v1, a_next TO a
+				for _, pair := range splitTopLevelTest(match[1]) {
+					names := renamePair.FindStringSubmatch(pair)
+					require.NotNil(t, names, "invalid RENAME %s", pair)
+					schema[names[2]] = schema[names[1]]
+					delete(schema, names[1])
+				}
+			} else if match := dropTable.FindStringSubmatch(stmt); match != nil {
+				delete(schema, match[1])
+			}
+		}
+	}
+	return schema
+}
+
+// enclosedTest - contents of the parenthesized group s starts with
+func enclosedTest(s string) (string, bool) {
+	depth := 0
+	quoted := false
+	for i, r := range s {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case quoted:
+		case r == '(':
+			depth++
+		case r == ')':
+			if depth--; depth == 0 {
+				return s[1:i], true
+			}
+		}
+	}
+	return "", false
+}
+
+// splitTopLevelTest - split on commas outside of quotes and parentheses
+func splitTopLevelTest(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	quoted := false
+	for i, r := range s {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case quoted:
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(s[start:]); rest != "" {
+		parts = append(parts, rest)
+	}
+	return parts
+}
+
+// columnDefTest - name and type of `name Type [DEFAULT ...] [COMMENT ...]`
+func columnDefTest(def string) (string, string) {
+	def = strings.TrimSpace(def)
+	name, rest, _ := strings.Cut(def, " ")
+	rest = strings.TrimSpace(rest)
+	depth := 0
+	for i, r := range rest {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case (r == ' ' || r == '\n' || r == '\t') && depth == 0:
+			return name, rest[:i]
+		}
+	}
+	return name, rest
+}
+
+// normalizeTypeTest - ch-go column types omit the DateTime64 timezone
+func normalizeTypeTest(t string) string {
+	t = strings.ReplaceAll(t, " ", "")
+	return strings.ReplaceAll(t, ",'UTC'", "")
+}
@@ -0,0 +1,383 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mikeblum/atgraph.dev/bsky"
+	"github.com/mikeblum/atgraph.dev/conf"
+	"github.com/mikeblum/atgraph.dev/graph/migrate"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	OP_INGEST             = "ingest"
+	OP_LOAD_SCHEMA        = "load_schema"
+	OP_CREATE_INDEXES     = "create_indexes"
+	OP_CREATE_CONSTRAINTS = "create_constraints"
+	OP_CLOSE              = "close"
+	OP_PING               = "ping"
+	OP_WRITE_SCORES       = "write_scores"
+	OP_WRITE_RECS         = "write_recommendations"
+	OP_REPO_SYNCED        = "repo_synced"
+)
+
+// Backend - a named engine and the policy applied to its errors
+type Backend struct {
+	Name   string
+	Engine Engine
+	Policy string
+}
+
+// CompositeEngine - fans every call out to each backend concurrently.
+// Every backend always receives the call; a backend's error policy only
+// decides whether its error is surfaced to the caller or logged and dropped.
+type CompositeEngine struct {
+	backends []Backend
+	log      *conf.Log
+	metrics  *CompositeMetrics
+}
+
+func NewCompositeEngine(ctx context.Context, backends ...Backend) (*CompositeEngine, error) {
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("graph: composite engine requires at least one backend")
+	}
+	metrics, err := NewCompositeMetrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range backends {
+		if backends[i].Policy == "" {
+			backends[i].Policy = ATGRAPH_ERROR_POLICY
+		}
+	}
+	return &CompositeEngine{
+		backends: backends,
+		log:      conf.NewLog(),
+		metrics:  metrics,
+	}, nil
+}
+
+// Open - construct every engine selected by ATGRAPH_ENGINES from the registry.
+// A backend with the ignore policy that fails to open is skipped.
+func Open(ctx context.Context, cfg *Conf) (*CompositeEngine, error) {
+	log := conf.NewLog()
+	policies := cfg.errorPolicies()
+	var backends []Backend
+	closeAll := func() {
+		for _, backend := range backends {
+			_ = backend.Engine.Close(ctx)
+		}
+	}
+	for _, name := range cfg.engines() {
+		policy, ok := policies[name]
+		if !ok {
+			policy = ATGRAPH_ERROR_POLICY
+		}
+		factory, err := lookup(name)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		var engine Engine
+		if engine, err = factory(ctx); err != nil {
+			if engine != nil {
+				_ = engine.Close(ctx)
+			}
+			if policy == ERROR_POLICY_IGNORE {
+				log.WithErrorMsg(err, "Error opening engine - skipping", "engine", name, "policy", policy)
+				continue
+			}
+			closeAll()
+			return nil, fmt.Errorf("open %s: %w", name, err)
+		}
+		log.With("engine", name, "policy", policy).Info("Engine ready")
+		backends = append(backends, Backend{Name: name, Engine: engine, Policy: policy})
+	}
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("graph: no engines available from %v", cfg.engines())
+	}
+	return NewCompositeEngine(ctx, backends...)
+}
+
+// Backends - names of the engines receiving writes
+func (c *CompositeEngine) Backends() []string {
+	names := make([]string, 0, len(c.backends))
+	for _, backend := range c.backends {
+		names = append(names, backend.Name)
+	}
+	return names
+}
+
+// Migrators - versioned migrations of every backend implementing Migratable
+func (c *CompositeEngine) Migrators() []*migrate.Migrator {
+	var migrators []*migrate.Migrator
+	for _, backend := range c.backends {
+		if m, ok := backend.Engine.(Migratable); ok {
+			migrators = append(migrators, m.Migrator())
+		}
+	}
+	return migrators
+}
+
+func (c *CompositeEngine) Ingest(ctx context.Context, workerID int, item bsky.RepoItem) error {
+	return c.fanOut(ctx, OP_INGEST, func(backend Backend) error {
+		start := time.Now()
+		err := backend.Engine.Ingest(ctx, workerID, item)
+		c.metrics.latency.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(
+			attribute.String("engine", backend.Name),
+		))
+		return err
+	})
+}
+
+func (c *CompositeEngine) LoadSchema(ctx context.Context) error {
+	return c.fanOut(ctx, OP_LOAD_SCHEMA, func(backend Backend) error {
+		return backend.Engine.LoadSchema(ctx)
+	})
+}
+
+func (c *CompositeEngine) CreateIndexes(ctx context.Context) error {
+	return c.fanOut(ctx, OP_CREATE_INDEXES, func(backend Backend) error {
+		return backend.Engine.CreateIndexes(ctx)
+	})
+}
+
+func (c *CompositeEngine) CreateConstraints(ctx context.Context) error {
+	return c.fanOut(ctx, OP_CREATE_CONSTRAINTS, func(backend Backend) error {
+		return backend.Engine.CreateConstraints(ctx)
+	})
+}
+
+// Close - close every backend regardless of policy so pending batches are flushed
+func (c *CompositeEngine) Close(ctx context.Context) error {
+	return c.fanOut(ctx, OP_CLOSE, func(backend Backend) error {
+		return backend.Engine.Close(ctx)
+	})
+}
+
+// Ping - ping every backend implementing Pinger; errors follow each backend's policy
+func (c *CompositeEngine) Ping(ctx context.Context) error {
+	return c.fanOut(ctx, OP_PING, func(backend Backend) error {
+		if p, ok := backend.Engine.(Pinger); ok {
+			return p.Ping(ctx)
+		}
+		return nil
+	})
+}
+
+// ScanFollows - snapshots are read from the first backend implementing FollowScanner
+func (c *CompositeEngine) ScanFollows(ctx context.Context, fn func(did, subject string) error) error {
+	for _, backend := range c.backends {
+		if s, ok := backend.Engine.(FollowScanner); ok {
+			return s.ScanFollows(ctx, fn)
+		}
+	}
+	return fmt.Errorf("%w: %v", ErrNoScanner, c.Backends())
+}
+
+// ShortestPaths - served by the first backend implementing PathFinder
+func (c *CompositeEngine) ShortestPaths(ctx context.Context, q PathQuery) ([]Path, error) {
+	for _, backend := range c.backends {
+		if p, ok := backend.Engine.(PathFinder); ok {
+			return p.ShortestPaths(ctx, q)
+		}
+	}
+	return nil, fmt.Errorf("%w: %v", ErrNoPathFinder, c.Backends())
+}
+
+// RepoSynced - fan out to every backend implementing RepoSyncer
+func (c *CompositeEngine) RepoSynced(ctx context.Context, did, rev string) error {
+	return c.fanOut(ctx, OP_REPO_SYNCED, func(backend Backend) error {
+		if s, ok := backend.Engine.(RepoSyncer); ok {
+			return s.RepoSynced(ctx, did, rev)
+		}
+		return nil
+	})
+}
+
+// FollowersAsOf - served by the first backend implementing History
+func (c *CompositeEngine) FollowersAsOf(ctx context.Context, did string, at time.Time, page Page) (*Results[Follow], error) {
+	h, err := c.history()
+	if err != nil {
+		return nil, err
+	}
+	return h.FollowersAsOf(ctx, did, at, page)
+}
+
+// SubgraphAsOf - served by the first backend implementing History
+func (c *CompositeEngine) SubgraphAsOf(ctx context.Context, dids []string, at time.Time) (*Subgraph, error) {
+	h, err := c.history()
+	if err != nil {
+		return nil, err
+	}
+	return h.SubgraphAsOf(ctx, dids, at)
+}
+
+func (c *CompositeEngine) history() (History, error) {
+	for _, backend := range c.backends {
+		if h, ok := backend.Engine.(History); ok {
+			return h, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %v", ErrNoHistory, c.Backends())
+}
+
+// ExportProfiles - served by the first backend implementing Exporter
+func (c *CompositeEngine) ExportProfiles(ctx context.Context, filter ExportFilter, fn func(Profile) error) error {
+	e, err := c.exporter()
+	if err != nil {
+		return err
+	}
+	return e.ExportProfiles(ctx, filter, fn)
+}
+
+// ExportFollows - served by the first backend implementing Exporter
+func (c *CompositeEngine) ExportFollows(ctx context.Context, filter ExportFilter, fn func(Follow) error) error {
+	e, err := c.exporter()
+	if err != nil {
+		return err
+	}
+	return e.ExportFollows(ctx, filter, fn)
+}
+
+// ExportBlocks - served by the first backend implementing Exporter
+func (c *CompositeEngine) ExportBlocks(ctx context.Context, filter ExportFilter, fn func(Block) error) error {
+	e, err := c.exporter()
+	if err != nil {
+		return err
+	}
+	return e.ExportBlocks(ctx, filter, fn)
+}
+
+func (c *CompositeEngine) exporter() (Exporter, error) {
+	for _, backend := range c.backends {
+		if e, ok := backend.Engine.(Exporter); ok {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %v", ErrNoExporter, c.Backends())
+}
+
+// WriteScores - fan out to every backend implementing ScoreWriter
+func (c *CompositeEngine) WriteScores(ctx context.Context, scores []Score) error {
+	return c.fanOut(ctx, OP_WRITE_SCORES, func(backend Backend) error {
+		if w, ok := backend.Engine.(ScoreWriter); ok {
+			return w.WriteScores(ctx, scores)
+		}
+		return nil
+	})
+}
+
+// WriteRecommendations - fan out to every backend implementing ScoreWriter
+func (c *CompositeEngine) WriteRecommendations(ctx context.Context, recommendations []Recommendations) error {
+	return c.fanOut(ctx, OP_WRITE_RECS, func(backend Backend) error {
+		if w, ok := backend.Engine.(ScoreWriter); ok {
+			return w.WriteRecommendations(ctx, recommendations)
+		}
+		return nil
+	})
+}
+
+// reader - reads aren't fanned out: the first backend implementing Reader serves them
+func (c *CompositeEngine) reader() (Reader, error) {
+	for _, backend := range c.backends {
+		if r, ok := backend.Engine.(Reader); ok {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %v", ErrNoReader, c.Backends())
+}
+
+func (c *CompositeEngine) GetProfile(ctx context.Context, did string) (*Profile, error) {
+	r, err := c.reader()
+	if err != nil {
+		return nil, err
+	}
+	return r.GetProfile(ctx, did)
+}
+
+func (c *CompositeEngine) Followers(ctx context.Context, did string, page Page) (*Results[Follow], error) {
+	r, err := c.reader()
+	if err != nil {
+		return nil, err
+	}
+	return r.Followers(ctx, did, page)
+}
+
+func (c *CompositeEngine) Following(ctx context.Context, did string, page Page) (*Results[Follow], error) {
+	r, err := c.reader()
+	if err != nil {
+		return nil, err
+	}
+	return r.Following(ctx, did, page)
+}
+
+func (c *CompositeEngine) MutualFollows(ctx context.Context, did string, page Page) (*Results[Follow], error) {
+	r, err := c.reader()
+	if err != nil {
+		return nil, err
+	}
+	return r.MutualFollows(ctx, did, page)
+}
+
+func (c *CompositeEngine) Blocks(ctx context.Context, did string, page Page) (*Results[Block], error) {
+	r, err := c.reader()
+	if err != nil {
+		return nil, err
+	}
+	return r.Blocks(ctx, did, page)
+}
+
+// fanOut - run fn against every backend and join the errors of backends
+// with the fail policy; errors from ignore backends are logged and counted
+func (c *CompositeEngine) fanOut(ctx context.Context, op string, fn func(backend Backend) error) error {
+	if len(c.backends) == 1 {
+		return c.handle(ctx, op, c.backends[0], fn(c.backends[0]))
+	}
+	errs := make([]error, len(c.backends))
+	var wg sync.WaitGroup
+	for i, backend := range c.backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = c.handle(ctx, op, backend, fn(backend))
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (c *CompositeEngine) handle(ctx context.Context, op string, backend Backend, err error) error {
+	if err == nil {
+		return nil
+	}
+	c.metrics.errors.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("engine", backend.Name),
+		attribute.String("op", op),
+		attribute.String("policy", backend.Policy),
+	))
+	if backend.Policy == ERROR_POLICY_IGNORE {
+		c.log.WithErrorMsg(err, "Ignoring engine error", "engine", backend.Name, "op", op)
+		return nil
+	}
+	return fmt.Errorf("%s %s: %w", backend.Name, op, err)
+}
+
+// validate Engine, Exporter, FollowScanner, History, PathFinder, Pinger, Reader,
+// RepoSyncer and ScoreWriter interfaces are implemented
+var (
+	_ Engine        = &CompositeEngine{}
+	_ Exporter      = &CompositeEngine{}
+	_ FollowScanner = &CompositeEngine{}
+	_ History       = &CompositeEngine{}
+	_ PathFinder    = &CompositeEngine{}
+	_ Pinger        = &CompositeEngine{}
+	_ Reader        = &CompositeEngine{}
+	_ RepoSyncer    = &CompositeEngine{}
+	_ ScoreWriter   = &CompositeEngine{}
+)
@@ -0,0 +1,50 @@
+package graph
+
+import (
+	"context"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type CompositeMetrics struct {
+	errors  metric.Int64Counter
+	latency metric.Float64Histogram
+}
+
+func NewCompositeMetrics(ctx context.Context) (*CompositeMetrics, error) {
+	buildInfo, ok := debug.ReadBuildInfo()
+	version := "unknown"
+	if ok {
+		version = buildInfo.Main.Version
+	}
+
+	meter := otel.GetMeterProvider().Meter(
+		"graph.engine",
+		metric.WithInstrumentationVersion(version),
+	)
+
+	errors, err := meter.Int64Counter(
+		"graph.engine.errors",
+		metric.WithDescription("Engine errors by engine, operation and error policy"),
+		metric.WithUnit("{errors}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	latency, err := meter.Float64Histogram(
+		"graph.engine.ingest.latency",
+		metric.WithDescription("Per engine ingest latency"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CompositeMetrics{
+		errors:  errors,
+		latency: latency,
+	}, nil
+}
@@ -0,0 +1,196 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mikeblum/atgraph.dev/bsky"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEngine struct {
+	err    error
+	ingest atomic.Int64
+	closed atomic.Bool
+}
+
+func (f *fakeEngine) Ingest(ctx context.Context, workerID int, item bsky.RepoItem) error {
+	f.ingest.Add(1)
+	return f.err
+}
+
+func (f *fakeEngine) LoadSchema(ctx context.Context) error        { return f.err }
+func (f *fakeEngine) CreateIndexes(ctx context.Context) error     { return f.err }
+func (f *fakeEngine) CreateConstraints(ctx context.Context) error { return f.err }
+
+func (f *fakeEngine) Close(ctx context.Context) error {
+	f.closed.Store(true)
+	return nil
+}
+
+// fakeReader - fakeEngine serving a single profile
+type fakeReader struct {
+	fakeEngine
+	profile *Profile
+}
+
+func (f *fakeReader) GetProfile(ctx context.Context, did string) (*Profile, error) {
+	if f.profile == nil || f.profile.DID != did {
+		return nil, ErrNotFound
+	}
+	return f.profile, nil
+}
+
+func (f *fakeReader) Followers(ctx context.Context, did string, page Page) (*Results[Follow], error) {
+	return NewResults[Follow](nil, page, nil), nil
+}
+
+func (f *fakeReader) Following(ctx context.Context, did string, page Page) (*Results[Follow], error) {
+	return NewResults[Follow](nil, page, nil), nil
+}
+
+func (f *fakeReader) MutualFollows(ctx context.Context, did string, page Page) (*Results[Follow], error) {
+	return NewResults[Follow](nil, page, nil), nil
+}
+
+func (f *fakeReader) Blocks(ctx context.Context, did string, page Page) (*Results[Block], error) {
+	return nil, NotIngested(bsky.ITEM_GRAPH_BLOCK)
+}
+
+func TestCompositeEngine(t *testing.T) {
+	t.Run("fan out to every backend", compositeFanOutTest)
+	t.Run("fail policy surfaces errors", compositeFailPolicyTest)
+	t.Run("ignore policy drops errors", compositeIgnorePolicyTest)
+	t.Run("open from registry", compositeOpenTest)
+	t.Run("open unknown engine", compositeOpenUnknownTest)
+	t.Run("reads go to the first reader", compositeReaderTest)
+}
+
+func TestConf(t *testing.T) {
+	t.Run("engines", confEnginesTest)
+	t.Run("error policies", confErrorPoliciesTest)
+}
+
+func compositeFanOutTest(t *testing.T) {
+	ctx := context.Background()
+	a, b := &fakeEngine{}, &fakeEngine{}
+	engine, err := NewCompositeEngine(ctx, Backend{Name: "a", Engine: a}, Backend{Name: "b", Engine: b})
+	require.Nil(t, err)
+	for range 3 {
+		require.Nil(t, engine.Ingest(ctx, 0, bsky.RepoItem{}))
+	}
+	assert.Equal(t, int64(3), a.ingest.Load())
+	assert.Equal(t, int64(3), b.ingest.Load())
+	require.Nil(t, engine.Close(ctx))
+	assert.True(t, a.closed.Load())
+	assert.True(t, b.closed.Load())
+}
+
+func compositeFailPolicyTest(t *testing.T) {
+	ctx := context.Background()
+	failing := &fakeEngine{err: errors.New("unavailable")}
+	healthy := &fakeEngine{}
+	engine, err := NewCompositeEngine(ctx,
+		Backend{Name: "failing", Engine: failing, Policy: ERROR_POLICY_FAIL},
+		Backend{Name: "healthy", Engine: healthy},
+	)
+	require.Nil(t, err)
+	err = engine.Ingest(ctx, 0, bsky.RepoItem{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failing ingest")
+	// the healthy backend still receives the write
+	assert.Equal(t, int64(1), healthy.ingest.Load())
+	assert.Error(t, engine.CreateIndexes(ctx))
+}
+
+func compositeIgnorePolicyTest(t *testing.T) {
+	ctx := context.Background()
+	failing := &fakeEngine{err: errors.New("unavailable")}
+	healthy := &fakeEngine{}
+	engine, err := NewCompositeEngine(ctx,
+		Backend{Name: "failing", Engine: failing, Policy: ERROR_POLICY_IGNORE},
+		Backend{Name: "healthy", Engine: healthy},
+	)
+	require.Nil(t, err)
+	assert.Nil(t, engine.Ingest(ctx, 0, bsky.RepoItem{}))
+	assert.Nil(t, engine.LoadSchema(ctx))
+	assert.Equal(t, int64(1), failing.ingest.Load())
+	assert.Equal(t, int64(1), healthy.ingest.Load())
+}
+
+func compositeOpenTest(t *testing.T) {
+	ctx := context.Background()
+	opened := &fakeEngine{}
+	Register("test-open", func(ctx context.Context) (Engine, error) {
+		return opened, nil
+	})
+	Register("test-broken", func(ctx context.Context) (Engine, error) {
+		return nil, errors.New("connection refused")
+	})
+	assert.Contains(t, Engines(), "test-open")
+
+	t.Setenv(ENV_ATGRAPH_ENGINES, "test-open, test-broken")
+	t.Setenv(ENV_ATGRAPH_ENGINE_ERROR_POLICY, "test-broken=ignore")
+	engine, err := Open(ctx, confTest(t))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"test-open"}, engine.Backends())
+
+	// broken backend with the default fail policy aborts and closes opened engines
+	t.Setenv(ENV_ATGRAPH_ENGINE_ERROR_POLICY, "")
+	_, err = Open(ctx, confTest(t))
+	assert.Error(t, err)
+	assert.True(t, opened.closed.Load())
+}
+
+func compositeOpenUnknownTest(t *testing.T) {
+	t.Setenv(ENV_ATGRAPH_ENGINES, "cassandra")
+	_, err := Open(context.Background(), confTest(t))
+	assert.Error(t, err)
+}
+
+func compositeReaderTest(t *testing.T) {
+	ctx := context.Background()
+	writeOnly := &fakeEngine{}
+	reader := &fakeReader{profile: &Profile{DID: "did:plc:test"}}
+	engine, err := NewCompositeEngine(ctx, Backend{Name: "write-only", Engine: writeOnly}, Backend{Name: "reader", Engine: reader})
+	require.Nil(t, err)
+	profile, err := engine.GetProfile(ctx, "did:plc:test")
+	require.Nil(t, err)
+	assert.Equal(t, "did:plc:test", profile.DID)
+	_, err = engine.GetProfile(ctx, "did:plc:missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = engine.Blocks(ctx, "did:plc:test", Page{})
+	assert.ErrorIs(t, err, ErrNotIngested)
+
+	engine, err = NewCompositeEngine(ctx, Backend{Name: "write-only", Engine: writeOnly})
+	require.Nil(t, err)
+	_, err = engine.Followers(ctx, "did:plc:test", Page{})
+	assert.ErrorIs(t, err, ErrNoReader)
+}
+
+func confEnginesTest(t *testing.T) {
+	t.Setenv(ENV_ATGRAPH_ENGINES, "")
+	assert.Equal(t, []string{ATGRAPH_ENGINES}, confTest(t).engines())
+	t.Setenv(ENV_ATGRAPH_ENGINES, " ClickHouse,neo4j,,clickhouse ")
+	assert.Equal(t, []string{"clickhouse", "neo4j"}, confTest(t).engines())
+}
+
+func confErrorPoliciesTest(t *testing.T) {
+	t.Setenv(ENV_ATGRAPH_ENGINE_ERROR_POLICY, "clickhouse=fail, neo4j=IGNORE")
+	assert.Equal(t, map[string]string{"clickhouse": ERROR_POLICY_FAIL, "neo4j": ERROR_POLICY_IGNORE}, confTest(t).errorPolicies())
+	t.Setenv(ENV_ATGRAPH_ENGINE_ERROR_POLICY, "neo4j=retry")
+	_, err := NewConf()
+	assert.Error(t, err)
+	t.Setenv(ENV_ATGRAPH_ENGINE_ERROR_POLICY, "neo4j")
+	_, err = NewConf()
+	assert.Error(t, err)
+}
+
+func confTest(t *testing.T) *Conf {
+	cfg, err := NewConf()
+	require.Nil(t, err)
+	return cfg
+}
@@ -0,0 +1,130 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mikeblum/atgraph.dev/conf"
+)
+
+type Conf struct {
+	conf.EnvConf
+	cfg      *Config
+	policies map[string]string
+}
+
+// NewConf - settings decoded once, invalid ones are left at their defaults
+// and reported in the returned error alongside the still usable Conf
+func NewConf() (*Conf, error) {
+	c := &Conf{EnvConf: conf.NewEnvConf()}
+	var err error
+	c.cfg, err = c.decode()
+	var policyErr error
+	c.policies, policyErr = c.cfg.policies()
+	return c, errors.Join(err, policyErr)
+}
+
+// Config - typed graph settings, ErrorPolicy entries are engine=policy pairs
+type Config struct {
+	Engines      []string      `env:"ATGRAPH_ENGINES"`
+	ErrorPolicy  []string      `env:"ATGRAPH_ENGINE_ERROR_POLICY"`
+	PathMaxDepth int           `env:"ATGRAPH_PATH_MAX_DEPTH" min:"1"`
+	PathLimit    int           `env:"ATGRAPH_PATH_LIMIT" min:"1"`
+	PathTimeout  time.Duration `env:"ATGRAPH_PATH_TIMEOUT" min:"1ns"`
+}
+
+// decode - defaults overridden by whatever conf.Decode can parse
+func (c *Conf) decode() (*Config, error) {
+	cfg := &Config{
+		Engines:      []string{ATGRAPH_ENGINES},
+		PathMaxDepth: PATH_MAX_DEPTH,
+		PathLimit:    PATH_LIMIT,
+		PathTimeout:  PATH_TIMEOUT,
+	}
+	return cfg, conf.Decode(c.EnvConf, cfg)
+}
+
+// Config - the settings decoded by NewConf
+func (c *Conf) Config() *Config {
+	return c.cfg
+}
+
+// engines - de-duplicated, lower cased engine names ie. clickhouse,neo4j
+func (c *Conf) engines() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range c.cfg.Engines {
+		name = strings.ToLower(name)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return []string{ATGRAPH_ENGINES}
+	}
+	return names
+}
+
+// errorPolicies - per engine overrides validated by NewConf
+func (c *Conf) errorPolicies() map[string]string {
+	return c.policies
+}
+
+// policies - per engine overrides ie. clickhouse=fail,neo4j=ignore
+func (cfg *Config) policies() (map[string]string, error) {
+	policies := make(map[string]string)
+	for _, pair := range cfg.ErrorPolicy {
+		name, policy, ok := strings.Cut(pair, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		policy = strings.ToLower(strings.TrimSpace(policy))
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid %s entry %q (expected engine=policy)", ENV_ATGRAPH_ENGINE_ERROR_POLICY, pair)
+		}
+		switch policy {
+		case ERROR_POLICY_FAIL, ERROR_POLICY_IGNORE:
+			policies[name] = policy
+		default:
+			return nil, fmt.Errorf("unsupported error policy for %s: %q (expected %s or %s)", name, policy, ERROR_POLICY_FAIL, ERROR_POLICY_IGNORE)
+		}
+	}
+	return policies, nil
+}
+
+// PathMaxDepth - upper bound on the hops a shortest path query may search
+func (c *Conf) PathMaxDepth() int {
+	return c.cfg.PathMaxDepth
+}
+
+// PathLimit - upper bound on the equally short paths returned
+func (c *Conf) PathLimit() int {
+	return c.cfg.PathLimit
+}
+
+// PathTimeout - bound on a single shortest path search
+func (c *Conf) PathTimeout() time.Duration {
+	return c.cfg.PathTimeout
+}
+
+// PathQuery - from -> to search bounded by the configured limits:
+// maxDepth and limit may narrow the defaults but never exceed them
+func (c *Conf) PathQuery(from, to string, mutual bool, maxDepth, limit int) PathQuery {
+	q := PathQuery{
+		From:     from,
+		To:       to,
+		MaxDepth: c.PathMaxDepth(),
+		Mutual:   mutual,
+		Limit:    c.PathLimit(),
+		Timeout:  c.PathTimeout(),
+	}
+	if maxDepth > 0 {
+		q.MaxDepth = min(maxDepth, q.MaxDepth)
+	}
+	if limit > 0 {
+		q.Limit = min(limit, q.Limit)
+	}
+	return q
+}
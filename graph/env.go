@@ -0,0 +1,26 @@
+package graph
+
+import "time"
+
+const (
+	ENV_ATGRAPH_ENGINES             = "ATGRAPH_ENGINES"
+	ENV_ATGRAPH_ENGINE_ERROR_POLICY = "ATGRAPH_ENGINE_ERROR_POLICY"
+	ENV_ATGRAPH_PATH_MAX_DEPTH      = "ATGRAPH_PATH_MAX_DEPTH"
+	ENV_ATGRAPH_PATH_LIMIT          = "ATGRAPH_PATH_LIMIT"
+	ENV_ATGRAPH_PATH_TIMEOUT        = "ATGRAPH_PATH_TIMEOUT"
+
+	// engine error policies
+	// fail - surface the error: abort startup or mark the item as failed
+	ERROR_POLICY_FAIL = "fail"
+	// ignore - log and count the error without affecting other engines
+	ERROR_POLICY_IGNORE = "ignore"
+
+	// defaults
+	ATGRAPH_ENGINES      = "clickhouse"
+	ATGRAPH_ERROR_POLICY = ERROR_POLICY_FAIL
+
+	// shortest path defaults - every extra hop multiplies the frontier by the average follow count
+	PATH_MAX_DEPTH = 6
+	PATH_LIMIT     = 10
+	PATH_TIMEOUT   = 10 * time.Second
+)
@@ -0,0 +1,33 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNoExporter = errors.New("graph: no engine supports exports")
+
+// Exporter - engines streaming the whole graph, or the part of it matching
+// an ExportFilter, one row at a time so exports never hold it in memory
+type Exporter interface {
+	// ExportProfiles - every account matching filter.DIDs: its stored profile,
+	// or only the DID for accounts known solely as the end of an edge
+	ExportProfiles(ctx context.Context, filter ExportFilter, fn func(Profile) error) error
+	// ExportFollows - follows matching filter, removed ones included unless filter.Current
+	ExportFollows(ctx context.Context, filter ExportFilter, fn func(Follow) error) error
+	// ExportBlocks - blocks matching filter, removed ones included unless filter.Current
+	ExportBlocks(ctx context.Context, filter ExportFilter, fn func(Block) error) error
+}
+
+// ExportFilter - the part of the graph an export covers. The zero value
+// exports every account and every edge ever ingested.
+type ExportFilter struct {
+	// DIDs - only these accounts and the edges between them, every account if empty
+	DIDs []string
+	// Since, Until - only edges created in [Since, Until), unbounded if zero
+	Since time.Time
+	Until time.Time
+	// Current - only edges still present in their repo
+	Current bool
+}
@@ -0,0 +1,61 @@
+package export
+
+import (
+	"github.com/mikeblum/atgraph.dev/conf"
+)
+
+type Conf struct {
+	conf.EnvConf
+	cfg *Config
+}
+
+// NewConf - settings decoded once, invalid ones are left at their defaults
+// and reported in the returned error alongside the still usable Conf
+func NewConf() (*Conf, error) {
+	c := &Conf{EnvConf: conf.NewEnvConf()}
+	var err error
+	c.cfg, err = c.decode()
+	return c, err
+}
+
+// Config - typed export settings, Format must be one of Formats()
+type Config struct {
+	Format   string `env:"ATGRAPH_EXPORT_FORMAT" oneof:"parquet graphml gexf csv"`
+	Dir      string `env:"ATGRAPH_EXPORT_DIR"`
+	RowGroup int    `env:"ATGRAPH_EXPORT_ROW_GROUP" min:"1"`
+}
+
+// decode - defaults overridden by whatever conf.Decode can parse
+func (c *Conf) decode() (*Config, error) {
+	cfg := &Config{
+		Format:   DEFAULT_FORMAT,
+		Dir:      DEFAULT_DIR,
+		RowGroup: DEFAULT_ROW_GROUP,
+	}
+	return cfg, conf.Decode(c.EnvConf, cfg)
+}
+
+// Config - the settings decoded by NewConf
+func (c *Conf) Config() *Config {
+	return c.cfg
+}
+
+// Format - one of Formats(), DEFAULT_FORMAT if unset
+func (c *Conf) Format() string {
+	return c.cfg.Format
+}
+
+// Dir - directory the export files are written to
+func (c *Conf) Dir() string {
+	return c.cfg.Dir
+}
+
+// RowGroup - rows buffered per Parquet row group
+func (c *Conf) RowGroup() int {
+	return c.cfg.RowGroup
+}
+
+// Formats - supported export formats
+func Formats() []string {
+	return []string{FORMAT_PARQUET, FORMAT_GRAPHML, FORMAT_GEXF, FORMAT_CSV}
+}
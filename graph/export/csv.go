@@ -0,0 +1,149 @@
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mikeblum/atgraph.dev/graph"
+)
+
+// neo4j-admin import header: node IDs share the Profile ID space, arrays use
+// the default ; delimiter and times are epoch milliseconds as the neo4j
+// engine stores them. Import with:
+//
+//	neo4j-admin database import full --nodes=profiles.csv \
+//		--relationships=follows.csv --relationships=blocks.csv <database>
+var (
+	csvProfileHeader = []string{
+		"id:ID(Profile)",
+		"handle",
+		"display_name",
+		"description",
+		"avatar_cid",
+		"avatar_mime_type",
+		"avatar_size:long",
+		"banner_cid",
+		"banner_mime_type",
+		"banner_size:long",
+		"labels:string[]",
+		"pinned_post_uri",
+		"pinned_post_cid",
+		"starter_pack_uri",
+		"starter_pack_cid",
+		"created:long",
+		"rev",
+		"uri",
+		"cid",
+		":LABEL",
+	}
+	csvEdgeHeader = []string{
+		":START_ID(Profile)",
+		":END_ID(Profile)",
+		"rkey",
+		"created:long",
+		"removed:long",
+		"rev",
+		"uri",
+		"cid",
+		":TYPE",
+	}
+)
+
+const (
+	CSV_LABEL_PROFILE = "Profile"
+	CSV_TYPE_FOLLOWS  = "FOLLOWS"
+	CSV_TYPE_BLOCKS   = "BLOCKS"
+)
+
+// csvSink - neo4j-admin import files. Empty fields leave the property unset.
+type csvSink struct {
+	profiles *csv.Writer
+	follows  *csv.Writer
+	blocks   *csv.Writer
+}
+
+func NewCSVSink(profiles, follows, blocks io.Writer) (Sink, error) {
+	s := &csvSink{
+		profiles: csv.NewWriter(profiles),
+		follows:  csv.NewWriter(follows),
+		blocks:   csv.NewWriter(blocks),
+	}
+	if err := errors.Join(
+		s.profiles.Write(csvProfileHeader),
+		s.follows.Write(csvEdgeHeader),
+		s.blocks.Write(csvEdgeHeader),
+	); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *csvSink) Profile(p graph.Profile) error {
+	return s.profiles.Write([]string{
+		p.DID,
+		p.Handle,
+		p.DisplayName,
+		p.Description,
+		p.Avatar.CID,
+		p.Avatar.MimeType,
+		csvInt(p.Avatar.Size),
+		p.Banner.CID,
+		p.Banner.MimeType,
+		csvInt(p.Banner.Size),
+		strings.Join(p.Labels, LABEL_SEPARATOR),
+		p.PinnedPost.URI,
+		p.PinnedPost.CID,
+		p.JoinedViaStarterPack.URI,
+		p.JoinedViaStarterPack.CID,
+		csvMillis(p.Created),
+		p.Rev,
+		p.URI,
+		p.CID,
+		CSV_LABEL_PROFILE,
+	})
+}
+
+func (s *csvSink) Follow(f graph.Follow) error {
+	return s.follows.Write(csvEdge(f.DID, f.Subject, f.RKey, f.Created, f.Removed, f.Rev, f.URI, f.CID, CSV_TYPE_FOLLOWS))
+}
+
+func (s *csvSink) Block(b graph.Block) error {
+	return s.blocks.Write(csvEdge(b.DID, b.Subject, b.RKey, b.Created, b.Removed, b.Rev, b.URI, b.CID, CSV_TYPE_BLOCKS))
+}
+
+func (s *csvSink) Close() error {
+	var errs []error
+	for _, w := range []*csv.Writer{s.profiles, s.follows, s.blocks} {
+		w.Flush()
+		errs = append(errs, w.Error())
+	}
+	return errors.Join(errs...)
+}
+
+func csvEdge(did, subject, rkey string, created time.Time, removed *time.Time, rev, uri, cid, kind string) []string {
+	var removedMillis string
+	if removed != nil {
+		removedMillis = csvMillis(*removed)
+	}
+	return []string{did, subject, rkey, csvMillis(created), removedMillis, rev, uri, cid, kind}
+}
+
+// csvInt - empty for zero so blob-less profiles don't gain a size
+func csvInt(i int64) string {
+	if i == 0 {
+		return ""
+	}
+	return strconv.FormatInt(i, 10)
+}
+
+// csvMillis - epoch milliseconds, empty for a zero time
+func csvMillis(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
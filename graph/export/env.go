@@ -0,0 +1,23 @@
+package export
+
+const (
+	ENV_ATGRAPH_EXPORT_FORMAT    = "ATGRAPH_EXPORT_FORMAT"
+	ENV_ATGRAPH_EXPORT_DIR       = "ATGRAPH_EXPORT_DIR"
+	ENV_ATGRAPH_EXPORT_ROW_GROUP = "ATGRAPH_EXPORT_ROW_GROUP"
+
+	// formats
+	// parquet - profiles.parquet, follows.parquet and blocks.parquet for DuckDB / pandas
+	FORMAT_PARQUET = "parquet"
+	// graphml - graph.graphml for NetworkX / igraph
+	FORMAT_GRAPHML = "graphml"
+	// gexf - graph.gexf for Gephi, edges carry their validity interval
+	FORMAT_GEXF = "gexf"
+	// csv - neo4j-admin database import files
+	FORMAT_CSV = "csv"
+
+	// defaults
+	DEFAULT_FORMAT = FORMAT_PARQUET
+	DEFAULT_DIR    = "export"
+	// rows buffered per Parquet row group - bounds the memory of an export
+	DEFAULT_ROW_GROUP = 100000
+)
@@ -0,0 +1,187 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mikeblum/atgraph.dev/conf"
+	"github.com/mikeblum/atgraph.dev/graph"
+)
+
+const (
+	EDGE_FOLLOW = "follow"
+	EDGE_BLOCK  = "block"
+
+	// TIME_FORMAT - RFC 3339 in UTC with milliseconds, the precision both engines store
+	TIME_FORMAT = "2006-01-02T15:04:05.000Z07:00"
+
+	// LABEL_SEPARATOR - joins a profile's self-labels into a single value
+	LABEL_SEPARATOR = ";"
+)
+
+var ErrUnsupportedFormat = errors.New("export: unsupported format")
+
+// Sink - a streaming destination in one format. Run hands it every profile
+// before the first edge so graph formats can close their node list.
+type Sink interface {
+	Profile(p graph.Profile) error
+	Follow(f graph.Follow) error
+	Block(b graph.Block) error
+	// Close - flush buffered rows and finish every file
+	Close() error
+}
+
+// Summary - rows written by Run
+type Summary struct {
+	Profiles int           `json:"profiles"`
+	Follows  int           `json:"follows"`
+	Blocks   int           `json:"blocks"`
+	Elapsed  time.Duration `json:"elapsed"`
+}
+
+// Run - stream the profiles, follows and blocks matching filter from
+// exporter into sink, closing it once done
+func Run(ctx context.Context, exporter graph.Exporter, sink Sink, filter graph.ExportFilter) (*Summary, error) {
+	summary, err := run(ctx, exporter, sink, filter)
+	return summary, errors.Join(err, sink.Close())
+}
+
+func run(ctx context.Context, exporter graph.Exporter, sink Sink, filter graph.ExportFilter) (*Summary, error) {
+	log := conf.NewLog()
+	start := time.Now()
+	summary := &Summary{}
+
+	if err := exporter.ExportProfiles(ctx, filter, func(p graph.Profile) error {
+		summary.Profiles++
+		return sink.Profile(p)
+	}); err != nil {
+		return summary, fmt.Errorf("export profiles: %w", err)
+	}
+	log.With("profiles", summary.Profiles, "elapsed", time.Since(start)).Info("Exported profiles")
+
+	if err := exporter.ExportFollows(ctx, filter, func(f graph.Follow) error {
+		summary.Follows++
+		return sink.Follow(f)
+	}); err != nil {
+		return summary, fmt.Errorf("export follows: %w", err)
+	}
+	log.With("follows", summary.Follows, "elapsed", time.Since(start)).Info("Exported follows")
+
+	if err := exporter.ExportBlocks(ctx, filter, func(b graph.Block) error {
+		summary.Blocks++
+		return sink.Block(b)
+	}); err != nil {
+		return summary, fmt.Errorf("export blocks: %w", err)
+	}
+	log.With("blocks", summary.Blocks, "elapsed", time.Since(start)).Info("Exported blocks")
+
+	summary.Elapsed = time.Since(start)
+	return summary, nil
+}
+
+// NewSink - create the files of format in dir, replacing any previous export
+func NewSink(format, dir string, rowGroup int) (Sink, error) {
+	var names []string
+	switch format {
+	case FORMAT_PARQUET:
+		names = []string{"profiles.parquet", "follows.parquet", "blocks.parquet"}
+	case FORMAT_CSV:
+		names = []string{"profiles.csv", "follows.csv", "blocks.csv"}
+	case FORMAT_GRAPHML:
+		names = []string{"graph.graphml"}
+	case FORMAT_GEXF:
+		names = []string{"graph.gexf"}
+	default:
+		return nil, fmt.Errorf("%w: %q (expected one of %s)", ErrUnsupportedFormat, format, strings.Join(Formats(), ", "))
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	files := make([]*file, 0, len(names))
+	for _, name := range names {
+		f, err := createFile(filepath.Join(dir, name))
+		if err != nil {
+			for _, f := range files {
+				_ = f.Close()
+			}
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	var sink Sink
+	var err error
+	switch format {
+	case FORMAT_PARQUET:
+		sink = NewParquetSink(files[0], files[1], files[2], rowGroup)
+	case FORMAT_CSV:
+		sink, err = NewCSVSink(files[0], files[1], files[2])
+	case FORMAT_GRAPHML:
+		sink, err = NewGraphMLSink(files[0])
+	case FORMAT_GEXF:
+		sink, err = NewGEXFSink(files[0])
+	}
+	fs := &fileSink{Sink: sink, files: files}
+	if err != nil {
+		return nil, errors.Join(err, fs.closeFiles())
+	}
+	return fs, nil
+}
+
+// file - buffered export file
+type file struct {
+	*bufio.Writer
+	f *os.File
+}
+
+func createFile(path string) (*file, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &file{Writer: bufio.NewWriter(f), f: f}, nil
+}
+
+func (f *file) Close() error {
+	return errors.Join(f.Flush(), f.f.Close())
+}
+
+// fileSink - closes the files under a Sink once it's finished writing them
+type fileSink struct {
+	Sink
+	files []*file
+}
+
+func (s *fileSink) Close() error {
+	err := s.Sink.Close()
+	return errors.Join(err, s.closeFiles())
+}
+
+func (s *fileSink) closeFiles() error {
+	var errs []error
+	for _, f := range s.files {
+		errs = append(errs, f.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// formatTime - TIME_FORMAT, empty for a zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(TIME_FORMAT)
+}
+
+// formatRemoved - formatTime of an edge's removal, empty while it's still in its repo
+func formatRemoved(removed *time.Time) string {
+	if removed == nil {
+		return ""
+	}
+	return formatTime(*removed)
+}
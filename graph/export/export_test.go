@@ -0,0 +1,451 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mikeblum/atgraph.dev/bsky"
+	"github.com/mikeblum/atgraph.dev/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	createdTest = time.Date(2024, 11, 5, 12, 30, 0, 0, time.UTC)
+	removedTest = createdTest.Add(48 * time.Hour)
+
+	profilesTest = []graph.Profile{
+		{
+			DID:         "did:plc:alice",
+			Handle:      "alice.test",
+			DisplayName: "Alice <3 & co",
+			Avatar:      bsky.Blob{CID: "bafyavatar", MimeType: "image/jpeg", Size: 1024},
+			Labels:      []string{"!no-unauthenticated", "porn"},
+			Created:     createdTest,
+			Rev:         "3lagz5ptlcc2k",
+			URI:         "at://did:plc:alice/app.bsky.actor.profile/self",
+			CID:         "bafyprofile",
+		},
+		{DID: "did:plc:bob", Handle: "bob.test", Created: createdTest, Rev: "3lagz5ptlcc2k"},
+		// only known as the subject of a follow
+		{DID: "did:plc:carol"},
+	}
+	followsTest = []graph.Follow{
+		{DID: "did:plc:alice", Subject: "did:plc:bob", RKey: "3laa", Created: createdTest, Rev: "3lab", URI: "at://did:plc:alice/app.bsky.graph.follow/3laa", CID: "bafy1"},
+		{DID: "did:plc:bob", Subject: "did:plc:alice", RKey: "3lab", Created: createdTest, Removed: &removedTest, Rev: "3lac", URI: "at://did:plc:bob/app.bsky.graph.follow/3lab", CID: "bafy2"},
+		{DID: "did:plc:bob", Subject: "did:plc:carol", RKey: "3lac", Created: createdTest, Rev: "3lac", URI: "at://did:plc:bob/app.bsky.graph.follow/3lac", CID: "bafy3"},
+	}
+	blocksTest = []graph.Block{
+		{DID: "did:plc:carol", Subject: "did:plc:alice", RKey: "3lad", Created: createdTest, Rev: "3lad", URI: "at://did:plc:carol/app.bsky.graph.block/3lad", CID: "bafy4"},
+	}
+)
+
+type exporterTest struct {
+	filters []graph.ExportFilter
+}
+
+func (e *exporterTest) ExportProfiles(ctx context.Context, filter graph.ExportFilter, fn func(graph.Profile) error) error {
+	e.filters = append(e.filters, filter)
+	return each(profilesTest, fn)
+}
+
+func (e *exporterTest) ExportFollows(ctx context.Context, filter graph.ExportFilter, fn func(graph.Follow) error) error {
+	e.filters = append(e.filters, filter)
+	return each(followsTest, fn)
+}
+
+func (e *exporterTest) ExportBlocks(ctx context.Context, filter graph.ExportFilter, fn func(graph.Block) error) error {
+	e.filters = append(e.filters, filter)
+	return each(blocksTest, fn)
+}
+
+func each[T any](items []T, fn func(T) error) error {
+	for _, item := range items {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExport(t *testing.T) {
+	t.Run("run streams every row and closes the sink", runTest)
+	t.Run("parquet", parquetTest)
+	t.Run("parquet read by pyarrow", parquetReaderTest)
+	t.Run("graphml", graphmlTest)
+	t.Run("gexf", gexfTest)
+	t.Run("neo4j-admin csv", csvTest)
+	t.Run("new sink writes format files", newSinkTest)
+}
+
+func runTest(t *testing.T) {
+	exporter := &exporterTest{}
+	var profiles, follows, blocks bytes.Buffer
+	sink, err := NewCSVSink(&profiles, &follows, &blocks)
+	require.Nil(t, err)
+	filter := graph.ExportFilter{DIDs: []string{"did:plc:alice"}, Since: createdTest, Current: true}
+	summary, err := Run(context.Background(), exporter, sink, filter)
+	require.Nil(t, err)
+	assert.Equal(t, len(profilesTest), summary.Profiles)
+	assert.Equal(t, len(followsTest), summary.Follows)
+	assert.Equal(t, len(blocksTest), summary.Blocks)
+	assert.Equal(t, []graph.ExportFilter{filter, filter, filter}, exporter.filters)
+	// Close flushed the csv writers
+	assert.NotZero(t, blocks.Len())
+}
+
+func parquetTest(t *testing.T) {
+	var profiles, follows, blocks bytes.Buffer
+	// two rows per row group splits the follows across two groups
+	_, err := Run(context.Background(), &exporterTest{}, NewParquetSink(&profiles, &follows, &blocks, 2), graph.ExportFilter{})
+	require.Nil(t, err)
+
+	meta := parquetFooter(t, profiles.Bytes())
+	assert.Equal(t, int64(len(profilesTest)), meta[3])
+	schema := meta[2].([]any)
+	require.Len(t, schema, 20)
+	assert.Equal(t, "schema", schema[0].(map[int16]any)[4])
+	assert.Equal(t, "did", schema[1].(map[int16]any)[4])
+	assert.Equal(t, int64(convertedTimestampMillis), schema[16].(map[int16]any)[6])
+	assert.Equal(t, int64(repetitionOptional), schema[16].(map[int16]any)[3])
+
+	data := follows.Bytes()
+	meta = parquetFooter(t, data)
+	assert.Equal(t, int64(len(followsTest)), meta[3])
+	groups := meta[4].([]any)
+	require.Len(t, groups, 2)
+	assert.Equal(t, int64(2), groups[0].(map[int16]any)[3])
+	assert.Equal(t, int64(1), groups[1].(map[int16]any)[3])
+
+	// did - required PLAIN byte arrays
+	page := parquetPage(t, data, groups[0], 0)
+	assert.Equal(t, []string{"did:plc:alice", "did:plc:bob"}, plainStrings(t, page, 2))
+
+	// removed - optional: bit-packed definition levels then only the set values
+	page = parquetPage(t, data, groups[0], 4)
+	size := binary.LittleEndian.Uint32(page)
+	levels := page[4 : 4+size]
+	header, n := binary.Uvarint(levels)
+	assert.Equal(t, uint64(1<<1|1), header, "one bit-packed group of 8")
+	assert.Equal(t, byte(0b10), levels[n], "only the second follow was removed")
+	values := page[4+size:]
+	require.Len(t, values, 8)
+	assert.Equal(t, removedTest.UnixMilli(), int64(binary.LittleEndian.Uint64(values)))
+
+	// an empty export is still a valid file
+	assert.Equal(t, int64(len(blocksTest)), parquetFooter(t, blocks.Bytes())[3])
+	var empty bytes.Buffer
+	require.Nil(t, newParquetWriter(&empty, 2, edgeColumns()...).Close())
+	meta = parquetFooter(t, empty.Bytes())
+	assert.Equal(t, int64(0), meta[3])
+	assert.Empty(t, meta[4])
+}
+
+// pyarrowTest - reads every column of each Parquet file with pyarrow,
+// timestamps as epoch milliseconds
+const pyarrowTest = `
+import json, sys
+import pyarrow as pa, pyarrow.parquet as pq
+files = {}
+for path in sys.argv[1:]:
+    table = pq.read_table(path)
+    columns = {}
+    for name in table.column_names:
+        column = table.column(name)
+        if pa.types.is_timestamp(column.type):
+            column = column.cast(pa.int64())
+        columns[name] = column.to_pylist()
+    files[path] = columns
+json.dump(files, sys.stdout)
+`
+
+// parquetReaderTest - the files are read back by an independent Parquet
+// implementation. Needs python3 with pyarrow, skipped without it.
+func parquetReaderTest(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err == nil {
+		err = exec.Command(python, "-c", "import pyarrow.parquet").Run()
+	}
+	if err != nil {
+		t.Skip("python3 with pyarrow is required to read the files back")
+	}
+	dir := t.TempDir()
+	paths := []string{filepath.Join(dir, "profiles.parquet"), filepath.Join(dir, "follows.parquet"), filepath.Join(dir, "blocks.parquet"), filepath.Join(dir, "empty.parquet")}
+	var files []*os.File
+	for _, path := range paths {
+		f, err := os.Create(path)
+		require.Nil(t, err)
+		defer f.Close()
+		files = append(files, f)
+	}
+	// two rows per row group splits the follows across two groups
+	_, err = Run(context.Background(), &exporterTest{}, NewParquetSink(files[0], files[1], files[2], 2), graph.ExportFilter{})
+	require.Nil(t, err)
+	require.Nil(t, newParquetWriter(files[3], 2, edgeColumns()...).Close())
+
+	out, err := exec.Command(python, append([]string{"-c", pyarrowTest}, paths...)...).Output()
+	require.Nil(t, err)
+	var read map[string]map[string][]any
+	require.Nil(t, json.Unmarshal(out, &read))
+
+	created, removed := float64(createdTest.UnixMilli()), float64(removedTest.UnixMilli())
+	profiles := read[paths[0]]
+	assert.Equal(t, []any{"did:plc:alice", "did:plc:bob", "did:plc:carol"}, profiles["did"])
+	assert.Equal(t, []any{"Alice <3 & co", "", ""}, profiles["display_name"])
+	assert.Equal(t, []any{float64(1024), float64(0), float64(0)}, profiles["avatar_size"])
+	assert.Equal(t, []any{"!no-unauthenticated;porn", "", ""}, profiles["labels"])
+	// stub profiles have a null created
+	assert.Equal(t, []any{created, created, nil}, profiles["created"])
+
+	follows := read[paths[1]]
+	assert.Equal(t, []any{"did:plc:alice", "did:plc:bob", "did:plc:bob"}, follows["did"])
+	assert.Equal(t, []any{"did:plc:bob", "did:plc:alice", "did:plc:carol"}, follows["subject"])
+	assert.Equal(t, []any{created, created, created}, follows["created"])
+	assert.Equal(t, []any{nil, removed, nil}, follows["removed"])
+	assert.Equal(t, []any{"bafy1", "bafy2", "bafy3"}, follows["cid"])
+
+	assert.Equal(t, []any{"did:plc:carol"}, read[paths[2]]["did"])
+	assert.Empty(t, read[paths[3]]["did"])
+}
+
+type graphmlTestDoc struct {
+	Keys  []graphmlKey `xml:"key"`
+	Graph struct {
+		EdgeDefault string `xml:"edgedefault,attr"`
+		Nodes       []struct {
+			ID   string        `xml:"id,attr"`
+			Data []graphmlData `xml:"data"`
+		} `xml:"node"`
+		Edges []struct {
+			Source string        `xml:"source,attr"`
+			Target string        `xml:"target,attr"`
+			Data   []graphmlData `xml:"data"`
+		} `xml:"edge"`
+	} `xml:"graph"`
+}
+
+func graphmlTest(t *testing.T) {
+	var out bytes.Buffer
+	sink, err := NewGraphMLSink(&out)
+	require.Nil(t, err)
+	_, err = Run(context.Background(), &exporterTest{}, sink, graph.ExportFilter{})
+	require.Nil(t, err)
+
+	var doc graphmlTestDoc
+	require.Nil(t, xml.Unmarshal(out.Bytes(), &doc))
+	assert.Len(t, doc.Keys, len(graphmlNodeKeys)+len(graphmlEdgeKeys))
+	assert.Equal(t, "directed", doc.Graph.EdgeDefault)
+	require.Len(t, doc.Graph.Nodes, len(profilesTest))
+	assert.Contains(t, doc.Graph.Nodes[0].Data, graphmlData{Key: "node_display_name", Value: "Alice <3 & co"})
+	assert.Contains(t, doc.Graph.Nodes[0].Data, graphmlData{Key: "node_labels", Value: "!no-unauthenticated;porn"})
+	assert.Empty(t, doc.Graph.Nodes[2].Data, "stub profiles carry no data")
+	require.Len(t, doc.Graph.Edges, len(followsTest)+len(blocksTest))
+	assert.Equal(t, "did:plc:bob", doc.Graph.Edges[1].Source)
+	assert.Contains(t, doc.Graph.Edges[1].Data, graphmlData{Key: "edge_removed", Value: "2024-11-07T12:30:00.000Z"})
+	assert.Contains(t, doc.Graph.Edges[3].Data, graphmlData{Key: "edge_type", Value: EDGE_BLOCK})
+}
+
+type gexfTestDoc struct {
+	Graph struct {
+		Mode  string `xml:"mode,attr"`
+		Nodes []struct {
+			ID    string `xml:"id,attr"`
+			Label string `xml:"label,attr"`
+		} `xml:"nodes>node"`
+		Edges []struct {
+			Label string `xml:"label,attr"`
+			Start string `xml:"start,attr"`
+			End   string `xml:"end,attr"`
+		} `xml:"edges>edge"`
+	} `xml:"graph"`
+}
+
+func gexfTest(t *testing.T) {
+	var out bytes.Buffer
+	sink, err := NewGEXFSink(&out)
+	require.Nil(t, err)
+	_, err = Run(context.Background(), &exporterTest{}, sink, graph.ExportFilter{})
+	require.Nil(t, err)
+
+	var doc gexfTestDoc
+	require.Nil(t, xml.Unmarshal(out.Bytes(), &doc))
+	assert.Equal(t, "dynamic", doc.Graph.Mode)
+	require.Len(t, doc.Graph.Nodes, len(profilesTest))
+	assert.Equal(t, "alice.test", doc.Graph.Nodes[0].Label)
+	assert.Equal(t, "did:plc:carol", doc.Graph.Nodes[2].Label, "stub profiles are labelled by DID")
+	require.Len(t, doc.Graph.Edges, len(followsTest)+len(blocksTest))
+	assert.Equal(t, "2024-11-05T12:30:00.000Z", doc.Graph.Edges[1].Start)
+	assert.Equal(t, "2024-11-07T12:30:00.000Z", doc.Graph.Edges[1].End)
+	assert.Empty(t, doc.Graph.Edges[0].End)
+	assert.Equal(t, EDGE_BLOCK, doc.Graph.Edges[3].Label)
+
+	// no edges still closes <nodes> and opens an empty <edges>
+	out.Reset()
+	sink, err = NewGEXFSink(&out)
+	require.Nil(t, err)
+	require.Nil(t, sink.Profile(profilesTest[0]))
+	require.Nil(t, sink.Close())
+	doc = gexfTestDoc{}
+	require.Nil(t, xml.Unmarshal(out.Bytes(), &doc))
+	assert.Len(t, doc.Graph.Nodes, 1)
+}
+
+func csvTest(t *testing.T) {
+	var profiles, follows, blocks bytes.Buffer
+	sink, err := NewCSVSink(&profiles, &follows, &blocks)
+	require.Nil(t, err)
+	_, err = Run(context.Background(), &exporterTest{}, sink, graph.ExportFilter{})
+	require.Nil(t, err)
+
+	rows, err := csv.NewReader(&profiles).ReadAll()
+	require.Nil(t, err)
+	require.Len(t, rows, len(profilesTest)+1)
+	assert.Equal(t, csvProfileHeader, rows[0])
+	assert.Equal(t, "1024", rows[1][6])
+	assert.Equal(t, "!no-unauthenticated;porn", rows[1][10])
+	assert.Equal(t, "1730809800000", rows[1][15])
+	assert.Equal(t, []string{"did:plc:carol", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", CSV_LABEL_PROFILE}, rows[3])
+
+	rows, err = csv.NewReader(&follows).ReadAll()
+	require.Nil(t, err)
+	require.Len(t, rows, len(followsTest)+1)
+	assert.Equal(t, csvEdgeHeader, rows[0])
+	assert.Equal(t, "", rows[1][4])
+	assert.Equal(t, "1730982600000", rows[2][4])
+	assert.Equal(t, CSV_TYPE_FOLLOWS, rows[2][8])
+
+	rows, err = csv.NewReader(&blocks).ReadAll()
+	require.Nil(t, err)
+	assert.Equal(t, CSV_TYPE_BLOCKS, rows[1][8])
+}
+
+func newSinkTest(t *testing.T) {
+	_, err := NewSink("xlsx", t.TempDir(), DEFAULT_ROW_GROUP)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	for format, names := range map[string][]string{
+		FORMAT_PARQUET: {"profiles.parquet", "follows.parquet", "blocks.parquet"},
+		FORMAT_CSV:     {"profiles.csv", "follows.csv", "blocks.csv"},
+		FORMAT_GRAPHML: {"graph.graphml"},
+		FORMAT_GEXF:    {"graph.gexf"},
+	} {
+		dir := filepath.Join(t.TempDir(), "export")
+		sink, err := NewSink(format, dir, DEFAULT_ROW_GROUP)
+		require.Nil(t, err, format)
+		_, err = Run(context.Background(), &exporterTest{}, sink, graph.ExportFilter{})
+		require.Nil(t, err, format)
+		for _, name := range names {
+			info, err := os.Stat(filepath.Join(dir, name))
+			require.Nil(t, err, name)
+			assert.NotZero(t, info.Size(), name)
+		}
+	}
+}
+
+// parquetFooter - FileMetaData decoded into field id -> value maps
+func parquetFooter(t *testing.T, data []byte) map[int16]any {
+	t.Helper()
+	require.True(t, bytes.HasPrefix(data, parquetMagic))
+	require.True(t, bytes.HasSuffix(data, parquetMagic))
+	size := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	r := &thriftReaderTest{t: t, b: data[len(data)-8-size : len(data)-8]}
+	meta := r.readStruct()
+	assert.Equal(t, len(r.b), r.pos, "footer fully consumed")
+	return meta
+}
+
+// parquetPage - body of the data page of column in group
+func parquetPage(t *testing.T, data []byte, group any, column int) []byte {
+	t.Helper()
+	chunk := group.(map[int16]any)[1].([]any)[column].(map[int16]any)
+	offset := chunk[3].(map[int16]any)[9].(int64)
+	r := &thriftReaderTest{t: t, b: data[offset:]}
+	header := r.readStruct()
+	assert.Equal(t, int64(pageData), header[1])
+	size := int(header[2].(int64))
+	assert.Equal(t, chunk[3].(map[int16]any)[6], int64(r.pos+size), "chunk size covers header and page")
+	return r.b[r.pos : r.pos+size]
+}
+
+func plainStrings(t *testing.T, page []byte, n int) []string {
+	t.Helper()
+	var values []string
+	for range n {
+		size := int(binary.LittleEndian.Uint32(page))
+		values = append(values, string(page[4:4+size]))
+		page = page[4+size:]
+	}
+	assert.Empty(t, page)
+	return values
+}
+
+// thriftReaderTest - decodes thrift compact structs into field id -> value maps
+type thriftReaderTest struct {
+	t   *testing.T
+	b   []byte
+	pos int
+}
+
+func (r *thriftReaderTest) readStruct() map[int16]any {
+	fields := make(map[int16]any)
+	var last int16
+	for {
+		header := r.b[r.pos]
+		r.pos++
+		if header == 0 {
+			return fields
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(r.varint())
+		}
+		last = id
+		fields[id] = r.value(header & 0x0f)
+	}
+}
+
+func (r *thriftReaderTest) value(kind byte) any {
+	switch kind {
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n, size := binary.Uvarint(r.b[r.pos:])
+		r.pos += size
+		s := string(r.b[r.pos : r.pos+int(n)])
+		r.pos += int(n)
+		return s
+	case thriftList:
+		header := r.b[r.pos]
+		r.pos++
+		n := int(header >> 4)
+		if n == 15 {
+			size, read := binary.Uvarint(r.b[r.pos:])
+			r.pos += read
+			n = int(size)
+		}
+		list := make([]any, n)
+		for i := range list {
+			list[i] = r.value(header & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.readStruct()
+	}
+	r.t.Fatalf("unexpected thrift type %d", kind)
+	return nil
+}
+
+func (r *thriftReaderTest) varint() int64 {
+	v, n := binary.Varint(r.b[r.pos:])
+	r.pos += n
+	return v
+}
@@ -0,0 +1,208 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/mikeblum/atgraph.dev/graph"
+)
+
+const (
+	GEXF_NAMESPACE = "http://www.gexf.net/1.2draft"
+	GEXF_VERSION   = "1.2"
+)
+
+// gexf attributes - ids are scoped to their class so nodes and edges may share names
+var (
+	gexfNodeAttrs = []string{
+		"handle",
+		"display_name",
+		"description",
+		"labels",
+		"starter_pack_uri",
+		"created",
+		"rev",
+	}
+	gexfEdgeAttrs = []string{
+		"type",
+		"rkey",
+		"rev",
+		"uri",
+	}
+)
+
+type gexfAttribute struct {
+	XMLName xml.Name `xml:"attribute"`
+	ID      string   `xml:"id,attr"`
+	Title   string   `xml:"title,attr"`
+	Type    string   `xml:"type,attr"`
+}
+
+type gexfAttributes struct {
+	XMLName    xml.Name        `xml:"attributes"`
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type gexfNode struct {
+	XMLName xml.Name    `xml:"node"`
+	ID      string      `xml:"id,attr"`
+	Label   string      `xml:"label,attr"`
+	Values  []gexfValue `xml:"attvalues>attvalue"`
+}
+
+// gexfEdge - start / end bound the edge's validity interval on Gephi's timeline
+type gexfEdge struct {
+	XMLName xml.Name    `xml:"edge"`
+	ID      string      `xml:"id,attr"`
+	Source  string      `xml:"source,attr"`
+	Target  string      `xml:"target,attr"`
+	Label   string      `xml:"label,attr"`
+	Start   string      `xml:"start,attr,omitempty"`
+	End     string      `xml:"end,attr,omitempty"`
+	Values  []gexfValue `xml:"attvalues>attvalue"`
+}
+
+// gexfSink - a single dynamic directed graph. GEXF lists every node before
+// the first edge, which Run guarantees.
+type gexfSink struct {
+	enc   *xml.Encoder
+	edges bool
+}
+
+func NewGEXFSink(w io.Writer) (Sink, error) {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return nil, err
+	}
+	s := &gexfSink{enc: xml.NewEncoder(w)}
+	s.enc.Indent("", "  ")
+	if err := s.start("gexf", "xmlns", GEXF_NAMESPACE, "version", GEXF_VERSION); err != nil {
+		return nil, err
+	}
+	if err := s.start("graph", "defaultedgetype", "directed", "mode", "dynamic", "timeformat", "dateTime"); err != nil {
+		return nil, err
+	}
+	for _, attrs := range []gexfAttributes{
+		{Class: "node", Attributes: gexfAttributeList(gexfNodeAttrs)},
+		{Class: "edge", Attributes: gexfAttributeList(gexfEdgeAttrs)},
+	} {
+		if err := s.enc.Encode(attrs); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.start("nodes"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *gexfSink) Profile(p graph.Profile) error {
+	label := p.Handle
+	if label == "" {
+		label = p.DID
+	}
+	return s.enc.Encode(gexfNode{
+		ID:    p.DID,
+		Label: label,
+		Values: gexfValues(
+			"handle", p.Handle,
+			"display_name", p.DisplayName,
+			"description", p.Description,
+			"labels", strings.Join(p.Labels, LABEL_SEPARATOR),
+			"starter_pack_uri", p.JoinedViaStarterPack.URI,
+			"created", formatTime(p.Created),
+			"rev", p.Rev,
+		),
+	})
+}
+
+func (s *gexfSink) Follow(f graph.Follow) error {
+	return s.edge(EDGE_FOLLOW, f.DID, f.Subject, f.RKey, formatTime(f.Created), formatRemoved(f.Removed), f.Rev, f.URI)
+}
+
+func (s *gexfSink) Block(b graph.Block) error {
+	return s.edge(EDGE_BLOCK, b.DID, b.Subject, b.RKey, formatTime(b.Created), formatRemoved(b.Removed), b.Rev, b.URI)
+}
+
+func (s *gexfSink) edge(kind, did, subject, rkey, created, removed, rev, uri string) error {
+	if err := s.beginEdges(); err != nil {
+		return err
+	}
+	return s.enc.Encode(gexfEdge{
+		ID:     uri,
+		Source: did,
+		Target: subject,
+		Label:  kind,
+		Start:  created,
+		End:    removed,
+		Values: gexfValues(
+			"type", kind,
+			"rkey", rkey,
+			"rev", rev,
+			"uri", uri,
+		),
+	})
+}
+
+// beginEdges - close <nodes> and open <edges> ahead of the first edge
+func (s *gexfSink) beginEdges() error {
+	if s.edges {
+		return nil
+	}
+	s.edges = true
+	if err := s.end("nodes"); err != nil {
+		return err
+	}
+	return s.start("edges")
+}
+
+func (s *gexfSink) Close() error {
+	if err := s.beginEdges(); err != nil {
+		return err
+	}
+	for _, name := range []string{"edges", "graph", "gexf"} {
+		if err := s.end(name); err != nil {
+			return err
+		}
+	}
+	return s.enc.Close()
+}
+
+// start - open element name with attribute name, value pairs
+func (s *gexfSink) start(name string, attrs ...string) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: attrs[i]}, Value: attrs[i+1]})
+	}
+	return s.enc.EncodeToken(start)
+}
+
+func (s *gexfSink) end(name string) error {
+	return s.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}})
+}
+
+func gexfAttributeList(names []string) []gexfAttribute {
+	attrs := make([]gexfAttribute, 0, len(names))
+	for _, name := range names {
+		attrs = append(attrs, gexfAttribute{ID: name, Title: name, Type: "string"})
+	}
+	return attrs
+}
+
+// gexfValues - <attvalue> for each non-empty name, value pair
+func gexfValues(pairs ...string) []gexfValue {
+	values := make([]gexfValue, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			continue
+		}
+		values = append(values, gexfValue{For: pairs[i], Value: pairs[i+1]})
+	}
+	return values
+}
@@ -0,0 +1,169 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/mikeblum/atgraph.dev/graph"
+)
+
+const GRAPHML_NAMESPACE = "http://graphml.graphdrawing.org/xmlns"
+
+// graphml attributes: key ids are <for>_<name> since ids are shared by nodes and edges
+var (
+	graphmlNodeKeys = []string{
+		"handle",
+		"display_name",
+		"description",
+		"avatar_cid",
+		"banner_cid",
+		"labels",
+		"pinned_post_uri",
+		"starter_pack_uri",
+		"created",
+		"rev",
+		"uri",
+	}
+	graphmlEdgeKeys = []string{
+		"type",
+		"rkey",
+		"created",
+		"removed",
+		"rev",
+		"uri",
+		"cid",
+	}
+)
+
+type graphmlKey struct {
+	XMLName xml.Name `xml:"key"`
+	ID      string   `xml:"id,attr"`
+	For     string   `xml:"for,attr"`
+	Name    string   `xml:"attr.name,attr"`
+	Type    string   `xml:"attr.type,attr"`
+}
+
+type graphmlData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphmlNode struct {
+	XMLName xml.Name      `xml:"node"`
+	ID      string        `xml:"id,attr"`
+	Data    []graphmlData `xml:"data"`
+}
+
+type graphmlEdge struct {
+	XMLName xml.Name      `xml:"edge"`
+	ID      string        `xml:"id,attr,omitempty"`
+	Source  string        `xml:"source,attr"`
+	Target  string        `xml:"target,attr"`
+	Data    []graphmlData `xml:"data"`
+}
+
+// graphmlSink - a single directed graph: profiles are nodes keyed by DID,
+// follows and blocks are edges keyed by their AT-URI with a type attribute
+type graphmlSink struct {
+	enc *xml.Encoder
+}
+
+func NewGraphMLSink(w io.Writer) (Sink, error) {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return nil, err
+	}
+	s := &graphmlSink{enc: xml.NewEncoder(w)}
+	s.enc.Indent("", "  ")
+	if err := s.enc.EncodeToken(xml.StartElement{
+		Name: xml.Name{Local: "graphml"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: GRAPHML_NAMESPACE}},
+	}); err != nil {
+		return nil, err
+	}
+	for _, keys := range []struct {
+		kind  string
+		names []string
+	}{{"node", graphmlNodeKeys}, {"edge", graphmlEdgeKeys}} {
+		for _, name := range keys.names {
+			if err := s.enc.Encode(graphmlKey{ID: keys.kind + "_" + name, For: keys.kind, Name: name, Type: "string"}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := s.enc.EncodeToken(xml.StartElement{
+		Name: xml.Name{Local: "graph"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "id"}, Value: "atgraph"},
+			{Name: xml.Name{Local: "edgedefault"}, Value: "directed"},
+		},
+	}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *graphmlSink) Profile(p graph.Profile) error {
+	return s.enc.Encode(graphmlNode{
+		ID: p.DID,
+		Data: graphmlAttrs("node",
+			"handle", p.Handle,
+			"display_name", p.DisplayName,
+			"description", p.Description,
+			"avatar_cid", p.Avatar.CID,
+			"banner_cid", p.Banner.CID,
+			"labels", strings.Join(p.Labels, LABEL_SEPARATOR),
+			"pinned_post_uri", p.PinnedPost.URI,
+			"starter_pack_uri", p.JoinedViaStarterPack.URI,
+			"created", formatTime(p.Created),
+			"rev", p.Rev,
+			"uri", p.URI,
+		),
+	})
+}
+
+func (s *graphmlSink) Follow(f graph.Follow) error {
+	return s.edge(EDGE_FOLLOW, f.DID, f.Subject, f.RKey, formatTime(f.Created), formatRemoved(f.Removed), f.Rev, f.URI, f.CID)
+}
+
+func (s *graphmlSink) Block(b graph.Block) error {
+	return s.edge(EDGE_BLOCK, b.DID, b.Subject, b.RKey, formatTime(b.Created), formatRemoved(b.Removed), b.Rev, b.URI, b.CID)
+}
+
+func (s *graphmlSink) edge(kind, did, subject, rkey, created, removed, rev, uri, cid string) error {
+	return s.enc.Encode(graphmlEdge{
+		ID:     uri,
+		Source: did,
+		Target: subject,
+		Data: graphmlAttrs("edge",
+			"type", kind,
+			"rkey", rkey,
+			"created", created,
+			"removed", removed,
+			"rev", rev,
+			"uri", uri,
+			"cid", cid,
+		),
+	})
+}
+
+func (s *graphmlSink) Close() error {
+	for _, name := range []string{"graph", "graphml"} {
+		if err := s.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}}); err != nil {
+			return err
+		}
+	}
+	return s.enc.Close()
+}
+
+// graphmlAttrs - <data> for each non-empty name, value pair
+func graphmlAttrs(kind string, pairs ...string) []graphmlData {
+	data := make([]graphmlData, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			continue
+		}
+		data = append(data, graphmlData{Key: kind + "_" + pairs[i], Value: pairs[i+1]})
+	}
+	return data
+}
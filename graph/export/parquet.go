@@ -0,0 +1,398 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mikeblum/atgraph.dev/graph"
+)
+
+// parquet.thrift enums
+const (
+	parquetInt64     int32 = 2
+	parquetByteArray int32 = 6
+
+	convertedNone            int32 = -1
+	convertedUTF8            int32 = 0
+	convertedTimestampMillis int32 = 9
+
+	repetitionRequired int32 = 0
+	repetitionOptional int32 = 1
+
+	encodingPlain int32 = 0
+	encodingRLE   int32 = 3
+
+	pageData          int32 = 0
+	codecUncompressed int32 = 0
+
+	PARQUET_CREATED_BY = "atgraph.dev"
+)
+
+var parquetMagic = []byte("PAR1")
+
+// parquetColumn - a flat column buffering the PLAIN encoded values and
+// definition levels of the current row group
+type parquetColumn struct {
+	name      string
+	kind      int32
+	converted int32
+	optional  bool
+	values    bytes.Buffer
+	defined   []bool
+}
+
+func stringColumn(name string) *parquetColumn {
+	return &parquetColumn{name: name, kind: parquetByteArray, converted: convertedUTF8}
+}
+
+func int64Column(name string) *parquetColumn {
+	return &parquetColumn{name: name, kind: parquetInt64, converted: convertedNone}
+}
+
+// timestampColumn - UTC epoch milliseconds, null for a zero or nil time
+func timestampColumn(name string) *parquetColumn {
+	return &parquetColumn{name: name, kind: parquetInt64, converted: convertedTimestampMillis, optional: true}
+}
+
+func (c *parquetColumn) append(value any) error {
+	switch v := value.(type) {
+	case string:
+		if c.kind != parquetByteArray {
+			return fmt.Errorf("parquet: column %s is not a string", c.name)
+		}
+		c.values.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(v))))
+		c.values.WriteString(v)
+	case int64:
+		if c.kind != parquetInt64 {
+			return fmt.Errorf("parquet: column %s is not an int64", c.name)
+		}
+		c.values.Write(binary.LittleEndian.AppendUint64(nil, uint64(v)))
+	case time.Time:
+		if v.IsZero() {
+			return c.null()
+		}
+		return c.append(v.UnixMilli())
+	case *time.Time:
+		if v == nil {
+			return c.null()
+		}
+		return c.append(*v)
+	default:
+		return fmt.Errorf("parquet: unsupported value %T for column %s", value, c.name)
+	}
+	c.defined = append(c.defined, true)
+	return nil
+}
+
+func (c *parquetColumn) null() error {
+	if !c.optional {
+		return fmt.Errorf("parquet: column %s is required", c.name)
+	}
+	c.defined = append(c.defined, false)
+	return nil
+}
+
+// page - data page v1 body: definition levels of optional columns as a
+// single bit-packed run prefixed by its length, then the non-null values
+func (c *parquetColumn) page() []byte {
+	var page bytes.Buffer
+	if c.optional {
+		groups := (len(c.defined) + 7) / 8
+		levels := binary.AppendUvarint(nil, uint64(groups)<<1|1)
+		for g := range groups {
+			var packed byte
+			for i := 0; i < 8 && g*8+i < len(c.defined); i++ {
+				if c.defined[g*8+i] {
+					packed |= 1 << i
+				}
+			}
+			levels = append(levels, packed)
+		}
+		page.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(levels))))
+		page.Write(levels)
+	}
+	page.Write(c.values.Bytes())
+	return page.Bytes()
+}
+
+func (c *parquetColumn) reset() {
+	c.values.Reset()
+	c.defined = c.defined[:0]
+}
+
+// parquetChunk - where a column chunk of a flushed row group was written
+type parquetChunk struct {
+	offset int64
+	size   int64
+}
+
+type parquetRowGroup struct {
+	rows   int64
+	size   int64
+	chunks []parquetChunk
+}
+
+// parquetWriter - streaming writer for flat schemas of UTF8 strings, int64s
+// and millisecond timestamps: PLAIN encoded, uncompressed, one data page per
+// column chunk. Rows are buffered for one row group at a time so memory is
+// bounded by rowGroup, not by the size of the file.
+type parquetWriter struct {
+	w        io.Writer
+	offset   int64
+	columns  []*parquetColumn
+	rowGroup int
+	rows     int
+	groups   []parquetRowGroup
+}
+
+func newParquetWriter(w io.Writer, rowGroup int, columns ...*parquetColumn) *parquetWriter {
+	return &parquetWriter{
+		w:        w,
+		columns:  columns,
+		rowGroup: max(rowGroup, 1),
+	}
+}
+
+// Write - one row, a value per column in schema order
+func (p *parquetWriter) Write(values ...any) error {
+	if len(values) != len(p.columns) {
+		return fmt.Errorf("parquet: expected %d values, got %d", len(p.columns), len(values))
+	}
+	for i, value := range values {
+		if err := p.columns[i].append(value); err != nil {
+			return err
+		}
+	}
+	if p.rows++; p.rows == p.rowGroup {
+		return p.flush()
+	}
+	return nil
+}
+
+// Close - flush the last row group and write the footer. The underlying
+// writer is left open.
+func (p *parquetWriter) Close() error {
+	if err := p.flush(); err != nil {
+		return err
+	}
+	if err := p.magic(); err != nil {
+		return err
+	}
+	footer := p.footer()
+	if err := p.write(footer); err != nil {
+		return err
+	}
+	if err := p.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer)))); err != nil {
+		return err
+	}
+	return p.write(parquetMagic)
+}
+
+// flush - write the buffered rows as a row group
+func (p *parquetWriter) flush() error {
+	if p.rows == 0 {
+		return nil
+	}
+	if err := p.magic(); err != nil {
+		return err
+	}
+	group := parquetRowGroup{rows: int64(p.rows)}
+	for _, c := range p.columns {
+		page := c.page()
+		header := pageHeader(len(page), p.rows)
+		chunk := parquetChunk{offset: p.offset, size: int64(len(header) + len(page))}
+		if err := p.write(header); err != nil {
+			return err
+		}
+		if err := p.write(page); err != nil {
+			return err
+		}
+		group.chunks = append(group.chunks, chunk)
+		group.size += chunk.size
+		c.reset()
+	}
+	p.groups = append(p.groups, group)
+	p.rows = 0
+	return nil
+}
+
+// magic - files start with PAR1
+func (p *parquetWriter) magic() error {
+	if p.offset > 0 {
+		return nil
+	}
+	return p.write(parquetMagic)
+}
+
+func (p *parquetWriter) write(b []byte) error {
+	n, err := p.w.Write(b)
+	p.offset += int64(n)
+	return err
+}
+
+// pageHeader - PageHeader of an uncompressed data page holding rows values
+func pageHeader(size, rows int) []byte {
+	t := newThriftWriter()
+	t.i32(1, pageData)
+	t.i32(2, int32(size))
+	t.i32(3, int32(size))
+	t.structField(5)
+	t.i32(1, int32(rows))
+	t.i32(2, encodingPlain)
+	t.i32(3, encodingRLE)
+	t.i32(4, encodingRLE)
+	t.end()
+	t.end()
+	return t.bytes()
+}
+
+// footer - FileMetaData: the schema and where each row group's chunks are
+func (p *parquetWriter) footer() []byte {
+	var rows int64
+	for _, group := range p.groups {
+		rows += group.rows
+	}
+	t := newThriftWriter()
+	t.i32(1, 1)
+	t.list(2, thriftStruct, len(p.columns)+1)
+	t.element()
+	t.binary(4, "schema")
+	t.i32(5, int32(len(p.columns)))
+	t.end()
+	for _, c := range p.columns {
+		t.element()
+		t.i32(1, c.kind)
+		repetition := repetitionRequired
+		if c.optional {
+			repetition = repetitionOptional
+		}
+		t.i32(3, repetition)
+		t.binary(4, c.name)
+		if c.converted != convertedNone {
+			t.i32(6, c.converted)
+		}
+		t.end()
+	}
+	t.i64(3, rows)
+	t.list(4, thriftStruct, len(p.groups))
+	for _, group := range p.groups {
+		t.element()
+		t.list(1, thriftStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			c := p.columns[i]
+			t.element()
+			t.i64(2, chunk.offset)
+			t.structField(3)
+			t.i32(1, c.kind)
+			t.list(2, thriftI32, 2)
+			t.listI32(encodingPlain)
+			t.listI32(encodingRLE)
+			t.list(3, thriftBinary, 1)
+			t.str(c.name)
+			t.i32(4, codecUncompressed)
+			t.i64(5, group.rows)
+			t.i64(6, chunk.size)
+			t.i64(7, chunk.size)
+			t.i64(9, chunk.offset)
+			t.end()
+			t.end()
+		}
+		t.i64(2, group.size)
+		t.i64(3, group.rows)
+		t.end()
+	}
+	t.binary(6, PARQUET_CREATED_BY)
+	t.end()
+	return t.bytes()
+}
+
+// parquetSink - profiles, follows and blocks as three Parquet files
+type parquetSink struct {
+	profiles *parquetWriter
+	follows  *parquetWriter
+	blocks   *parquetWriter
+}
+
+// NewParquetSink - self-labels are joined by LABEL_SEPARATOR, profiles only
+// known as the end of an edge have a null created
+func NewParquetSink(profiles, follows, blocks io.Writer, rowGroup int) Sink {
+	return &parquetSink{
+		profiles: newParquetWriter(profiles, rowGroup,
+			stringColumn("did"),
+			stringColumn("handle"),
+			stringColumn("display_name"),
+			stringColumn("description"),
+			stringColumn("avatar_cid"),
+			stringColumn("avatar_mime_type"),
+			int64Column("avatar_size"),
+			stringColumn("banner_cid"),
+			stringColumn("banner_mime_type"),
+			int64Column("banner_size"),
+			stringColumn("labels"),
+			stringColumn("pinned_post_uri"),
+			stringColumn("pinned_post_cid"),
+			stringColumn("starter_pack_uri"),
+			stringColumn("starter_pack_cid"),
+			timestampColumn("created"),
+			stringColumn("rev"),
+			stringColumn("uri"),
+			stringColumn("cid"),
+		),
+		follows: newParquetWriter(follows, rowGroup, edgeColumns()...),
+		blocks:  newParquetWriter(blocks, rowGroup, edgeColumns()...),
+	}
+}
+
+func edgeColumns() []*parquetColumn {
+	return []*parquetColumn{
+		stringColumn("did"),
+		stringColumn("subject"),
+		stringColumn("rkey"),
+		timestampColumn("created"),
+		timestampColumn("removed"),
+		stringColumn("rev"),
+		stringColumn("uri"),
+		stringColumn("cid"),
+	}
+}
+
+func (s *parquetSink) Profile(p graph.Profile) error {
+	return s.profiles.Write(
+		p.DID,
+		p.Handle,
+		p.DisplayName,
+		p.Description,
+		p.Avatar.CID,
+		p.Avatar.MimeType,
+		p.Avatar.Size,
+		p.Banner.CID,
+		p.Banner.MimeType,
+		p.Banner.Size,
+		strings.Join(p.Labels, LABEL_SEPARATOR),
+		p.PinnedPost.URI,
+		p.PinnedPost.CID,
+		p.JoinedViaStarterPack.URI,
+		p.JoinedViaStarterPack.CID,
+		p.Created,
+		p.Rev,
+		p.URI,
+		p.CID,
+	)
+}
+
+func (s *parquetSink) Follow(f graph.Follow) error {
+	return s.follows.Write(f.DID, f.Subject, f.RKey, f.Created, f.Removed, f.Rev, f.URI, f.CID)
+}
+
+func (s *parquetSink) Block(b graph.Block) error {
+	return s.blocks.Write(b.DID, b.Subject, b.RKey, b.Created, b.Removed, b.Rev, b.URI, b.CID)
+}
+
+func (s *parquetSink) Close() error {
+	return errors.Join(s.profiles.Close(), s.follows.Close(), s.blocks.Close())
+}
@@ -0,0 +1,101 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// thrift compact protocol field / element types
+const (
+	thriftI32    byte = 5
+	thriftI64    byte = 6
+	thriftBinary byte = 8
+	thriftList   byte = 9
+	thriftStruct byte = 12
+)
+
+// thriftWriter - the subset of the Thrift compact protocol Parquet page
+// headers and file metadata need. Fields must be written in ascending id
+// order within each struct.
+type thriftWriter struct {
+	buf bytes.Buffer
+	// fields - id of the last field written in each open struct
+	fields []int16
+}
+
+func newThriftWriter() *thriftWriter {
+	return &thriftWriter{fields: []int16{0}}
+}
+
+// field - short form header when the id delta fits in 4 bits
+func (t *thriftWriter) field(id int16, kind byte) {
+	last := &t.fields[len(t.fields)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | kind)
+	} else {
+		t.buf.WriteByte(kind)
+		t.varint(int64(id))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.str(s)
+}
+
+// structField - open a nested struct, closed by end
+func (t *thriftWriter) structField(id int16) {
+	t.field(id, thriftStruct)
+	t.fields = append(t.fields, 0)
+}
+
+// list - header for n elements of kind, written with element / listI32 / str
+func (t *thriftWriter) list(id int16, kind byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | kind)
+		return
+	}
+	t.buf.WriteByte(0xf0 | kind)
+	t.buf.Write(binary.AppendUvarint(nil, uint64(n)))
+}
+
+// element - open a struct list element, closed by end
+func (t *thriftWriter) element() {
+	t.fields = append(t.fields, 0)
+}
+
+func (t *thriftWriter) listI32(v int32) {
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) str(s string) {
+	t.buf.Write(binary.AppendUvarint(nil, uint64(len(s))))
+	t.buf.WriteString(s)
+}
+
+// end - stop the innermost open struct
+func (t *thriftWriter) end() {
+	t.buf.WriteByte(0)
+	t.fields = t.fields[:len(t.fields)-1]
+}
+
+// varint - zigzag encoded
+func (t *thriftWriter) varint(v int64) {
+	t.buf.Write(binary.AppendVarint(nil, v))
+}
+
+func (t *thriftWriter) bytes() []byte {
+	return t.buf.Bytes()
+}
@@ -0,0 +1,42 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// SUBGRAPH_MAX_DIDS - bound on the accounts a single SubgraphAsOf rebuilds
+const SUBGRAPH_MAX_DIDS = 1000
+
+var ErrNoHistory = errors.New("graph: no engine supports time travel")
+
+// RepoSyncer - engines closing the validity interval of every edge a repo no
+// longer contains. Called once all records of did at rev have been ingested:
+// edges last seen at an older rev were removed by the time of rev.
+type RepoSyncer interface {
+	RepoSynced(ctx context.Context, did, rev string) error
+}
+
+// History - the graph as it was at a point in time, rebuilt from each edge's
+// validity interval [Created, Removed)
+type History interface {
+	// FollowersAsOf - follows whose subject is did that were valid at at, ordered by follower DID
+	FollowersAsOf(ctx context.Context, did string, at time.Time, page Page) (*Results[Follow], error)
+	// SubgraphAsOf - follow and block edges between dids that were valid at at
+	SubgraphAsOf(ctx context.Context, dids []string, at time.Time) (*Subgraph, error)
+}
+
+// Subgraph - follow and block edges between a set of accounts at a point in time
+type Subgraph struct {
+	At      time.Time `json:"at"`
+	DIDs    []string  `json:"dids"`
+	Follows []Follow  `json:"follows"`
+	Blocks  []Block   `json:"blocks"`
+}
+
+// ValidAt - an edge created at created and removed at removed (nil if still
+// present) existed at at
+func ValidAt(created time.Time, removed *time.Time, at time.Time) bool {
+	return !created.After(at) && (removed == nil || removed.After(at))
+}
@@ -0,0 +1,50 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSyncer - fakeEngine recording RepoSynced calls
+type fakeSyncer struct {
+	fakeEngine
+	synced []string
+}
+
+func (f *fakeSyncer) RepoSynced(ctx context.Context, did, rev string) error {
+	f.synced = append(f.synced, did+"@"+rev)
+	return f.err
+}
+
+func TestHistory(t *testing.T) {
+	t.Run("validity intervals", validAtTest)
+	t.Run("repo syncs fan out", compositeRepoSyncedTest)
+}
+
+func validAtTest(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	removed := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	assert.False(t, ValidAt(created, nil, created.Add(-time.Second)))
+	assert.True(t, ValidAt(created, nil, created))
+	assert.True(t, ValidAt(created, nil, time.Now()))
+	assert.True(t, ValidAt(created, &removed, removed.Add(-time.Second)))
+	// [created, removed)
+	assert.False(t, ValidAt(created, &removed, removed))
+	assert.False(t, ValidAt(created, &removed, removed.Add(time.Hour)))
+}
+
+func compositeRepoSyncedTest(t *testing.T) {
+	ctx := context.Background()
+	plain, syncer := &fakeEngine{}, &fakeSyncer{}
+	engine, err := NewCompositeEngine(ctx, Backend{Name: "plain", Engine: plain}, Backend{Name: "syncer", Engine: syncer})
+	require.Nil(t, err)
+	require.Nil(t, engine.RepoSynced(ctx, "did:plc:test", "3kabc"))
+	assert.Equal(t, []string{"did:plc:test@3kabc"}, syncer.synced)
+
+	_, err = engine.SubgraphAsOf(ctx, []string{"did:plc:test"}, time.Now())
+	assert.ErrorIs(t, err, ErrNoHistory)
+}
@@ -0,0 +1,10 @@
+package graph
+
+import (
+	"github.com/mikeblum/atgraph.dev/graph/migrate"
+)
+
+// Migratable - engines whose schema is managed by versioned migrations
+type Migratable interface {
+	Migrator() *migrate.Migrator
+}
@@ -0,0 +1,273 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mikeblum/atgraph.dev/conf"
+)
+
+const (
+	StateApplied  = "applied"
+	StatePending  = "pending"
+	StateModified = "modified"
+	StateMissing  = "missing"
+)
+
+// migration files are named <version>_<name>.<ext> ie. 0002_add_profiles_description.sql
+var filePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(sql|cypher)$`)
+
+// Migration - a numbered, ordered list of statements
+type Migration struct {
+	Version    int
+	Name       string
+	Checksum   string
+	Statements []string
+}
+
+// Applied - a migration recorded by a Store
+type Applied struct {
+	Version  int
+	Name     string
+	Checksum string
+	Applied  time.Time
+}
+
+// Status - a migration and whether it has been applied
+type Status struct {
+	Version  int       `json:"version"`
+	Name     string    `json:"name"`
+	State    string    `json:"state"`
+	Applied  time.Time `json:"applied,omitzero"`
+	Checksum string    `json:"checksum"`
+}
+
+// Store - engine specific bookkeeping of applied migrations.
+// Engines without DDL transactions should keep statements idempotent
+// (IF NOT EXISTS) since a failed migration is retried from the start.
+type Store interface {
+	// Init - create the migrations table / constraint if missing
+	Init(ctx context.Context) error
+	// Applied - every recorded migration
+	Applied(ctx context.Context) ([]Applied, error)
+	// Apply - execute the statements then record the migration
+	Apply(ctx context.Context, migration Migration) error
+}
+
+// LoadFrom - migrations from the override directory when set,
+// otherwise dir of the embedded files bundled into the binary
+func LoadFrom(embedded fs.FS, dir, override string) ([]Migration, error) {
+	if override != "" {
+		return Load(os.DirFS(override), ".")
+	}
+	return Load(embedded, dir)
+}
+
+// Load - parse every migration file in dir ordered by version
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: error reading %s: %w", dir, err)
+	}
+	var migrations []Migration
+	versions := make(map[int]string)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := filePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migrate: invalid migration file name %s (expected <version>_<name>.sql|cypher)", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		if dup, ok := versions[version]; ok {
+			return nil, fmt.Errorf("migrate: duplicate version %d: %s and %s", version, dup, entry.Name())
+		}
+		versions[version] = entry.Name()
+		var body []byte
+		if body, err = fs.ReadFile(fsys, path.Join(dir, entry.Name())); err != nil {
+			return nil, fmt.Errorf("migrate: error reading %s: %w", entry.Name(), err)
+		}
+		statements := Split(string(body))
+		if len(statements) == 0 {
+			return nil, fmt.Errorf("migrate: %s has no statements", entry.Name())
+		}
+		sum := sha256.Sum256(body)
+		migrations = append(migrations, Migration{
+			Version:    version,
+			Name:       match[2],
+			Checksum:   hex.EncodeToString(sum[:]),
+			Statements: statements,
+		})
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return a.Version - b.Version
+	})
+	return migrations, nil
+}
+
+// Split - break a file into statements terminated by a trailing `;`,
+// dropping `--` and `//` comment lines
+func Split(body string) []string {
+	var statements []string
+	var stmt strings.Builder
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") || strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+		if stmt.Len() > 0 {
+			stmt.WriteByte('\n')
+		}
+		stmt.WriteString(strings.TrimRight(line, " \t\r"))
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(stmt.String()), ";"))
+			stmt.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(stmt.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+// Migrator - applies pending migrations in version order
+type Migrator struct {
+	engine     string
+	store      Store
+	migrations []Migration
+	log        *conf.Log
+}
+
+func NewMigrator(engine string, store Store, migrations []Migration) *Migrator {
+	return &Migrator{
+		engine:     engine,
+		store:      store,
+		migrations: migrations,
+		log:        conf.NewLog(),
+	}
+}
+
+// Engine - name of the engine being migrated
+func (m *Migrator) Engine() string {
+	return m.engine
+}
+
+// Status - every known migration plus any applied migration missing from disk
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.store.Init(ctx); err != nil {
+		return nil, fmt.Errorf("migrate %s: error initializing store: %w", m.engine, err)
+	}
+	applied, err := m.store.Applied(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("migrate %s: error reading applied migrations: %w", m.engine, err)
+	}
+	return status(m.migrations, applied), nil
+}
+
+// Pending - migrations Up would apply (dry-run)
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkModified(m.engine, statuses); err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if stateOf(statuses, migration.Version) == StatePending {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up - apply every pending migration, stopping at the first failure
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var applied []Migration
+	for _, migration := range pending {
+		start := time.Now()
+		if err = m.store.Apply(ctx, migration); err != nil {
+			return applied, fmt.Errorf("migrate %s: error applying %04d_%s: %w", m.engine, migration.Version, migration.Name, err)
+		}
+		m.log.With("engine", m.engine, "version", migration.Version, "name", migration.Name, "duration", time.Since(start)).Info("Applied migration")
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+func status(migrations []Migration, applied []Applied) []Status {
+	recorded := make(map[int]Applied, len(applied))
+	for _, a := range applied {
+		recorded[a.Version] = a
+	}
+	var statuses []Status
+	for _, migration := range migrations {
+		s := Status{
+			Version:  migration.Version,
+			Name:     migration.Name,
+			State:    StatePending,
+			Checksum: migration.Checksum,
+		}
+		if a, ok := recorded[migration.Version]; ok {
+			s.State = StateApplied
+			s.Applied = a.Applied
+			if a.Checksum != migration.Checksum {
+				s.State = StateModified
+			}
+			delete(recorded, migration.Version)
+		}
+		statuses = append(statuses, s)
+	}
+	for _, a := range recorded {
+		statuses = append(statuses, Status{
+			Version:  a.Version,
+			Name:     a.Name,
+			State:    StateMissing,
+			Applied:  a.Applied,
+			Checksum: a.Checksum,
+		})
+	}
+	slices.SortFunc(statuses, func(a, b Status) int {
+		return a.Version - b.Version
+	})
+	return statuses
+}
+
+// checkModified - refuse to migrate once an applied migration has been edited
+func checkModified(engine string, statuses []Status) error {
+	var modified []string
+	for _, s := range statuses {
+		if s.State == StateModified {
+			modified = append(modified, fmt.Sprintf("%04d_%s", s.Version, s.Name))
+		}
+	}
+	if len(modified) > 0 {
+		return fmt.Errorf("migrate %s: applied migrations have been modified: %s", engine, strings.Join(modified, ", "))
+	}
+	return nil
+}
+
+func stateOf(statuses []Status, version int) string {
+	for _, s := range statuses {
+		if s.Version == version {
+			return s.State
+		}
+	}
+	return StatePending
+}
@@ -0,0 +1,165 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type storeTest struct {
+	applied []Applied
+	ran     []string
+	fail    int
+}
+
+func (s *storeTest) Init(ctx context.Context) error {
+	return nil
+}
+
+func (s *storeTest) Applied(ctx context.Context) ([]Applied, error) {
+	return s.applied, nil
+}
+
+func (s *storeTest) Apply(ctx context.Context, migration Migration) error {
+	if migration.Version == s.fail {
+		return errors.New("syntax error")
+	}
+	s.ran = append(s.ran, migration.Statements...)
+	s.applied = append(s.applied, Applied{
+		Version:  migration.Version,
+		Name:     migration.Name,
+		Checksum: migration.Checksum,
+		Applied:  time.Now(),
+	})
+	return nil
+}
+
+func fsTest() fstest.MapFS {
+	return fstest.MapFS{
+		"migrations/0002_add_description.sql": {Data: []byte("-- evolve\nALTER TABLE profiles\n    ADD COLUMN IF NOT EXISTS description String;\n")},
+		"migrations/0001_create_profiles.sql": {Data: []byte("CREATE TABLE IF NOT EXISTS profiles\n(\n    did String\n);\nCREATE TABLE IF NOT EXISTS follows (did String);\n")},
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	t.Run("load orders by version", loadTest)
+	t.Run("invalid file name", loadInvalidTest)
+	t.Run("duplicate version", loadDuplicateTest)
+	t.Run("override directory", loadOverrideTest)
+	t.Run("split statements", splitTest)
+	t.Run("up applies pending", upTest)
+	t.Run("status", statusTest)
+	t.Run("modified migration blocks up", modifiedTest)
+	t.Run("failed migration stops up", failedTest)
+}
+
+func loadTest(t *testing.T) {
+	migrations, err := Load(fsTest(), "migrations")
+	require.Nil(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, 1, migrations[0].Version)
+	assert.Equal(t, "create_profiles", migrations[0].Name)
+	assert.Len(t, migrations[0].Statements, 2)
+	assert.Equal(t, 2, migrations[1].Version)
+	assert.NotEqual(t, migrations[0].Checksum, migrations[1].Checksum)
+}
+
+func loadInvalidTest(t *testing.T) {
+	fsys := fsTest()
+	fsys["migrations/create.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	_, err := Load(fsys, "migrations")
+	assert.Error(t, err)
+}
+
+func loadDuplicateTest(t *testing.T) {
+	fsys := fsTest()
+	fsys["migrations/02_other.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	_, err := Load(fsys, "migrations")
+	assert.Error(t, err)
+}
+
+func loadOverrideTest(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "0001_custom.sql"), []byte("CREATE TABLE custom (id String);"), 0o600))
+	migrations, err := LoadFrom(fsTest(), "migrations", dir)
+	require.Nil(t, err)
+	require.Len(t, migrations, 1)
+	assert.Equal(t, "custom", migrations[0].Name)
+	// embedded files without an override
+	migrations, err = LoadFrom(fsTest(), "migrations", "")
+	require.Nil(t, err)
+	assert.Len(t, migrations, 2)
+}
+
+func splitTest(t *testing.T) {
+	statements := Split("// comment\nCREATE INDEX a;\n\nCREATE INDEX b\n  ON (n.b);\nRETURN 1")
+	assert.Equal(t, []string{"CREATE INDEX a", "CREATE INDEX b\n  ON (n.b)", "RETURN 1"}, statements)
+}
+
+func upTest(t *testing.T) {
+	ctx := context.Background()
+	migrations, err := Load(fsTest(), "migrations")
+	require.Nil(t, err)
+	store := &storeTest{}
+	migrator := NewMigrator("test", store, migrations)
+
+	pending, err := migrator.Pending(ctx)
+	require.Nil(t, err)
+	assert.Len(t, pending, 2)
+	assert.Empty(t, store.ran, "dry-run must not apply")
+
+	applied, err := migrator.Up(ctx)
+	require.Nil(t, err)
+	assert.Len(t, applied, 2)
+	assert.Len(t, store.ran, 3)
+
+	// re-running is a no-op
+	applied, err = migrator.Up(ctx)
+	require.Nil(t, err)
+	assert.Empty(t, applied)
+}
+
+func statusTest(t *testing.T) {
+	ctx := context.Background()
+	migrations, err := Load(fsTest(), "migrations")
+	require.Nil(t, err)
+	store := &storeTest{applied: []Applied{
+		{Version: 1, Name: "create_profiles", Checksum: migrations[0].Checksum},
+		{Version: 7, Name: "removed"},
+	}}
+	statuses, err := NewMigrator("test", store, migrations).Status(ctx)
+	require.Nil(t, err)
+	require.Len(t, statuses, 3)
+	assert.Equal(t, StateApplied, statuses[0].State)
+	assert.Equal(t, StatePending, statuses[1].State)
+	assert.Equal(t, StateMissing, statuses[2].State)
+}
+
+func modifiedTest(t *testing.T) {
+	ctx := context.Background()
+	migrations, err := Load(fsTest(), "migrations")
+	require.Nil(t, err)
+	store := &storeTest{applied: []Applied{
+		{Version: 1, Name: "create_profiles", Checksum: "edited"},
+	}}
+	_, err = NewMigrator("test", store, migrations).Up(ctx)
+	assert.ErrorContains(t, err, "0001_create_profiles")
+	assert.Empty(t, store.ran)
+}
+
+func failedTest(t *testing.T) {
+	ctx := context.Background()
+	migrations, err := Load(fsTest(), "migrations")
+	require.Nil(t, err)
+	store := &storeTest{fail: 2}
+	applied, err := NewMigrator("test", store, migrations).Up(ctx)
+	assert.Error(t, err)
+	assert.Len(t, applied, 1)
+}
@@ -0,0 +1,119 @@
+package neo4j
+
+import (
+	"context"
+
+	"github.com/mikeblum/atgraph.dev/graph"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+const (
+	APP_ANALYZE = "atgraph.dev:analyze"
+
+	matchFollowEdges = `
+		MATCH (a:Profile)-[r:FOLLOWS]->(b:Profile)
+		WHERE r.removed IS NULL
+		RETURN a.id AS did, b.id AS subject;
+		`
+
+	// MATCH rather than MERGE: scores only apply to profiles already in the graph
+	setScores = `
+		UNWIND $rows AS row
+		MATCH (p:Profile {id: row.id})
+		SET
+			p.pagerank		= row.pagerank,
+			p.community		= row.community,
+			p.scored		= row.computed;
+		`
+
+	// replace the previous run's [:MAY_KNOW] edges for each profile: stale
+	// edges are deleted before the UNWIND so rows without candidates clear them
+	mergeRecommendations = `
+		UNWIND $rows AS row
+		MATCH (a:Profile {id: row.id})
+		OPTIONAL MATCH (a)-[stale:MAY_KNOW]->()
+		DELETE stale
+		WITH DISTINCT a, row
+		UNWIND row.candidates AS candidate
+		MATCH (b:Profile {id: candidate.id})
+		MERGE (a)-[r:MAY_KNOW]->(b)
+		SET
+			r.rank			= candidate.rank,
+			r.common		= candidate.common,
+			r.computed		= row.computed;
+		`
+)
+
+// ScanFollows - stream every [:FOLLOWS] edge in an auto-commit query so
+// large snapshots aren't bound by the transaction timeout
+func (e *Engine) ScanFollows(ctx context.Context, fn func(did, subject string) error) error {
+	return e.stream(ctx, APP_ANALYZE, matchFollowEdges, nil, func(row readRow) error {
+		return fn(row.str("did"), row.str("subject"))
+	})
+}
+
+// stream - run query as an auto-commit read, handing each record to fn as
+// it arrives rather than collecting the result
+func (e *Engine) stream(ctx context.Context, app, query string, params map[string]any, fn func(readRow) error) error {
+	session := e.driver.NewSession(ctx, neo4j.SessionConfig{
+		AccessMode:   neo4j.AccessModeRead,
+		DatabaseName: e.session.DatabaseName,
+		BoltLogger:   e.session.BoltLogger,
+	})
+	defer session.Close(ctx)
+	result, err := session.Run(ctx, query, params, neo4j.WithTxMetadata(map[string]any{"app": app}))
+	if err != nil {
+		return err
+	}
+	for result.Next(ctx) {
+		if err = fn(result.Record().AsMap()); err != nil {
+			return err
+		}
+	}
+	return result.Err()
+}
+
+// WriteScores - batch pagerank and community onto :Profile nodes
+func (e *Engine) WriteScores(ctx context.Context, scores []graph.Score) error {
+	for _, score := range scores {
+		if err := e.scores.Append(ctx, map[string]any{
+			"id":        score.DID,
+			"pagerank":  score.PageRank,
+			"community": score.Community,
+			// neo4j (java) expects epoch time in milliseconds
+			"computed": score.Computed.UnixMilli(),
+		}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteRecommendations - batch (:Profile)-[:MAY_KNOW]->(:Profile) edges
+func (e *Engine) WriteRecommendations(ctx context.Context, recommendations []graph.Recommendations) error {
+	for _, r := range recommendations {
+		candidates := make([]map[string]any, 0, len(r.Candidates))
+		for i, candidate := range r.Candidates {
+			candidates = append(candidates, map[string]any{
+				"id":     candidate.DID,
+				"rank":   i + 1,
+				"common": candidate.Common,
+			})
+		}
+		if err := e.recs.Append(ctx, map[string]any{
+			"id":         r.DID,
+			"candidates": candidates,
+			// neo4j (java) expects epoch time in milliseconds
+			"computed": r.Computed.UnixMilli(),
+		}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate graph.FollowScanner and graph.ScoreWriter interfaces are implemented
+var (
+	_ graph.FollowScanner = &Engine{}
+	_ graph.ScoreWriter   = &Engine{}
+)
@@ -0,0 +1,228 @@
+package neo4j
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mikeblum/atgraph.dev/conf"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	FlushReasonSize     = "size"
+	FlushReasonInterval = "interval"
+	FlushReasonClose    = "close"
+)
+
+// batchWriter - runs query with $rows bound to a single write transaction
+type batchWriter func(ctx context.Context, query string, rows []map[string]any) error
+
+// Batcher - collects rows per label / relationship type and writes each
+// batch with a single `UNWIND $rows AS row MERGE ...` transaction
+type Batcher struct {
+	write      batchWriter
+	log        *conf.Log
+	metrics    *BatchMetrics
+	size       int
+	interval   time.Duration
+	maxRetries int
+	mu         sync.Mutex
+	batches    []*Batch
+	stop       chan struct{}
+	stopped    sync.WaitGroup
+	closeOnce  sync.Once
+}
+
+func NewBatcher(ctx context.Context, write batchWriter, cfg *Conf) (*Batcher, error) {
+	metrics, err := NewBatchMetrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Batcher{
+		write:      write,
+		log:        conf.NewLog(),
+		metrics:    metrics,
+		size:       cfg.batchSize(),
+		interval:   cfg.batchInterval(),
+		maxRetries: cfg.batchMaxRetries(),
+		stop:       make(chan struct{}),
+	}, nil
+}
+
+// Start - flush partially filled batches on an interval until Close
+func (b *Batcher) Start(ctx context.Context) *Batcher {
+	b.stopped.Add(1)
+	go func() {
+		defer b.stopped.Done()
+		ticker := time.NewTicker(b.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-b.stop:
+				return
+			case <-ticker.C:
+				if err := b.Flush(ctx, FlushReasonInterval); err != nil {
+					b.log.WithErrorMsg(err, "Error flushing batches", "engine", "neo4j", "reason", FlushReasonInterval)
+				}
+			}
+		}
+	}()
+	return b
+}
+
+// Batch - register a label / relationship type and the UNWIND query that writes it
+func (b *Batcher) Batch(name, query string) *Batch {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	batch := &Batch{
+		batcher: b,
+		name:    name,
+		query:   query,
+	}
+	b.batches = append(b.batches, batch)
+	return batch
+}
+
+// Flush - write every pending batch
+func (b *Batcher) Flush(ctx context.Context, reason string) error {
+	b.mu.Lock()
+	batches := append([]*Batch(nil), b.batches...)
+	b.mu.Unlock()
+	var err error
+	for _, batch := range batches {
+		err = errors.Join(err, batch.Flush(ctx, reason))
+	}
+	return err
+}
+
+// Close - stop the interval flush and drain any pending rows
+func (b *Batcher) Close(ctx context.Context) error {
+	var err error
+	b.closeOnce.Do(func() {
+		close(b.stop)
+		b.stopped.Wait()
+		err = b.Flush(ctx, FlushReasonClose)
+	})
+	return err
+}
+
+// Batch - pending rows for a single label / relationship type
+type Batch struct {
+	batcher *Batcher
+	name    string
+	query   string
+	mu      sync.Mutex
+	rows    []map[string]any
+	acks    []func(error)
+}
+
+// Append - queue a row, writing the batch on the caller's goroutine once full.
+//
+// ack, if set, is called with the outcome of the write holding the row,
+// whichever caller or interval triggers it, and that write's error is not
+// returned here. Without an ack the caller only learns about the write it
+// triggered itself.
+func (b *Batch) Append(ctx context.Context, row map[string]any, ack func(error)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rows = append(b.rows, row)
+	if ack != nil {
+		b.acks = append(b.acks, ack)
+	}
+	var err error
+	if len(b.rows) >= b.batcher.size {
+		err = b.flush(ctx, FlushReasonSize)
+	}
+	if ack != nil {
+		return nil
+	}
+	return err
+}
+
+func (b *Batch) Flush(ctx context.Context, reason string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.flush(ctx, reason)
+}
+
+// Len - number of rows currently pending
+func (b *Batch) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.rows)
+}
+
+// flush - write the pending rows, retrying transient driver errors with
+// backoff. The rows are cleared either way: a batch that still fails is
+// dropped and the acks of its rows are settled with the error so the repos
+// they belong to fail rather than being marked synced, and are re-ingested
+// by the next sync. Keeping failed rows around would grow the batch without
+// bound through an outage and write rows whose repos were already failed.
+func (b *Batch) flush(ctx context.Context, reason string) error {
+	rows := len(b.rows)
+	if rows == 0 {
+		return nil
+	}
+	batcher := b.batcher
+	start := time.Now()
+
+	var err error
+retry:
+	for attempt := 0; ; attempt++ {
+		if err = batcher.write(ctx, b.query, b.rows); err == nil || attempt >= batcher.maxRetries || !transient(err) {
+			break
+		}
+		batcher.log.With("error", err, "engine", "neo4j", "batch", b.name, "rows", rows, "attempt", attempt+1).Warn("Error writing batch")
+		// 2^n backoff starting at 100ms
+		select {
+		case <-ctx.Done():
+			err = errors.Join(err, ctx.Err())
+			break retry
+		case <-time.After(100 * time.Millisecond * time.Duration(1<<uint(attempt))):
+		}
+	}
+
+	status := "ok"
+	if err != nil {
+		status = "err"
+		err = fmt.Errorf("write %s: dropped %d rows: %w", b.name, rows, err)
+		batcher.log.WithErrorMsg(err, "Error writing batch", "engine", "neo4j", "batch", b.name, "reason", reason)
+	}
+	attrs := metric.WithAttributes(
+		attribute.String("batch", b.name),
+		attribute.String("reason", reason),
+		attribute.String("status", status),
+	)
+	batcher.metrics.flushes.Add(ctx, 1, attrs)
+	batcher.metrics.size.Record(ctx, int64(rows), attrs)
+	batcher.metrics.latency.Record(ctx, time.Since(start).Seconds(), attrs)
+
+	for _, ack := range b.acks {
+		ack(err)
+	}
+	b.acks = nil
+	b.rows = nil
+	return err
+}
+
+// transient - a driver error worth retrying, including one the driver already
+// retried until NEO4J_MAX_TRANSACTION_RETRY_TIME ran out
+func transient(err error) bool {
+	var limit *neo4j.TransactionExecutionLimit
+	if errors.As(err, &limit) {
+		for _, err := range limit.Errors {
+			if neo4j.IsRetryable(err) {
+				return true
+			}
+		}
+		return false
+	}
+	return neo4j.IsRetryable(err)
+}
@@ -0,0 +1,61 @@
+package neo4j
+
+import (
+	"context"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type BatchMetrics struct {
+	flushes metric.Int64Counter
+	size    metric.Int64Histogram
+	latency metric.Float64Histogram
+}
+
+func NewBatchMetrics(ctx context.Context) (*BatchMetrics, error) {
+	buildInfo, ok := debug.ReadBuildInfo()
+	version := "unknown"
+	if ok {
+		version = buildInfo.Main.Version
+	}
+
+	meter := otel.GetMeterProvider().Meter(
+		"neo4j.batch",
+		metric.WithInstrumentationVersion(version),
+	)
+
+	flushes, err := meter.Int64Counter(
+		"neo4j.batch.flushes",
+		metric.WithDescription("UNWIND batch writes by label / relationship, reason and status"),
+		metric.WithUnit("{flushes}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := meter.Int64Histogram(
+		"neo4j.batch.size",
+		metric.WithDescription("Rows per UNWIND batch"),
+		metric.WithUnit("{rows}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	latency, err := meter.Float64Histogram(
+		"neo4j.batch.latency",
+		metric.WithDescription("UNWIND batch write transaction latency"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BatchMetrics{
+		flushes: flushes,
+		size:    size,
+		latency: latency,
+	}, nil
+}
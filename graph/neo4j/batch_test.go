@@ -0,0 +1,159 @@
+package neo4j
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatcher(t *testing.T) {
+	t.Run("write batch once full", batchSizeTest)
+	t.Run("write batch on interval", batchIntervalTest)
+	t.Run("write pending batches on close", batchCloseTest)
+	t.Run("failed batch is reported to the caller", batchErrorTest)
+	t.Run("transient errors are retried", batchRetryTest)
+	t.Run("failed batch is dropped with its acks", batchDropTest)
+	t.Run("rows are acked once written", batchAckTest)
+}
+
+type writerTest struct {
+	mu       sync.Mutex
+	err      error
+	failures int
+	queries  []string
+	sizes    []int
+	rows     []map[string]any
+}
+
+func (w *writerTest) write(ctx context.Context, query string, rows []map[string]any) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.failures > 0 {
+		w.failures--
+		return w.err
+	}
+	w.queries = append(w.queries, query)
+	w.sizes = append(w.sizes, len(rows))
+	w.rows = append(w.rows, rows...)
+	return nil
+}
+
+func (w *writerTest) written() []int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]int(nil), w.sizes...)
+}
+
+func batcherTest(t *testing.T, writer *writerTest) *Batcher {
+	batcher, err := NewBatcher(context.TODO(), writer.write, confTest(t))
+	require.Nil(t, err)
+	batcher.size = 2
+	batcher.interval = time.Hour
+	batcher.maxRetries = 2
+	return batcher
+}
+
+func batchSizeTest(t *testing.T) {
+	writer := &writerTest{}
+	batch := batcherTest(t, writer).Batch("Profile", mergeProfiles)
+	for _, id := range []string{"a", "b", "c"} {
+		require.Nil(t, batch.Append(context.TODO(), map[string]any{"id": id}, nil))
+	}
+	assert.Equal(t, []int{2}, writer.written())
+	assert.Equal(t, mergeProfiles, writer.queries[0])
+	assert.Equal(t, 1, batch.Len())
+}
+
+func batchIntervalTest(t *testing.T) {
+	writer := &writerTest{}
+	batcher := batcherTest(t, writer)
+	batcher.interval = 10 * time.Millisecond
+	batch := batcher.Batch("FOLLOWS", mergeFollows)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	batcher.Start(ctx)
+	require.Nil(t, batch.Append(ctx, map[string]any{"id_a": "a", "id_b": "b"}, nil))
+	assert.Eventually(t, func() bool {
+		return len(writer.written()) == 1
+	}, time.Second, 5*time.Millisecond)
+	require.Nil(t, batcher.Close(context.TODO()))
+}
+
+func batchCloseTest(t *testing.T) {
+	writer := &writerTest{}
+	batcher := batcherTest(t, writer).Start(context.TODO())
+	profiles := batcher.Batch("Profile", mergeProfiles)
+	follows := batcher.Batch("FOLLOWS", mergeFollows)
+	require.Nil(t, profiles.Append(context.TODO(), map[string]any{"id": "a"}, nil))
+	require.Nil(t, follows.Append(context.TODO(), map[string]any{"id_a": "a", "id_b": "b"}, nil))
+	require.Nil(t, batcher.Close(context.TODO()))
+	assert.Equal(t, []int{1, 1}, writer.written())
+}
+
+func batchErrorTest(t *testing.T) {
+	writer := &writerTest{err: errors.New("invalid query"), failures: 1}
+	batch := batcherTest(t, writer).Batch("Profile", mergeProfiles)
+	require.Nil(t, batch.Append(context.TODO(), map[string]any{"id": "a"}, nil))
+	err := batch.Append(context.TODO(), map[string]any{"id": "b"}, nil)
+	require.ErrorContains(t, err, "dropped 2 rows")
+	// not transient - retrying won't help
+	assert.Equal(t, 0, writer.failures)
+	assert.Equal(t, 0, batch.Len())
+}
+
+// transientErrTest - a connection failure the driver reports as retryable
+func transientErrTest() error {
+	return &neo4j.ConnectivityError{Inner: errors.New("connection reset")}
+}
+
+func batchRetryTest(t *testing.T) {
+	writer := &writerTest{err: transientErrTest(), failures: 2}
+	batch := batcherTest(t, writer).Batch("Profile", mergeProfiles)
+	require.Nil(t, batch.Append(context.TODO(), map[string]any{"id": "a"}, nil))
+	require.Nil(t, batch.Append(context.TODO(), map[string]any{"id": "b"}, nil))
+	assert.Equal(t, []int{2}, writer.written())
+	assert.Equal(t, 0, batch.Len())
+}
+
+func batchDropTest(t *testing.T) {
+	writer := &writerTest{err: transientErrTest(), failures: 3}
+	batcher := batcherTest(t, writer)
+	batch := batcher.Batch("Profile", mergeProfiles)
+	var acked []error
+	ack := func(err error) {
+		acked = append(acked, err)
+	}
+	require.Nil(t, batch.Append(context.TODO(), map[string]any{"id": "a"}, ack))
+	// the write error reaches the ack rather than the caller
+	require.Nil(t, batch.Append(context.TODO(), map[string]any{"id": "b"}, ack))
+	require.Len(t, acked, 2)
+	for _, err := range acked {
+		assert.ErrorContains(t, err, "dropped 2 rows")
+	}
+	// failed rows aren't kept around to be written without their acks
+	assert.Equal(t, 0, batch.Len())
+	require.Nil(t, batch.Append(context.TODO(), map[string]any{"id": "c"}, ack))
+	require.Nil(t, batcher.Flush(context.TODO(), FlushReasonInterval))
+	assert.Equal(t, []int{1}, writer.written())
+	assert.Nil(t, acked[2])
+}
+
+func batchAckTest(t *testing.T) {
+	writer := &writerTest{}
+	batcher := batcherTest(t, writer)
+	batch := batcher.Batch("FOLLOWS", mergeFollows)
+	var acked []error
+	require.Nil(t, batch.Append(context.TODO(), map[string]any{"id_a": "a", "id_b": "b"}, func(err error) {
+		acked = append(acked, err)
+	}))
+	// pending rows aren't acknowledged until written
+	assert.Empty(t, acked)
+	require.Nil(t, batcher.Close(context.TODO()))
+	assert.Equal(t, []error{nil}, acked)
+}
@@ -1,32 +1,166 @@
 package neo4j
 
 import (
+	"crypto/tls"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/mikeblum/atgraph.dev/conf"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/config"
 )
 
 type Conf struct {
 	conf.EnvConf
+	cfg *Config
 }
 
-func NewConf() *Conf {
-	return &Conf{conf.NewEnvConf()}
+// NewConf - settings decoded once, invalid ones are left at their defaults
+// and reported in the returned error alongside the still usable Conf
+func NewConf() (*Conf, error) {
+	c := &Conf{EnvConf: conf.NewEnvConf()}
+	var err error
+	c.cfg, err = c.decode()
+	return c, err
+}
+
+// Config - typed Neo4j settings, Auth defaults to basic when a password is set, otherwise none
+type Config struct {
+	URI                          string        `env:"NEO4J_URI"`
+	Database                     string        `env:"NEO4J_DATABASE"`
+	Timeout                      time.Duration `env:"NEO4J_TIMEOUT" min:"0s"`
+	BatchSize                    int           `env:"NEO4J_BATCH_SIZE" min:"1"`
+	BatchInterval                time.Duration `env:"NEO4J_BATCH_INTERVAL" min:"1ns"`
+	BatchMaxRetries              int           `env:"NEO4J_BATCH_MAX_RETRIES" min:"0"`
+	Auth                         string        `env:"NEO4J_AUTH" oneof:"none basic bearer kerberos"`
+	Username                     string        `env:"NEO4J_USERNAME"`
+	Password                     string        `env:"NEO4J_PASSWORD" secret:"true"`
+	Realm                        string        `env:"NEO4J_REALM"`
+	BearerToken                  string        `env:"NEO4J_BEARER_TOKEN" secret:"true"`
+	KerberosTicket               string        `env:"NEO4J_KERBEROS_TICKET" secret:"true"`
+	ConnectionPoolSize           int           `env:"NEO4J_CONNECTION_POOL_SIZE" min:"1"`
+	ConnectionAcquisitionTimeout time.Duration `env:"NEO4J_CONNECTION_ACQUISITION_TIMEOUT"`
+	MaxTransactionRetryTime      time.Duration `env:"NEO4J_MAX_TRANSACTION_RETRY_TIME" min:"0s"`
+	TLSCAFile                    string        `env:"NEO4J_TLS_CA_FILE"`
+	TLSCertFile                  string        `env:"NEO4J_TLS_CERT_FILE"`
+	TLSKeyFile                   string        `env:"NEO4J_TLS_KEY_FILE"`
+	MigrationsDir                string        `env:"NEO4J_MIGRATIONS_DIR"`
+}
+
+// decode - defaults overridden by whatever conf.Decode can parse
+func (c *Conf) decode() (*Config, error) {
+	cfg := &Config{
+		URI:                          NEO4J_URI,
+		Database:                     NEO4J_DATABASE,
+		Timeout:                      NEO4J_TIMEOUT,
+		BatchSize:                    NEO4J_BATCH_SIZE,
+		BatchInterval:                NEO4J_BATCH_INTERVAL,
+		BatchMaxRetries:              NEO4J_BATCH_MAX_RETRIES,
+		Username:                     NEO4J_USERNAME,
+		ConnectionPoolSize:           NEO4J_CONNECTION_POOL_SIZE,
+		ConnectionAcquisitionTimeout: NEO4J_CONNECTION_ACQUISITION_TIMEOUT,
+		MaxTransactionRetryTime:      NEO4J_MAX_TRANSACTION_RETRY_TIME,
+	}
+	err := conf.Decode(c.EnvConf, cfg)
+	if cfg.Auth == "" {
+		cfg.Auth = NEO4J_AUTH_NONE
+		if cfg.Password != "" {
+			cfg.Auth = NEO4J_AUTH_BASIC
+		}
+	}
+	return cfg, errors.Join(err, cfg.validate())
+}
+
+// Config - the settings decoded by NewConf
+func (c *Conf) Config() *Config {
+	return c.cfg
+}
+
+// validate - the credentials the auth scheme requires
+func (cfg *Config) validate() error {
+	switch cfg.Auth {
+	case NEO4J_AUTH_BEARER:
+		if cfg.BearerToken == "" {
+			return fmt.Errorf("%s=%s requires %s", ENV_NEO4J_AUTH, cfg.Auth, ENV_NEO4J_BEARER_TOKEN)
+		}
+	case NEO4J_AUTH_KERBEROS:
+		if cfg.KerberosTicket == "" {
+			return fmt.Errorf("%s=%s requires %s", ENV_NEO4J_AUTH, cfg.Auth, ENV_NEO4J_KERBEROS_TICKET)
+		}
+	}
+	return nil
 }
 
 func (c *Conf) uri() string {
-	return c.GetEnv(ENV_NEO4J_URI, NEO4J_URI)
+	return c.cfg.URI
 }
 
 func (c *Conf) database() string {
-	return c.GetEnv(ENV_NEO4J_DATABASE, NEO4J_DATABASE)
+	return c.cfg.Database
 }
 
 func (c *Conf) timeout() time.Duration {
-	var timeout time.Duration
-	var err error
-	if timeout, err = time.ParseDuration(c.GetEnv(ENV_NEO4J_TIMEOUT, NEO4J_TIMEOUT.String())); err != nil {
-		return NEO4J_TIMEOUT
+	return c.cfg.Timeout
+}
+
+// batchSize - rows per UNWIND write transaction
+func (c *Conf) batchSize() int {
+	return c.cfg.BatchSize
+}
+
+// batchInterval - flush partially filled batches at least this often
+func (c *Conf) batchInterval() time.Duration {
+	return c.cfg.BatchInterval
+}
+
+// batchMaxRetries - retries of a batch failing with a transient driver error
+func (c *Conf) batchMaxRetries() int {
+	return c.cfg.BatchMaxRetries
+}
+
+// auth - token for the scheme validated by NewConf
+func (c *Conf) auth() neo4j.AuthToken {
+	cfg := c.cfg
+	switch cfg.Auth {
+	case NEO4J_AUTH_BASIC:
+		return neo4j.BasicAuth(cfg.Username, cfg.Password, cfg.Realm)
+	case NEO4J_AUTH_BEARER:
+		return neo4j.BearerAuth(cfg.BearerToken)
+	case NEO4J_AUTH_KERBEROS:
+		return neo4j.KerberosAuth(cfg.KerberosTicket)
 	}
-	return timeout
+	return neo4j.NoAuth()
+}
+
+// driverConfig - pool, timeout and TLS settings applied to the driver
+// TLS only takes effect for neo4j+s:// and bolt+s:// URIs, the driver always
+// verifies the server name against the NEO4J_URI host
+func (c *Conf) driverConfig() (func(*config.Config), error) {
+	settings := c.cfg
+	tlsOpts := conf.TLSOptions{
+		CAFile:   settings.TLSCAFile,
+		CertFile: settings.TLSCertFile,
+		KeyFile:  settings.TLSKeyFile,
+	}
+	var tlsConfig *tls.Config
+	if tlsOpts.CAFile != "" || tlsOpts.CertFile != "" {
+		var err error
+		if tlsConfig, err = tlsOpts.Config(); err != nil {
+			return nil, err
+		}
+	}
+	return func(cfg *config.Config) {
+		cfg.MaxConnectionPoolSize = settings.ConnectionPoolSize
+		cfg.ConnectionAcquisitionTimeout = settings.ConnectionAcquisitionTimeout
+		cfg.MaxTransactionRetryTime = settings.MaxTransactionRetryTime
+		if tlsConfig != nil {
+			cfg.TlsConfig = tlsConfig
+		}
+	}, nil
+}
+
+// migrationsDir - override the embedded migrations with a directory on disk
+func (c *Conf) migrationsDir() string {
+	return c.cfg.MigrationsDir
 }
@@ -0,0 +1,96 @@
+package neo4j
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConf(t *testing.T) {
+	t.Run("no auth by default", confNoAuthTest)
+	t.Run("basic auth when password set", confBasicAuthTest)
+	t.Run("bearer auth", confBearerAuthTest)
+	t.Run("kerberos requires ticket", confKerberosAuthTest)
+	t.Run("unsupported auth scheme", confUnsupportedAuthTest)
+	t.Run("driver config", confDriverConfigTest)
+	t.Run("missing CA file", confTLSErrorTest)
+}
+
+// confTest - Conf decoded from the current env without errors
+func confTest(t *testing.T) *Conf {
+	cfg, err := NewConf()
+	require.Nil(t, err)
+	return cfg
+}
+
+func confNoAuthTest(t *testing.T) {
+	t.Setenv(ENV_NEO4J_PASSWORD, "")
+	auth := confTest(t).auth()
+	assert.Equal(t, "none", auth.Tokens["scheme"])
+}
+
+func confBasicAuthTest(t *testing.T) {
+	t.Setenv(ENV_NEO4J_PASSWORD, "secret")
+	auth := confTest(t).auth()
+	assert.Equal(t, "basic", auth.Tokens["scheme"])
+	assert.Equal(t, NEO4J_USERNAME, auth.Tokens["principal"])
+	assert.Equal(t, "secret", auth.Tokens["credentials"])
+}
+
+func confBearerAuthTest(t *testing.T) {
+	t.Setenv(ENV_NEO4J_AUTH, "Bearer")
+	_, err := NewConf()
+	assert.ErrorContains(t, err, ENV_NEO4J_BEARER_TOKEN)
+	t.Setenv(ENV_NEO4J_BEARER_TOKEN, "token")
+	auth := confTest(t).auth()
+	assert.Equal(t, "bearer", auth.Tokens["scheme"])
+	assert.Equal(t, "token", auth.Tokens["credentials"])
+}
+
+func confKerberosAuthTest(t *testing.T) {
+	t.Setenv(ENV_NEO4J_AUTH, NEO4J_AUTH_KERBEROS)
+	_, err := NewConf()
+	assert.ErrorContains(t, err, ENV_NEO4J_KERBEROS_TICKET)
+	t.Setenv(ENV_NEO4J_KERBEROS_TICKET, "ticket")
+	auth := confTest(t).auth()
+	assert.Equal(t, "kerberos", auth.Tokens["scheme"])
+}
+
+func confUnsupportedAuthTest(t *testing.T) {
+	t.Setenv(ENV_NEO4J_AUTH, "ldap")
+	cfg, err := NewConf()
+	assert.ErrorContains(t, err, ENV_NEO4J_AUTH)
+	// the invalid scheme is left at its default
+	assert.Equal(t, "none", cfg.auth().Tokens["scheme"])
+}
+
+func confDriverConfigTest(t *testing.T) {
+	t.Setenv(ENV_NEO4J_CONNECTION_POOL_SIZE, "25")
+	t.Setenv(ENV_NEO4J_CONNECTION_ACQUISITION_TIMEOUT, "15s")
+	t.Setenv(ENV_NEO4J_MAX_TRANSACTION_RETRY_TIME, "invalid")
+	settings, err := NewConf()
+	assert.ErrorContains(t, err, ENV_NEO4J_MAX_TRANSACTION_RETRY_TIME)
+	configure, err := settings.driverConfig()
+	require.Nil(t, err)
+	var cfg config.Config
+	configure(&cfg)
+	assert.Equal(t, 25, cfg.MaxConnectionPoolSize)
+	assert.Equal(t, 15*time.Second, cfg.ConnectionAcquisitionTimeout)
+	assert.Equal(t, NEO4J_MAX_TRANSACTION_RETRY_TIME, cfg.MaxTransactionRetryTime)
+	// no TLS settings - the driver's defaults apply
+	assert.Nil(t, cfg.TlsConfig)
+}
+
+func confTLSErrorTest(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "ca.pem")
+	_, err := os.Stat(missing)
+	require.True(t, os.IsNotExist(err))
+	t.Setenv(ENV_NEO4J_TLS_CA_FILE, missing)
+	_, err = confTest(t).driverConfig()
+	assert.Error(t, err)
+}
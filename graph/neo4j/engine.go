@@ -2,34 +2,58 @@ package neo4j
 
 import (
 	"context"
+	"errors"
 
 	"github.com/mikeblum/atgraph.dev/conf"
 	"github.com/mikeblum/atgraph.dev/graph"
+	"github.com/mikeblum/atgraph.dev/graph/migrate"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/config"
 )
 
 type Engine struct {
-	conf    *Conf
-	driver  neo4j.DriverWithContext
-	session neo4j.SessionConfig
-	log     *conf.Log
+	conf     *Conf
+	driver   neo4j.DriverWithContext
+	session  neo4j.SessionConfig
+	log      *conf.Log
+	batcher  *Batcher
+	migrator *migrate.Migrator
+	profiles *Batch
+	follows  *Batch
+	blocks   *Batch
+	syncs    *Batch
+	scores   *Batch
+	recs     *Batch
+}
+
+// ENGINE - name used to select this engine via ATGRAPH_ENGINES
+const ENGINE = "neo4j"
+
+func init() {
+	graph.Register(ENGINE, NewEngine)
 }
 
 func NewEngine(ctx context.Context) (graph.Engine, error) {
 	var driver neo4j.DriverWithContext
-	var err error
-
-	cfg := NewConf()
 
+	cfg, err := NewConf()
+	if err != nil {
+		return nil, err
+	}
+	var configure func(*config.Config)
+	if configure, err = cfg.driverConfig(); err != nil {
+		return nil, err
+	}
 	if driver, err = neo4j.NewDriverWithContext(
 		cfg.uri(),
-		neo4j.NoAuth(),
+		cfg.auth(),
+		configure,
 	); err != nil {
 		return nil, err
 	}
 	log := conf.NewLog()
 
-	return &Engine{
+	engine := &Engine{
 		conf:   cfg,
 		driver: driver,
 		session: neo4j.SessionConfig{
@@ -37,17 +61,85 @@ func NewEngine(ctx context.Context) (graph.Engine, error) {
 			BoltLogger:   neo4jLogBridge(log),
 		},
 		log: log,
-	}, driver.VerifyConnectivity(ctx)
+	}
+	if err = driver.VerifyConnectivity(ctx); err != nil {
+		driver.Close(ctx)
+		return nil, err
+	}
+	if engine.migrator, err = newMigrator(driver, cfg); err != nil {
+		driver.Close(ctx)
+		return nil, err
+	}
+	if engine.batcher, err = NewBatcher(ctx, engine.writeBatch, cfg); err != nil {
+		driver.Close(ctx)
+		return nil, err
+	}
+	engine.profiles = engine.batcher.Batch("Profile", mergeProfiles)
+	engine.follows = engine.batcher.Batch("FOLLOWS", mergeFollows)
+	engine.blocks = engine.batcher.Batch("BLOCKS", mergeBlocks)
+	engine.syncs = engine.batcher.Batch("RepoSync", markRemoved)
+	engine.scores = engine.batcher.Batch("Score", setScores)
+	engine.recs = engine.batcher.Batch("MAY_KNOW", mergeRecommendations)
+	engine.batcher.Start(ctx)
+
+	return engine, nil
 }
 
+// writeBatch - run an UNWIND query over rows in a single write transaction
+func (e *Engine) writeBatch(ctx context.Context, query string, rows []map[string]any) error {
+	session := e.driver.NewSession(ctx, e.session)
+	defer session.Close(ctx)
+	_, err := session.ExecuteWrite(ctx,
+		func(tx neo4j.ManagedTransaction) (any, error) {
+			result, err := tx.Run(ctx, query, map[string]any{"rows": rows})
+			if err != nil {
+				return nil, err
+			}
+			return result.Consume(ctx)
+		},
+		neo4j.WithTxTimeout(e.conf.timeout()),
+		neo4j.WithTxMetadata(map[string]any{"app": APP_INGEST}))
+	return err
+}
+
+// LoadSchema - apply any pending constraint / index migrations
 func (e *Engine) LoadSchema(ctx context.Context) error {
-	// no-op since we don't load a schema beforehand
+	if _, err := e.migrator.Up(ctx); err != nil {
+		e.log.WithErrorMsg(err, "Error migrating Neo4j schema")
+		return err
+	}
 	return nil
 }
 
+// Migrator - versioned constraint / index migrations
+func (e *Engine) Migrator() *migrate.Migrator {
+	return e.migrator
+}
+
+func (e *Engine) CreateIndexes(ctx context.Context) error {
+	// no-op as migrations manage indexes
+	return nil
+}
+
+func (e *Engine) CreateConstraints(ctx context.Context) error {
+	// no-op as migrations manage constraints
+	return nil
+}
+
+// Ping - verify the driver can reach the server
+func (e *Engine) Ping(ctx context.Context) error {
+	return e.driver.VerifyConnectivity(ctx)
+}
+
+// Close - write any pending batches before closing the driver
 func (e *Engine) Close(ctx context.Context) error {
-	return e.driver.Close(ctx)
+	return errors.Join(e.batcher.Close(ctx), e.driver.Close(ctx))
 }
 
-// validate graph.Engine interface is implemented
-var _ graph.Engine = &Engine{}
+// validate graph.Engine, graph.Migratable, graph.Pinger and graph.RepoSyncer interfaces are implemented
+var (
+	_ graph.Engine     = &Engine{}
+	_ graph.Migratable = &Engine{}
+	_ graph.Pinger     = &Engine{}
+	_ graph.RepoSyncer = &Engine{}
+)
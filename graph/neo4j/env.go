@@ -3,16 +3,41 @@ package neo4j
 import "time"
 
 const (
-	ENV_NEO4J_DATABASE = "NEO4J_DATABASE"
-	ENV_NEO4J_PASSWORD = "NEO4J_PASSWORD"
-	ENV_NEO4J_TIMEOUT  = "NEO4J_TIMEOUT"
-	ENV_NEO4J_URI      = "NEO4J_URI"
-	ENV_NEO4J_USERNAME = "NEO4J_USERNAME"
+	ENV_NEO4J_AUTH                           = "NEO4J_AUTH"
+	ENV_NEO4J_BATCH_INTERVAL                 = "NEO4J_BATCH_INTERVAL"
+	ENV_NEO4J_BATCH_MAX_RETRIES              = "NEO4J_BATCH_MAX_RETRIES"
+	ENV_NEO4J_BATCH_SIZE                     = "NEO4J_BATCH_SIZE"
+	ENV_NEO4J_BEARER_TOKEN                   = "NEO4J_BEARER_TOKEN"
+	ENV_NEO4J_CONNECTION_ACQUISITION_TIMEOUT = "NEO4J_CONNECTION_ACQUISITION_TIMEOUT"
+	ENV_NEO4J_CONNECTION_POOL_SIZE           = "NEO4J_CONNECTION_POOL_SIZE"
+	ENV_NEO4J_DATABASE                       = "NEO4J_DATABASE"
+	ENV_NEO4J_KERBEROS_TICKET                = "NEO4J_KERBEROS_TICKET"
+	ENV_NEO4J_MAX_TRANSACTION_RETRY_TIME     = "NEO4J_MAX_TRANSACTION_RETRY_TIME"
+	ENV_NEO4J_MIGRATIONS_DIR                 = "NEO4J_MIGRATIONS_DIR"
+	ENV_NEO4J_PASSWORD                       = "NEO4J_PASSWORD"
+	ENV_NEO4J_REALM                          = "NEO4J_REALM"
+	ENV_NEO4J_TIMEOUT                        = "NEO4J_TIMEOUT"
+	ENV_NEO4J_TLS_CA_FILE                    = "NEO4J_TLS_CA_FILE"
+	ENV_NEO4J_TLS_CERT_FILE                  = "NEO4J_TLS_CERT_FILE"
+	ENV_NEO4J_TLS_KEY_FILE                   = "NEO4J_TLS_KEY_FILE"
+	ENV_NEO4J_URI                            = "NEO4J_URI"
+	ENV_NEO4J_USERNAME                       = "NEO4J_USERNAME"
+
+	// supported auth schemes
+	NEO4J_AUTH_NONE     = "none"
+	NEO4J_AUTH_BASIC    = "basic"
+	NEO4J_AUTH_BEARER   = "bearer"
+	NEO4J_AUTH_KERBEROS = "kerberos"
 
 	// defaults
-	NEO4J_CONNECTION_POOL_SIZE = 3
-	NEO4J_DATABASE             = "bluesky"
-	NEO4J_TIMEOUT              = time.Second * 10
-	NEO4J_URI                  = "neo4j://localhost:7687"
-	NEO4J_USERNAME             = "neo4j"
+	NEO4J_BATCH_INTERVAL                 = time.Second * 5
+	NEO4J_BATCH_MAX_RETRIES              = 3
+	NEO4J_BATCH_SIZE                     = 1000
+	NEO4J_CONNECTION_ACQUISITION_TIMEOUT = time.Minute
+	NEO4J_CONNECTION_POOL_SIZE           = 3
+	NEO4J_DATABASE                       = "bluesky"
+	NEO4J_MAX_TRANSACTION_RETRY_TIME     = time.Second * 30
+	NEO4J_TIMEOUT                        = time.Second * 10
+	NEO4J_URI                            = "neo4j://localhost:7687"
+	NEO4J_USERNAME                       = "neo4j"
 )
@@ -0,0 +1,70 @@
+package neo4j
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mikeblum/atgraph.dev/graph"
+)
+
+const (
+	APP_EXPORT = "atgraph.dev:export"
+
+	// every :Profile including those only MERGEd as the end of an edge
+	matchExportProfiles = `
+		MATCH (p:Profile)
+		WHERE $dids IS NULL OR p.id IN $dids
+		` + returnProfile + `;
+		`
+
+	// null parameters leave the bound open
+	matchExportEdges = `
+		MATCH (a:Profile)-[r:%s]->(b:Profile)
+		WHERE ($dids IS NULL OR (a.id IN $dids AND b.id IN $dids))
+			AND ($since IS NULL OR r.created >= $since)
+			AND ($until IS NULL OR r.created < $until)
+			AND (NOT $current OR r.removed IS NULL)
+		` + returnEdges + `;
+		`
+)
+
+func (e *Engine) ExportProfiles(ctx context.Context, filter graph.ExportFilter, fn func(graph.Profile) error) error {
+	return e.stream(ctx, APP_EXPORT, matchExportProfiles, exportParams(filter), func(row readRow) error {
+		return fn(toProfile(row))
+	})
+}
+
+func (e *Engine) ExportFollows(ctx context.Context, filter graph.ExportFilter, fn func(graph.Follow) error) error {
+	return e.stream(ctx, APP_EXPORT, fmt.Sprintf(matchExportEdges, "FOLLOWS"), exportParams(filter), func(row readRow) error {
+		return fn(toFollow(row))
+	})
+}
+
+func (e *Engine) ExportBlocks(ctx context.Context, filter graph.ExportFilter, fn func(graph.Block) error) error {
+	return e.stream(ctx, APP_EXPORT, fmt.Sprintf(matchExportEdges, "BLOCKS"), exportParams(filter), func(row readRow) error {
+		return fn(toBlock(row))
+	})
+}
+
+// exportParams - neo4j (java) expects epoch time in milliseconds
+func exportParams(filter graph.ExportFilter) map[string]any {
+	params := map[string]any{
+		"dids":    nil,
+		"since":   nil,
+		"until":   nil,
+		"current": filter.Current,
+	}
+	if len(filter.DIDs) > 0 {
+		params["dids"] = filter.DIDs
+	}
+	if !filter.Since.IsZero() {
+		params["since"] = filter.Since.UnixMilli()
+	}
+	if !filter.Until.IsZero() {
+		params["until"] = filter.Until.UnixMilli()
+	}
+	return params
+}
+
+// validate graph.Exporter interface is implemented
+var _ graph.Exporter = &Engine{}
@@ -0,0 +1,74 @@
+package neo4j
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mikeblum/atgraph.dev/graph"
+)
+
+const (
+	// validity intervals: created <= $at < removed, epoch milliseconds
+	validAt = `r.created <= $at AND (r.removed IS NULL OR r.removed > $at)`
+
+	matchFollowersAsOf = `
+		MATCH (a:Profile)-[r:FOLLOWS]->(b:Profile {id: $did})
+		WHERE ` + validAt + `
+			AND (a.id > $after OR (a.id = $after AND r.rkey > $rkey))
+		` + returnEdges + `
+		ORDER BY did, rkey
+		LIMIT $limit;
+		`
+
+	matchSubgraphAsOf = `
+		MATCH (a:Profile)-[r:%s]->(b:Profile)
+		WHERE a.id IN $dids AND b.id IN $dids AND ` + validAt + `
+		` + returnEdges + `
+		ORDER BY did, rkey;
+		`
+)
+
+func (e *Engine) FollowersAsOf(ctx context.Context, did string, at time.Time, page graph.Page) (*graph.Results[graph.Follow], error) {
+	keys, err := page.Keys(2)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := e.read(ctx, matchFollowersAsOf, map[string]any{
+		"did":   did,
+		"at":    at.UnixMilli(),
+		"after": keys[0],
+		"rkey":  keys[1],
+		"limit": page.Size() + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return graph.NewResults(toFollows(rows), page, func(f graph.Follow) []string {
+		return []string{f.DID, f.RKey}
+	}), nil
+}
+
+func (e *Engine) SubgraphAsOf(ctx context.Context, dids []string, at time.Time) (*graph.Subgraph, error) {
+	params := map[string]any{
+		"dids": dids,
+		"at":   at.UnixMilli(),
+	}
+	follows, err := e.read(ctx, fmt.Sprintf(matchSubgraphAsOf, "FOLLOWS"), params)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := e.read(ctx, fmt.Sprintf(matchSubgraphAsOf, "BLOCKS"), params)
+	if err != nil {
+		return nil, err
+	}
+	return &graph.Subgraph{
+		At:      at,
+		DIDs:    dids,
+		Follows: toFollows(follows),
+		Blocks:  toBlocks(blocks),
+	}, nil
+}
+
+// validate graph.History interface is implemented
+var _ graph.History = &Engine{}
@@ -10,11 +10,121 @@ import (
 	"github.com/bluesky-social/indigo/atproto/syntax"
 	"github.com/mikeblum/atgraph.dev/bsky"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
-	"golang.org/x/sync/errgroup"
 )
 
 const (
 	APP_INGEST = "atgraph.dev:ingest"
+
+	// writes only apply when the incoming rev is newer than the stored one.
+	// rev_version is the rev TID as an integer so comparisons match the
+	// ClickHouse ReplacingMergeTree(rev_version) ordering. An unresolved
+	// handle is sent as null so it never replaces a known one.
+	mergeProfiles = `
+		UNWIND $rows AS row
+		MERGE (p:Profile {id: row.id})
+		ON CREATE
+			SET
+				// tracking ingestion lag time
+				p.ingested 	= timestamp()
+		WITH p, row
+		WHERE p.rev_version IS NULL OR row.rev_version > p.rev_version
+		SET
+			p.type			= row.type,
+			p.handle		= coalesce(row.handle, p.handle),
+			p.created		= row.created,
+			p.rev			= row.rev,
+			p.rev_version	= row.rev_version,
+			p.sig			= row.sig,
+			p.version		= row.version,
+			p.rkey			= row.rkey,
+			p.uri			= row.uri,
+			p.cid			= row.cid,
+			p.display_name	= row.display_name,
+			p.description	= row.description,
+			p.avatar_cid	= row.avatar_cid,
+			p.avatar_mime_type	= row.avatar_mime_type,
+			p.avatar_size	= row.avatar_size,
+			p.banner_cid	= row.banner_cid,
+			p.banner_mime_type	= row.banner_mime_type,
+			p.banner_size	= row.banner_size,
+			p.labels		= row.labels,
+			p.pinned_post_uri	= row.pinned_post_uri,
+			p.pinned_post_cid	= row.pinned_post_cid,
+			// tracking firehose lag time
+			p.updated		= timestamp()
+		// joinedViaStarterPack as a (:Profile)-[:JOINED_VIA]->(:StarterPack) edge
+		WITH p, row
+		WHERE row.starter_pack_uri <> ''
+		MERGE (s:StarterPack {uri: row.starter_pack_uri})
+		ON CREATE
+			SET
+				s.ingested	= timestamp()
+		SET
+			s.cid			= row.starter_pack_cid
+		MERGE (p)-[j:JOINED_VIA]->(s)
+		ON CREATE
+			SET
+				j.ingested	= timestamp();
+		`
+
+	// MERGE both ends: a follow may be flushed before its author's :Profile batch.
+	// The record URI identifies the follow so re-syncing a repo updates in place.
+	mergeFollows = `
+		UNWIND $rows AS row
+		MERGE (a:Profile {id: row.id_a})
+		MERGE (b:Profile {id: row.id_b})
+		MERGE (a)-[r:FOLLOWS {uri: row.uri}]->(b)
+		ON CREATE
+			SET
+				r.ingested	= timestamp()
+		WITH r, row
+		WHERE r.rev_version IS NULL OR row.rev_version > r.rev_version
+		SET
+			r.rkey			= row.rkey,
+			r.created		= row.created,
+			r.rev			= row.rev,
+			r.rev_version	= row.rev_version,
+			r.version		= row.version,
+			r.cid			= row.cid,` + reopenEdge
+
+	// same as mergeFollows for [:BLOCKS]
+	mergeBlocks = `
+		UNWIND $rows AS row
+		MERGE (a:Profile {id: row.id_a})
+		MERGE (b:Profile {id: row.id_b})
+		MERGE (a)-[r:BLOCKS {uri: row.uri}]->(b)
+		ON CREATE
+			SET
+				r.ingested	= timestamp()
+		WITH r, row
+		WHERE r.rev_version IS NULL OR row.rev_version > r.rev_version
+		SET
+			r.rkey			= row.rkey,
+			r.created		= row.created,
+			r.rev			= row.rev,
+			r.rev_version	= row.rev_version,
+			r.version		= row.version,
+			r.cid			= row.cid,` + reopenEdge
+
+	// an edge seen at or after the sync that removed it was flushed late:
+	// it's still in the repo. Both SET items read the pre-SET removed_rev_version.
+	reopenEdge = `
+			r.removed		= CASE WHEN row.rev_version >= coalesce(r.removed_rev_version, 0) THEN null ELSE r.removed END,
+			r.removed_rev_version	= CASE WHEN row.rev_version >= coalesce(r.removed_rev_version, 0) THEN null ELSE r.removed_rev_version END;
+		`
+
+	// every record of the repo at row.rev_version was ingested: edges last seen
+	// at an older rev were removed by then. The earliest such sync wins so a
+	// late flush can only narrow the interval.
+	markRemoved = `
+		UNWIND $rows AS row
+		MATCH (a:Profile {id: row.id})-[r:FOLLOWS|BLOCKS]->()
+		WHERE r.rev_version < row.rev_version
+			AND (r.removed_rev_version IS NULL OR row.rev_version < r.removed_rev_version)
+		SET
+			r.removed		= row.removed,
+			r.removed_rev_version	= row.rev_version;
+		`
 )
 
 func (e *Engine) Ingest(ctx context.Context, workerID int, item bsky.RepoItem) error {
@@ -91,10 +201,12 @@ func (e *Engine) ingestItem(ctx context.Context, item *bsky.RepoItem) (chan *neo
 			return records, nil
 		}
 	case bsky.ITEM_GRAPH_BLOCK:
-		if _, ok = item.Data.(*bskyItem.GraphBlock); !ok {
+		var data *bskyItem.GraphBlock
+		if data, ok = item.Data.(*bskyItem.GraphBlock); !ok {
 			e.ingestionErr(item)
 			return records, nil
 		}
+		return e.ingestBlock(ctx, item, data)
 	case bsky.ITEM_GRAPH_LIST_BLOCK:
 		if _, ok = item.Data.(*bskyItem.GraphListblock); !ok {
 			e.ingestionErr(item)
@@ -125,112 +237,131 @@ func (e *Engine) ingestionErr(item *bsky.RepoItem) error {
 
 func (e *Engine) ingestProfile(ctx context.Context, item *bsky.RepoItem, actor *bskyItem.ActorProfile) (chan *neo4j.Record, error) {
 	records := make(chan *neo4j.Record, 1)
-	session := e.driver.NewSession(ctx, e.session)
-	defer session.Close(ctx)
-	group, ctx := errgroup.WithContext(ctx)
-	group.Go(func() error {
-		_, err := session.ExecuteWrite(ctx,
-			func(tx neo4j.ManagedTransaction) (any, error) {
-				defer close(records)
-				createdTiemstamp, err := datetimeMust(item.DID, actor.CreatedAt)
-				if err != nil {
-					return nil, err
-				}
-				result, err := tx.Run(ctx, `
-					MERGE (p:Profile {id: $id})
-					ON CREATE
-						SET
-							p.type		= $type,
-							// tracking ingestion lag time
-							p.ingested 	= timestamp(),
-							p.created 	= $created
-					ON MATCH
-						SET 
-							p.handle	= $handle,
-							p.rev		= $rev,
-							p.sig 		= $sig,
-							p.version 	= $version,
-							// tracking firehose lag time
-							p.updated 	= timestamp()
-					RETURN p.id AS did, p.ingested AS ingested_ts;
-					`, map[string]any{
-					"id":     item.DID.String(),
-					"rev":    item.Rev,
-					"sig":    item.Sig,
-					"type":   actor.LexiconTypeID,
-					"handle": item.Ident.Handle.String(),
-					// neo4j (java) expects epoch time in milliseconds
-					"created": createdTiemstamp.Unix() * int64(time.Second/time.Millisecond),
-					"version": item.Version,
-				})
-				if err != nil {
-					return nil, err
-				}
-				for result.Next(ctx) {
-					record := result.Record()
-					records <- record
-				}
-				return records, nil
-			},
-			neo4j.WithTxTimeout(e.conf.timeout()),
-			neo4j.WithTxMetadata(map[string]any{"app": APP_INGEST}))
-		if err != nil {
-			e.log.WithErrorMsg(err, "Error ingesting :Profile", "id", item.DID.String(), "action", "ingest")
-			return err
-		}
-		return nil
-	})
-
-	return records, group.Wait()
+	defer close(records)
+	createdTimestamp, err := datetimeMust(item.DID, actor.CreatedAt)
+	if err != nil {
+		e.log.WithError(err).Error("Missing created timestamp", "did", item.DID, "lexicon", actor.LexiconTypeID)
+		// createdAt is optional - fall back to the unix epoch like clickhouse
+		epoch := time.Unix(0, 0).UTC()
+		createdTimestamp = &epoch
+	}
+	content := bsky.NewProfileContent(actor)
+	if err = e.profiles.Append(ctx, map[string]any{
+		"id":               item.DID.String(),
+		"rev":              item.Rev,
+		"rev_version":      int64(item.RevVersion()),
+		"sig":              item.Sig,
+		"type":             actor.LexiconTypeID,
+		"handle":           knownHandle(item.Ident.Handle),
+		"rkey":             item.RKey,
+		"uri":              item.URI.String(),
+		"cid":              item.CID,
+		"display_name":     content.DisplayName,
+		"description":      content.Description,
+		"avatar_cid":       content.Avatar.CID,
+		"avatar_mime_type": content.Avatar.MimeType,
+		"avatar_size":      content.Avatar.Size,
+		"banner_cid":       content.Banner.CID,
+		"banner_mime_type": content.Banner.MimeType,
+		"banner_size":      content.Banner.Size,
+		"labels":           content.Labels,
+		"pinned_post_uri":  content.PinnedPost.URI,
+		"pinned_post_cid":  content.PinnedPost.CID,
+		"starter_pack_uri": content.JoinedViaStarterPack.URI,
+		"starter_pack_cid": content.JoinedViaStarterPack.CID,
+		// neo4j (java) expects epoch time in milliseconds
+		"created": createdTimestamp.UnixMilli(),
+		"version": item.Version,
+	}, item.Defer()); err != nil {
+		e.log.WithErrorMsg(err, "Error ingesting :Profile", "id", item.DID.String(), "action", "ingest")
+		return records, err
+	}
+	records <- batchedRecord(item)
+	return records, nil
 }
 
 func (e *Engine) ingestFollow(ctx context.Context, item *bsky.RepoItem, follow *bskyItem.GraphFollow) (chan *neo4j.Record, error) {
 	records := make(chan *neo4j.Record, 1)
-	session := e.driver.NewSession(ctx, e.session)
-	defer session.Close(ctx)
-	group, ctx := errgroup.WithContext(ctx)
-	group.Go(func() error {
-		_, err := session.ExecuteWrite(ctx,
-			func(tx neo4j.ManagedTransaction) (any, error) {
-				defer close(records)
-				createdTiemstamp, err := datetimeMust(item.DID, &follow.CreatedAt)
-				if err != nil {
-					return nil, err
-				}
-				result, err := tx.Run(ctx, `
-					MATCH (a:Profile {id: $id_a})
-					MERGE (b:Profile {id: $id_b})
-					MERGE (a)-[r:FOLLOWS {created: $created, rev: $rev, version: $version}]->(b)
-					RETURN a.id AS a_did, b.id AS b_did;
-					`, map[string]any{
-					"id_a": item.DID.String(),
-					"id_b": follow.Subject,
-					"rev":  item.Rev,
-					"sig":  item.Sig,
-					"type": follow.LexiconTypeID,
-					// neo4j (java) expects epoch time in milliseconds
-					"created": createdTiemstamp.Unix() * int64(time.Second/time.Millisecond),
-					"version": item.Version,
-				})
-				if err != nil {
-					return nil, err
-				}
-				for result.Next(ctx) {
-					record := result.Record()
-					records <- record
-				}
-				return records, nil
-			},
-			neo4j.WithTxTimeout(e.conf.timeout()),
-			neo4j.WithTxMetadata(map[string]any{"app": APP_INGEST}))
-		if err != nil {
-			e.log.WithErrorMsg(err, "Error ingesting [:FOLLOWS]", "id", item.DID.String(), "action", "ingest")
-			return err
-		}
-		return nil
-	})
+	defer close(records)
+	createdTimestamp, err := datetimeMust(item.DID, &follow.CreatedAt)
+	if err != nil {
+		e.log.WithErrorMsg(err, "Error ingesting [:FOLLOWS]", "id", item.DID.String(), "action", "ingest")
+		return records, err
+	}
+	if err = e.follows.Append(ctx, map[string]any{
+		"id_a":        item.DID.String(),
+		"id_b":        follow.Subject,
+		"rev":         item.Rev,
+		"rev_version": int64(item.RevVersion()),
+		"sig":         item.Sig,
+		"type":        follow.LexiconTypeID,
+		"rkey":        item.RKey,
+		"uri":         item.URI.String(),
+		"cid":         item.CID,
+		// neo4j (java) expects epoch time in milliseconds
+		"created": createdTimestamp.UnixMilli(),
+		"version": item.Version,
+	}, item.Defer()); err != nil {
+		e.log.WithErrorMsg(err, "Error ingesting [:FOLLOWS]", "id", item.DID.String(), "action", "ingest")
+		return records, err
+	}
+	records <- batchedRecord(item)
+	return records, nil
+}
+
+func (e *Engine) ingestBlock(ctx context.Context, item *bsky.RepoItem, block *bskyItem.GraphBlock) (chan *neo4j.Record, error) {
+	records := make(chan *neo4j.Record, 1)
+	defer close(records)
+	createdTimestamp, err := datetimeMust(item.DID, &block.CreatedAt)
+	if err != nil {
+		e.log.WithErrorMsg(err, "Error ingesting [:BLOCKS]", "id", item.DID.String(), "action", "ingest")
+		return records, err
+	}
+	if err = e.blocks.Append(ctx, map[string]any{
+		"id_a":        item.DID.String(),
+		"id_b":        block.Subject,
+		"rev":         item.Rev,
+		"rev_version": int64(item.RevVersion()),
+		"sig":         item.Sig,
+		"type":        block.LexiconTypeID,
+		"rkey":        item.RKey,
+		"uri":         item.URI.String(),
+		"cid":         item.CID,
+		// neo4j (java) expects epoch time in milliseconds
+		"created": createdTimestamp.UnixMilli(),
+		"version": item.Version,
+	}, item.Defer()); err != nil {
+		e.log.WithErrorMsg(err, "Error ingesting [:BLOCKS]", "id", item.DID.String(), "action", "ingest")
+		return records, err
+	}
+	records <- batchedRecord(item)
+	return records, nil
+}
 
-	return records, group.Wait()
+// RepoSynced - close the validity interval of edges did's repo no longer contains
+func (e *Engine) RepoSynced(ctx context.Context, did, rev string) error {
+	return e.syncs.Append(ctx, map[string]any{
+		"id":          did,
+		"rev_version": int64(bsky.RevVersion(rev)),
+		// neo4j (java) expects epoch time in milliseconds
+		"removed": bsky.RevTime(rev).UnixMilli(),
+	}, nil)
+}
+
+// batchedRecord - placeholder record for an item queued in a pending batch
+func batchedRecord(item *bsky.RepoItem) *neo4j.Record {
+	return &neo4j.Record{
+		Keys:   []string{"did", "nsid"},
+		Values: []any{item.DID.String(), item.NSID.String()},
+	}
+}
+
+// knownHandle - nil for handle.invalid so mergeProfiles keeps the stored handle
+func knownHandle(handle syntax.Handle) any {
+	if handle == syntax.HandleInvalid {
+		return nil
+	}
+	return handle.String()
 }
 
 func datetimeMust(did syntax.DID, datetime *string) (*time.Time, error) {
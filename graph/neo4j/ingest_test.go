@@ -0,0 +1,40 @@
+package neo4j
+
+import (
+	"context"
+	"testing"
+
+	bskyItem "github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/atproto/identity"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/mikeblum/atgraph.dev/bsky"
+	"github.com/mikeblum/atgraph.dev/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngest(t *testing.T) {
+	t.Run("profile without createdAt falls back to the epoch", ingestProfileEpochTest)
+}
+
+func ingestProfileEpochTest(t *testing.T) {
+	ctx := context.Background()
+	writer := &writerTest{}
+	e := &Engine{
+		log:      conf.NewLog(),
+		profiles: batcherTest(t, writer).Batch("Profile", mergeProfiles),
+	}
+	did := syntax.DID("did:plc:test")
+	item := &bsky.RepoItem{
+		DID:   did,
+		Ident: &identity.Identity{DID: did, Handle: syntax.Handle("test.bsky.social")},
+		NSID:  syntax.NSID("app.bsky.actor.profile"),
+		RKey:  "self",
+	}
+	_, err := e.ingestProfile(ctx, item, &bskyItem.ActorProfile{LexiconTypeID: "app.bsky.actor.profile"})
+	require.Nil(t, err)
+	require.Nil(t, e.profiles.Flush(ctx, "test"))
+	require.Len(t, writer.rows, 1)
+	assert.Equal(t, did.String(), writer.rows[0]["id"])
+	assert.Equal(t, int64(0), writer.rows[0]["created"])
+}
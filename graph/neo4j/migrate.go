@@ -0,0 +1,91 @@
+package neo4j
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"time"
+
+	"github.com/mikeblum/atgraph.dev/graph/migrate"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+const (
+	uidx_migration_version = `CREATE CONSTRAINT uidx_migration_version IF NOT EXISTS FOR (m:Migration) REQUIRE (m.version) IS UNIQUE;`
+
+	matchMigrations = `MATCH (m:Migration) RETURN m.version AS version, m.name AS name, m.checksum AS checksum, m.applied AS applied ORDER BY version`
+	mergeMigration  = `MERGE (m:Migration {version: $version})
+		SET m.name = $name,
+			m.checksum = $checksum,
+			m.applied = datetime()`
+)
+
+//go:embed migrations/*.cypher
+var migrations embed.FS
+
+// migrationStore - records applied migrations as (:Migration) nodes.
+// Schema commands can't share a transaction with writes so statements
+// must be idempotent (IF NOT EXISTS).
+type migrationStore struct {
+	driver   neo4j.DriverWithContext
+	database string
+}
+
+func (s *migrationStore) Init(ctx context.Context) error {
+	_, err := s.execute(ctx, uidx_migration_version, nil)
+	return err
+}
+
+func (s *migrationStore) Applied(ctx context.Context) ([]migrate.Applied, error) {
+	result, err := s.execute(ctx, matchMigrations, nil)
+	if err != nil {
+		return nil, err
+	}
+	applied := make([]migrate.Applied, 0, len(result.Records))
+	for _, record := range result.Records {
+		version, _, err := neo4j.GetRecordValue[int64](record, "version")
+		if err != nil {
+			return nil, err
+		}
+		name, _, _ := neo4j.GetRecordValue[string](record, "name")
+		checksum, _, _ := neo4j.GetRecordValue[string](record, "checksum")
+		at, _, _ := neo4j.GetRecordValue[time.Time](record, "applied")
+		applied = append(applied, migrate.Applied{
+			Version:  int(version),
+			Name:     name,
+			Checksum: checksum,
+			Applied:  at,
+		})
+	}
+	return applied, nil
+}
+
+func (s *migrationStore) Apply(ctx context.Context, migration migrate.Migration) error {
+	for i, stmt := range migration.Statements {
+		if _, err := s.execute(ctx, stmt, nil); err != nil {
+			return fmt.Errorf("statement %d: %w", i+1, err)
+		}
+	}
+	_, err := s.execute(ctx, mergeMigration, map[string]any{
+		"version":  migration.Version,
+		"name":     migration.Name,
+		"checksum": migration.Checksum,
+	})
+	return err
+}
+
+func (s *migrationStore) execute(ctx context.Context, query string, params map[string]any) (*neo4j.EagerResult, error) {
+	return neo4j.ExecuteQuery(ctx, s.driver,
+		query,
+		params, neo4j.EagerResultTransformer,
+		neo4j.ExecuteQueryWithDatabase(s.database))
+}
+
+// newMigrator - embedded Cypher migrations applied to the configured database
+func newMigrator(driver neo4j.DriverWithContext, cfg *Conf) (*migrate.Migrator, error) {
+	loaded, err := migrate.LoadFrom(migrations, "migrations", cfg.migrationsDir())
+	if err != nil {
+		return nil, err
+	}
+	return migrate.NewMigrator(ENGINE, &migrationStore{driver: driver, database: cfg.database()}, loaded), nil
+}
@@ -0,0 +1,3 @@
+// Profile uniqueness
+CREATE CONSTRAINT uidx_profile_id IF NOT EXISTS FOR (n:Profile) REQUIRE (n.id) IS UNIQUE;
+CREATE CONSTRAINT uidx_profile_handle_id IF NOT EXISTS FOR (n:Profile) REQUIRE (n.handle, n.id) IS UNIQUE;
@@ -0,0 +1,4 @@
+// Profile lookups by timestamp and repo revision
+CREATE INDEX idx_profile_created IF NOT EXISTS FOR (n:Profile) ON (n.created);
+CREATE INDEX idx_profile_updated IF NOT EXISTS FOR (n:Profile) ON (n.updated);
+CREATE INDEX idx_profile_rev IF NOT EXISTS FOR (n:Profile) ON (n.rev);
@@ -0,0 +1,4 @@
+// lookups by AT-URI for deletes and linking likes / reposts to their subjects
+CREATE INDEX idx_profile_uri IF NOT EXISTS FOR (n:Profile) ON (n.uri);
+CREATE INDEX idx_follows_uri IF NOT EXISTS FOR ()-[r:FOLLOWS]-() ON (r.uri);
+CREATE INDEX idx_follows_rkey IF NOT EXISTS FOR ()-[r:FOLLOWS]-() ON (r.rkey);
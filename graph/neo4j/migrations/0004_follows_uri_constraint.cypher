@@ -0,0 +1,4 @@
+// record URI identifies a follow - replaces the plain index from 0003
+DROP INDEX idx_follows_uri IF EXISTS;
+CREATE CONSTRAINT uidx_follows_uri IF NOT EXISTS FOR ()-[r:FOLLOWS]-() REQUIRE (r.uri) IS UNIQUE;
+CREATE INDEX idx_profile_rev_version IF NOT EXISTS FOR (n:Profile) ON (n.rev_version);
@@ -0,0 +1,3 @@
+// StarterPack uniqueness - (:Profile)-[:JOINED_VIA]->(:StarterPack) merges on the AT-URI
+CREATE CONSTRAINT uidx_starter_pack_uri IF NOT EXISTS FOR (n:StarterPack) REQUIRE (n.uri) IS UNIQUE;
+CREATE INDEX idx_profile_pinned_post_uri IF NOT EXISTS FOR (n:Profile) ON (n.pinned_post_uri);
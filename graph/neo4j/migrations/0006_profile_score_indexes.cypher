@@ -0,0 +1,3 @@
+// cmd/analyze results: top profiles by influence and members of a community
+CREATE INDEX idx_profile_pagerank IF NOT EXISTS FOR (n:Profile) ON (n.pagerank);
+CREATE INDEX idx_profile_community IF NOT EXISTS FOR (n:Profile) ON (n.community);
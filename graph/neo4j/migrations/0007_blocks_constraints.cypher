@@ -0,0 +1,2 @@
+// record URI identifies a block, as for follows in 0004
+CREATE CONSTRAINT uidx_blocks_uri IF NOT EXISTS FOR ()-[r:BLOCKS]-() REQUIRE (r.uri) IS UNIQUE;
@@ -0,0 +1,65 @@
+package neo4j
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mikeblum/atgraph.dev/graph"
+)
+
+const (
+	// variable length bounds can't be parameterized: %d is the max depth
+	matchShortestPaths = `
+		MATCH (a:Profile {id: $from}), (b:Profile {id: $to})
+		MATCH p = allShortestPaths((a)-[:FOLLOWS*..%d]->(b))
+		WHERE all(r IN relationships(p) WHERE r.removed IS NULL)
+		%s
+		RETURN [n IN nodes(p) | n.id] AS path
+		LIMIT $limit;
+		`
+
+	// every hop must be followed back
+	whereMutual = `
+		AND all(r IN relationships(p) WHERE EXISTS {
+			MATCH (x:Profile)-[back:FOLLOWS]->(y:Profile)
+			WHERE x = endNode(r) AND y = startNode(r) AND back.removed IS NULL
+		})
+		`
+)
+
+// ShortestPaths - native allShortestPaths over [:FOLLOWS]
+func (e *Engine) ShortestPaths(ctx context.Context, q graph.PathQuery) ([]graph.Path, error) {
+	if q.From == q.To {
+		return []graph.Path{{q.From}}, nil
+	}
+	if q.MaxDepth < 1 {
+		return []graph.Path{}, nil
+	}
+	timeout := e.conf.timeout()
+	if q.Timeout > 0 {
+		timeout = q.Timeout
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, q.Timeout)
+		defer cancel()
+	}
+	where := ""
+	if q.Mutual {
+		where = whereMutual
+	}
+	rows, err := e.readTimeout(ctx, fmt.Sprintf(matchShortestPaths, q.MaxDepth, where), map[string]any{
+		"from":  q.From,
+		"to":    q.To,
+		"limit": max(q.Limit, 1),
+	}, timeout)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]graph.Path, 0, len(rows))
+	for _, row := range rows {
+		paths = append(paths, graph.Path(row.strs("path")))
+	}
+	return paths, nil
+}
+
+// validate graph.PathFinder interface is implemented
+var _ graph.PathFinder = &Engine{}
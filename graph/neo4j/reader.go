@@ -0,0 +1,323 @@
+package neo4j
+
+import (
+	"context"
+	"time"
+
+	"github.com/mikeblum/atgraph.dev/bsky"
+	"github.com/mikeblum/atgraph.dev/graph"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+const (
+	APP_READ = "atgraph.dev:read"
+
+	// profiles only MERGEd as the end of a follow have no rev_version yet
+	matchProfile = `
+		MATCH (p:Profile {id: $did})
+		WHERE p.rev_version IS NOT NULL
+		` + returnProfile + `
+		LIMIT 1;
+		`
+
+	// p and the starter pack it was joined via
+	returnProfile = `
+		OPTIONAL MATCH (p)-[:JOINED_VIA]->(s:StarterPack)
+		RETURN
+			p.id				AS did,
+			p.handle			AS handle,
+			p.display_name		AS display_name,
+			p.description		AS description,
+			p.avatar_cid		AS avatar_cid,
+			p.avatar_mime_type	AS avatar_mime_type,
+			p.avatar_size		AS avatar_size,
+			p.banner_cid		AS banner_cid,
+			p.banner_mime_type	AS banner_mime_type,
+			p.banner_size		AS banner_size,
+			p.labels			AS labels,
+			p.pinned_post_uri	AS pinned_post_uri,
+			p.pinned_post_cid	AS pinned_post_cid,
+			s.uri				AS starter_pack_uri,
+			s.cid				AS starter_pack_cid,
+			p.created			AS created,
+			p.rev				AS rev,
+			p.uri				AS uri,
+			p.cid				AS cid
+		`
+
+	// keyset pagination: each page starts after the sort key of the previous page's last row
+	returnEdges = `
+		RETURN
+			a.id		AS did,
+			b.id		AS subject,
+			r.rkey		AS rkey,
+			r.created	AS created,
+			r.removed	AS removed,
+			r.rev		AS rev,
+			r.uri		AS uri,
+			r.cid		AS cid
+		`
+
+	matchFollowers = `
+		MATCH (a:Profile)-[r:FOLLOWS]->(b:Profile {id: $did})
+		WHERE r.removed IS NULL
+			AND (a.id > $after OR (a.id = $after AND r.rkey > $rkey))
+		` + returnEdges + `
+		ORDER BY did, rkey
+		LIMIT $limit;
+		`
+
+	matchFollowing = `
+		MATCH (a:Profile {id: $did})-[r:FOLLOWS]->(b:Profile)
+		WHERE r.removed IS NULL AND r.rkey > $rkey
+		` + returnEdges + `
+		ORDER BY rkey
+		LIMIT $limit;
+		`
+
+	matchMutualFollows = `
+		MATCH (a:Profile {id: $did})-[r:FOLLOWS]->(b:Profile)
+		WHERE r.removed IS NULL
+			AND EXISTS { MATCH (b)-[back:FOLLOWS]->(a) WHERE back.removed IS NULL }
+			AND (b.id > $after OR (b.id = $after AND r.rkey > $rkey))
+		` + returnEdges + `
+		ORDER BY subject, rkey
+		LIMIT $limit;
+		`
+
+	matchBlocks = `
+		MATCH (a:Profile {id: $did})-[r:BLOCKS]->(b:Profile)
+		WHERE r.removed IS NULL AND r.rkey > $rkey
+		` + returnEdges + `
+		ORDER BY rkey
+		LIMIT $limit;
+		`
+)
+
+func (e *Engine) GetProfile(ctx context.Context, did string) (*graph.Profile, error) {
+	rows, err := e.read(ctx, matchProfile, map[string]any{"did": did})
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, graph.ErrNotFound
+	}
+	profile := toProfile(rows[0])
+	return &profile, nil
+}
+
+func (e *Engine) Followers(ctx context.Context, did string, page graph.Page) (*graph.Results[graph.Follow], error) {
+	keys, err := page.Keys(2)
+	if err != nil {
+		return nil, err
+	}
+	follows, err := e.readFollows(ctx, matchFollowers, did, keys[0], keys[1], page)
+	if err != nil {
+		return nil, err
+	}
+	return graph.NewResults(follows, page, func(f graph.Follow) []string {
+		return []string{f.DID, f.RKey}
+	}), nil
+}
+
+func (e *Engine) Following(ctx context.Context, did string, page graph.Page) (*graph.Results[graph.Follow], error) {
+	keys, err := page.Keys(1)
+	if err != nil {
+		return nil, err
+	}
+	follows, err := e.readFollows(ctx, matchFollowing, did, "", keys[0], page)
+	if err != nil {
+		return nil, err
+	}
+	return graph.NewResults(follows, page, func(f graph.Follow) []string {
+		return []string{f.RKey}
+	}), nil
+}
+
+func (e *Engine) MutualFollows(ctx context.Context, did string, page graph.Page) (*graph.Results[graph.Follow], error) {
+	keys, err := page.Keys(2)
+	if err != nil {
+		return nil, err
+	}
+	follows, err := e.readFollows(ctx, matchMutualFollows, did, keys[0], keys[1], page)
+	if err != nil {
+		return nil, err
+	}
+	return graph.NewResults(follows, page, func(f graph.Follow) []string {
+		return []string{f.Subject, f.RKey}
+	}), nil
+}
+
+func (e *Engine) Blocks(ctx context.Context, did string, page graph.Page) (*graph.Results[graph.Block], error) {
+	keys, err := page.Keys(1)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := e.read(ctx, matchBlocks, map[string]any{
+		"did":   did,
+		"rkey":  keys[0],
+		"limit": page.Size() + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return graph.NewResults(toBlocks(rows), page, func(b graph.Block) []string {
+		return []string{b.RKey}
+	}), nil
+}
+
+// readFollows - run a [:FOLLOWS] query fetching one extra row to detect the next page
+func (e *Engine) readFollows(ctx context.Context, query, did, after, rkey string, page graph.Page) ([]graph.Follow, error) {
+	rows, err := e.read(ctx, query, map[string]any{
+		"did":   did,
+		"after": after,
+		"rkey":  rkey,
+		"limit": page.Size() + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toFollows(rows), nil
+}
+
+func toProfile(row readRow) graph.Profile {
+	return graph.Profile{
+		DID:         row.str("did"),
+		Handle:      row.str("handle"),
+		DisplayName: row.str("display_name"),
+		Description: row.str("description"),
+		Avatar: bsky.Blob{
+			CID:      row.str("avatar_cid"),
+			MimeType: row.str("avatar_mime_type"),
+			Size:     row.int("avatar_size"),
+		},
+		Banner: bsky.Blob{
+			CID:      row.str("banner_cid"),
+			MimeType: row.str("banner_mime_type"),
+			Size:     row.int("banner_size"),
+		},
+		Labels:               row.strs("labels"),
+		PinnedPost:           bsky.StrongRef{URI: row.str("pinned_post_uri"), CID: row.str("pinned_post_cid")},
+		JoinedViaStarterPack: bsky.StrongRef{URI: row.str("starter_pack_uri"), CID: row.str("starter_pack_cid")},
+		Created:              row.time("created"),
+		Rev:                  row.str("rev"),
+		URI:                  row.str("uri"),
+		CID:                  row.str("cid"),
+	}
+}
+
+func toFollows(rows []readRow) []graph.Follow {
+	follows := make([]graph.Follow, 0, len(rows))
+	for _, row := range rows {
+		follows = append(follows, toFollow(row))
+	}
+	return follows
+}
+
+func toFollow(row readRow) graph.Follow {
+	return graph.Follow{
+		DID:     row.str("did"),
+		Subject: row.str("subject"),
+		RKey:    row.str("rkey"),
+		Created: row.time("created"),
+		Removed: row.timePtr("removed"),
+		Rev:     row.str("rev"),
+		URI:     row.str("uri"),
+		CID:     row.str("cid"),
+	}
+}
+
+func toBlocks(rows []readRow) []graph.Block {
+	blocks := make([]graph.Block, 0, len(rows))
+	for _, row := range rows {
+		blocks = append(blocks, toBlock(row))
+	}
+	return blocks
+}
+
+func toBlock(row readRow) graph.Block {
+	return graph.Block{
+		DID:     row.str("did"),
+		Subject: row.str("subject"),
+		RKey:    row.str("rkey"),
+		Created: row.time("created"),
+		Removed: row.timePtr("removed"),
+		Rev:     row.str("rev"),
+		URI:     row.str("uri"),
+		CID:     row.str("cid"),
+	}
+}
+
+// read - run query in a read transaction and collect every record
+func (e *Engine) read(ctx context.Context, query string, params map[string]any) ([]readRow, error) {
+	return e.readTimeout(ctx, query, params, e.conf.timeout())
+}
+
+// readTimeout - read bounded by timeout rather than the configured transaction timeout
+func (e *Engine) readTimeout(ctx context.Context, query string, params map[string]any, timeout time.Duration) ([]readRow, error) {
+	session := e.driver.NewSession(ctx, e.session)
+	defer session.Close(ctx)
+	return neo4j.ExecuteRead(ctx, session,
+		func(tx neo4j.ManagedTransaction) ([]readRow, error) {
+			result, err := tx.Run(ctx, query, params)
+			if err != nil {
+				return nil, err
+			}
+			records, err := result.Collect(ctx)
+			if err != nil {
+				return nil, err
+			}
+			rows := make([]readRow, 0, len(records))
+			for _, record := range records {
+				rows = append(rows, record.AsMap())
+			}
+			return rows, nil
+		},
+		neo4j.WithTxTimeout(timeout),
+		neo4j.WithTxMetadata(map[string]any{"app": APP_READ}))
+}
+
+// readRow - record values by key; missing or null properties read as zero values
+type readRow map[string]any
+
+func (r readRow) str(key string) string {
+	s, _ := r[key].(string)
+	return s
+}
+
+func (r readRow) int(key string) int64 {
+	i, _ := r[key].(int64)
+	return i
+}
+
+func (r readRow) strs(key string) []string {
+	values, _ := r[key].([]any)
+	strs := make([]string, 0, len(values))
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
+// time - neo4j stores epoch time in milliseconds; zero for a missing or null property
+func (r readRow) time(key string) time.Time {
+	if t := r.timePtr(key); t != nil {
+		return *t
+	}
+	return time.Time{}
+}
+
+// timePtr - nil for a missing or null property
+func (r readRow) timePtr(key string) *time.Time {
+	millis, ok := r[key].(int64)
+	if !ok {
+		return nil
+	}
+	t := time.UnixMilli(millis).UTC()
+	return &t
+}
+
+// validate graph.Reader interface is implemented
+var _ graph.Reader = &Engine{}
@@ -0,0 +1,167 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+var ErrNoPathFinder = errors.New("graph: no engine supports shortest paths")
+
+// PathFinder - engines answering "how am I connected to X" over [:FOLLOWS]
+type PathFinder interface {
+	// ShortestPaths - up to q.Limit shortest follow paths from q.From to q.To,
+	// empty if they aren't connected within q.MaxDepth hops
+	ShortestPaths(ctx context.Context, q PathQuery) ([]Path, error)
+}
+
+// PathQuery - shortest paths between two DIDs
+type PathQuery struct {
+	From string
+	To   string
+	// MaxDepth - maximum hops ie. degrees of separation
+	MaxDepth int
+	// Mutual - only traverse follows that are followed back
+	Mutual bool
+	// Limit - maximum number of equally short paths returned
+	Limit int
+	// Timeout - bound on the search, applied by the engine
+	Timeout time.Duration
+}
+
+// Path - DIDs from PathQuery.From to PathQuery.To, each following the next
+type Path []string
+
+// Degrees - degrees of separation ie. hops along the path
+func (p Path) Degrees() int {
+	return max(len(p)-1, 0)
+}
+
+// Expander - neighbours of every DID in dids: accounts they follow, or the
+// accounts following them if reverse. Mutual searches ignore reverse.
+type Expander func(ctx context.Context, dids []string, reverse bool) (map[string][]string, error)
+
+// ShortestPathsBFS - bidirectional breadth first search for engines without a
+// native shortest path, expanding the smaller frontier one level at a time so
+// each side only needs to reach about half of q.MaxDepth
+func ShortestPathsBFS(ctx context.Context, q PathQuery, expand Expander) ([]Path, error) {
+	if q.From == q.To {
+		return []Path{{q.From}}, nil
+	}
+	fwd, bwd := newSearchSide(q.From), newSearchSide(q.To)
+	for fwd.level+bwd.level < q.MaxDepth && len(fwd.frontier) > 0 && len(bwd.frontier) > 0 {
+		side, other, reverse := fwd, bwd, false
+		if len(bwd.frontier) < len(fwd.frontier) {
+			side, other, reverse = bwd, fwd, true
+		}
+		adj, err := expand(ctx, side.frontier, reverse)
+		if err != nil {
+			return nil, err
+		}
+		side.advance(adj)
+
+		// nodes reached from both sides - only those on the shortest total length
+		shortest := -1
+		var meets []string
+		for _, node := range side.frontier {
+			depth, ok := other.depth[node]
+			if !ok {
+				continue
+			}
+			switch total := side.level + depth; {
+			case shortest < 0 || total < shortest:
+				shortest, meets = total, []string{node}
+			case total == shortest:
+				meets = append(meets, node)
+			}
+		}
+		if len(meets) > 0 {
+			slices.Sort(meets)
+			return joinPaths(fwd, bwd, meets, q.Limit), nil
+		}
+	}
+	return []Path{}, nil
+}
+
+// searchSide - BFS state rooted at one end of the path
+type searchSide struct {
+	level    int
+	frontier []string
+	depth    map[string]int
+	// parents - neighbours one level closer to the root
+	parents map[string][]string
+}
+
+func newSearchSide(root string) *searchSide {
+	return &searchSide{
+		frontier: []string{root},
+		depth:    map[string]int{root: 0},
+		parents:  make(map[string][]string),
+	}
+}
+
+// advance - replace the frontier with every node first reached at the next level
+func (s *searchSide) advance(adj map[string][]string) {
+	var next []string
+	for _, node := range s.frontier {
+		for _, neighbour := range adj[node] {
+			depth, seen := s.depth[neighbour]
+			if seen && depth <= s.level {
+				continue
+			}
+			if !seen {
+				s.depth[neighbour] = s.level + 1
+				next = append(next, neighbour)
+			}
+			if !slices.Contains(s.parents[neighbour], node) {
+				s.parents[neighbour] = append(s.parents[neighbour], node)
+			}
+		}
+	}
+	for _, parents := range s.parents {
+		slices.Sort(parents)
+	}
+	s.level++
+	s.frontier = next
+}
+
+// walk - every path from node back to the root, stopping once limit are found
+func (s *searchSide) walk(node string, limit int) [][]string {
+	parents := s.parents[node]
+	if len(parents) == 0 {
+		return [][]string{{node}}
+	}
+	var paths [][]string
+	for _, parent := range parents {
+		for _, path := range s.walk(parent, limit-len(paths)) {
+			paths = append(paths, append([]string{node}, path...))
+			if len(paths) >= limit {
+				return paths
+			}
+		}
+	}
+	return paths
+}
+
+// joinPaths - from -> meet paths joined with meet -> to paths
+func joinPaths(fwd, bwd *searchSide, meets []string, limit int) []Path {
+	if limit <= 0 {
+		limit = 1
+	}
+	var paths []Path
+	for _, meet := range meets {
+		for _, head := range fwd.walk(meet, limit) {
+			// walk runs meet -> from: reverse into from -> meet
+			slices.Reverse(head)
+			for _, tail := range bwd.walk(meet, limit) {
+				path := append(slices.Clone(head), tail[1:]...)
+				paths = append(paths, path)
+				if len(paths) >= limit {
+					return paths
+				}
+			}
+		}
+	}
+	return paths
+}
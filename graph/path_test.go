@@ -0,0 +1,118 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mikeblum/atgraph.dev/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShortestPaths(t *testing.T) {
+	t.Run("degrees", pathDegreesTest)
+	t.Run("bidirectional bfs", pathBFSTest)
+	t.Run("mutual follows only", pathMutualTest)
+	t.Run("max depth and limit", pathBoundsTest)
+	t.Run("conf clamps queries", pathConfTest)
+}
+
+// expanderTest - Expander over an in-memory follow list
+func expanderTest(follows [][2]string, mutual bool) Expander {
+	edges := make(map[[2]string]bool, len(follows))
+	for _, f := range follows {
+		edges[f] = true
+	}
+	return func(ctx context.Context, dids []string, reverse bool) (map[string][]string, error) {
+		adj := make(map[string][]string)
+		for _, f := range follows {
+			if mutual && !edges[[2]string{f[1], f[0]}] {
+				continue
+			}
+			if reverse && !mutual {
+				adj[f[1]] = append(adj[f[1]], f[0])
+			} else {
+				adj[f[0]] = append(adj[f[0]], f[1])
+			}
+		}
+		return adj, nil
+	}
+}
+
+// a -> b -> d, a -> c -> d, d -> e, c <-> a, c <-> d
+var followsTest = [][2]string{
+	{"a", "b"}, {"b", "d"},
+	{"a", "c"}, {"c", "a"},
+	{"c", "d"}, {"d", "c"},
+	{"d", "e"},
+}
+
+func pathDegreesTest(t *testing.T) {
+	assert.Equal(t, 0, Path{}.Degrees())
+	assert.Equal(t, 0, Path{"a"}.Degrees())
+	assert.Equal(t, 2, Path{"a", "b", "c"}.Degrees())
+}
+
+func pathBFSTest(t *testing.T) {
+	ctx := context.Background()
+	q := PathQuery{From: "a", To: "e", MaxDepth: PATH_MAX_DEPTH, Limit: PATH_LIMIT}
+	paths, err := ShortestPathsBFS(ctx, q, expanderTest(followsTest, false))
+	require.Nil(t, err)
+	assert.Equal(t, []Path{{"a", "b", "d", "e"}, {"a", "c", "d", "e"}}, paths)
+
+	// follows are directed
+	q.From, q.To = "e", "a"
+	paths, err = ShortestPathsBFS(ctx, q, expanderTest(followsTest, false))
+	require.Nil(t, err)
+	assert.Empty(t, paths)
+
+	q.From, q.To = "a", "a"
+	paths, err = ShortestPathsBFS(ctx, q, expanderTest(followsTest, false))
+	require.Nil(t, err)
+	assert.Equal(t, []Path{{"a"}}, paths)
+}
+
+func pathMutualTest(t *testing.T) {
+	q := PathQuery{From: "a", To: "d", MaxDepth: PATH_MAX_DEPTH, Mutual: true, Limit: PATH_LIMIT}
+	paths, err := ShortestPathsBFS(context.Background(), q, expanderTest(followsTest, true))
+	require.Nil(t, err)
+	assert.Equal(t, []Path{{"a", "c", "d"}}, paths)
+
+	// d -> e isn't followed back
+	q.To = "e"
+	paths, err = ShortestPathsBFS(context.Background(), q, expanderTest(followsTest, true))
+	require.Nil(t, err)
+	assert.Empty(t, paths)
+}
+
+func pathBoundsTest(t *testing.T) {
+	ctx := context.Background()
+	q := PathQuery{From: "a", To: "e", MaxDepth: 2, Limit: PATH_LIMIT}
+	paths, err := ShortestPathsBFS(ctx, q, expanderTest(followsTest, false))
+	require.Nil(t, err)
+	assert.Empty(t, paths)
+
+	q.MaxDepth, q.Limit = 3, 1
+	paths, err = ShortestPathsBFS(ctx, q, expanderTest(followsTest, false))
+	require.Nil(t, err)
+	assert.Len(t, paths, 1)
+	assert.Equal(t, 3, paths[0].Degrees())
+}
+
+func pathConfTest(t *testing.T) {
+	t.Setenv(ENV_ATGRAPH_PATH_MAX_DEPTH, "4")
+	t.Setenv(ENV_ATGRAPH_PATH_LIMIT, "nope")
+	cfg, err := NewConf()
+	var fieldErr *conf.FieldError
+	require.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, ENV_ATGRAPH_PATH_LIMIT, fieldErr.Env)
+	q := cfg.PathQuery("a", "b", true, 0, 0)
+	assert.Equal(t, 4, q.MaxDepth)
+	assert.Equal(t, PATH_LIMIT, q.Limit)
+	assert.Equal(t, PATH_TIMEOUT, q.Timeout)
+	assert.True(t, q.Mutual)
+
+	q = cfg.PathQuery("a", "b", false, 10, 3)
+	assert.Equal(t, 4, q.MaxDepth)
+	assert.Equal(t, 3, q.Limit)
+}
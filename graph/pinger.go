@@ -0,0 +1,8 @@
+package graph
+
+import "context"
+
+// Pinger - engines able to report whether their backing store is reachable
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
@@ -0,0 +1,153 @@
+package graph
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/mikeblum/atgraph.dev/bsky"
+)
+
+const (
+	PAGE_LIMIT_DEFAULT = 50
+	PAGE_LIMIT_MAX     = 100
+)
+
+var (
+	ErrNotFound      = errors.New("graph: not found")
+	ErrNotIngested   = errors.New("graph: collection is not ingested")
+	ErrInvalidCursor = errors.New("graph: invalid cursor")
+	ErrNoReader      = errors.New("graph: no engine supports reads")
+)
+
+// Reader - typed queries over the ingested graph. Every list call is keyset
+// paginated: pass the previous Results.Cursor to fetch the next page.
+type Reader interface {
+	// GetProfile - ErrNotFound if did has no profile
+	GetProfile(ctx context.Context, did string) (*Profile, error)
+	// Followers - follows whose subject is did, ordered by follower DID
+	Followers(ctx context.Context, did string, page Page) (*Results[Follow], error)
+	// Following - follows created by did, ordered by rkey
+	Following(ctx context.Context, did string, page Page) (*Results[Follow], error)
+	// MutualFollows - follows created by did whose subject follows did back, ordered by subject
+	MutualFollows(ctx context.Context, did string, page Page) (*Results[Follow], error)
+	// Blocks - blocks created by did, ordered by rkey
+	Blocks(ctx context.Context, did string, page Page) (*Results[Block], error)
+}
+
+// Profile - a stored app.bsky.actor.profile
+type Profile struct {
+	DID                  string         `json:"did"`
+	Handle               string         `json:"handle"`
+	DisplayName          string         `json:"displayName"`
+	Description          string         `json:"description"`
+	Avatar               bsky.Blob      `json:"avatar"`
+	Banner               bsky.Blob      `json:"banner"`
+	Labels               []string       `json:"labels"`
+	PinnedPost           bsky.StrongRef `json:"pinnedPost"`
+	JoinedViaStarterPack bsky.StrongRef `json:"joinedViaStarterPack"`
+	Created              time.Time      `json:"created"`
+	Rev                  string         `json:"rev"`
+	URI                  string         `json:"uri"`
+	CID                  string         `json:"cid"`
+}
+
+// Follow - an app.bsky.graph.follow edge from DID to Subject
+type Follow struct {
+	DID     string    `json:"did"`
+	Subject string    `json:"subject"`
+	RKey    string    `json:"rkey"`
+	Created time.Time `json:"created"`
+	// Removed - nil while the follow is still in DID's repo
+	Removed *time.Time `json:"removed,omitempty"`
+	Rev     string     `json:"rev"`
+	URI     string     `json:"uri"`
+	CID     string     `json:"cid"`
+}
+
+// Block - an app.bsky.graph.block edge from DID to Subject
+type Block struct {
+	DID     string    `json:"did"`
+	Subject string    `json:"subject"`
+	RKey    string    `json:"rkey"`
+	Created time.Time `json:"created"`
+	// Removed - nil while the block is still in DID's repo
+	Removed *time.Time `json:"removed,omitempty"`
+	Rev     string     `json:"rev"`
+	URI     string     `json:"uri"`
+	CID     string     `json:"cid"`
+}
+
+// Page - page size and the cursor returned with the previous page.
+// An empty cursor starts from the beginning.
+type Page struct {
+	Limit  int
+	Cursor string
+}
+
+// Size - Limit clamped to (0, PAGE_LIMIT_MAX], PAGE_LIMIT_DEFAULT if unset
+func (p Page) Size() int {
+	switch {
+	case p.Limit <= 0:
+		return PAGE_LIMIT_DEFAULT
+	case p.Limit > PAGE_LIMIT_MAX:
+		return PAGE_LIMIT_MAX
+	}
+	return p.Limit
+}
+
+// Keys - the n sort keys encoded in Cursor. An empty cursor decodes to
+// n empty strings which sort before every key.
+func (p Page) Keys(n int) ([]string, error) {
+	if p.Cursor == "" {
+		return make([]string, n), nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	var keys []string
+	if err = json.Unmarshal(raw, &keys); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	if len(keys) != n {
+		return nil, fmt.Errorf("%w: expected %d keys, got %d", ErrInvalidCursor, n, len(keys))
+	}
+	return keys, nil
+}
+
+// Results - a page of items and the cursor of the next page, empty once exhausted
+type Results[T any] struct {
+	Items  []T    `json:"items"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// NewResults - engines fetch page.Size()+1 items; the extra item only signals
+// another page exists and the cursor is built from the sort keys of the last
+// item returned
+func NewResults[T any](items []T, page Page, keys func(T) []string) *Results[T] {
+	results := &Results[T]{Items: items}
+	if results.Items == nil {
+		results.Items = []T{}
+	}
+	if size := page.Size(); len(items) > size {
+		results.Items = items[:size]
+		results.Cursor = EncodeCursor(keys(results.Items[size-1])...)
+	}
+	return results
+}
+
+// EncodeCursor - opaque cursor from the sort keys of the last item in a page
+func EncodeCursor(keys ...string) string {
+	raw, _ := json.Marshal(keys)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// NotIngested - ErrNotIngested for a collection that isn't written to the engines yet
+func NotIngested(nsid syntax.NSID) error {
+	return fmt.Errorf("%w: %s", ErrNotIngested, nsid)
+}
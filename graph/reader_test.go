@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader(t *testing.T) {
+	t.Run("page size", pageSizeTest)
+	t.Run("cursor round trip", cursorTest)
+	t.Run("invalid cursor", cursorInvalidTest)
+	t.Run("results cursor only when more items", resultsTest)
+}
+
+func pageSizeTest(t *testing.T) {
+	assert.Equal(t, PAGE_LIMIT_DEFAULT, Page{}.Size())
+	assert.Equal(t, PAGE_LIMIT_DEFAULT, Page{Limit: -1}.Size())
+	assert.Equal(t, 10, Page{Limit: 10}.Size())
+	assert.Equal(t, PAGE_LIMIT_MAX, Page{Limit: PAGE_LIMIT_MAX + 1}.Size())
+}
+
+func cursorTest(t *testing.T) {
+	keys, err := Page{}.Keys(2)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"", ""}, keys)
+
+	keys, err = Page{Cursor: EncodeCursor("did:plc:a", "3kabc")}.Keys(2)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"did:plc:a", "3kabc"}, keys)
+}
+
+func cursorInvalidTest(t *testing.T) {
+	_, err := Page{Cursor: "not base64!"}.Keys(1)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+	_, err = Page{Cursor: EncodeCursor("a", "b")}.Keys(1)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func resultsTest(t *testing.T) {
+	key := func(f Follow) []string { return []string{f.RKey} }
+	page := Page{Limit: 2}
+
+	results := NewResults([]Follow{{RKey: "a"}, {RKey: "b"}, {RKey: "c"}}, page, key)
+	assert.Equal(t, []Follow{{RKey: "a"}, {RKey: "b"}}, results.Items)
+	next, err := Page{Cursor: results.Cursor}.Keys(1)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"b"}, next)
+
+	results = NewResults([]Follow{{RKey: "c"}}, page, key)
+	assert.Len(t, results.Items, 1)
+	assert.Empty(t, results.Cursor)
+
+	results = NewResults[Follow](nil, page, key)
+	assert.NotNil(t, results.Items)
+	assert.Empty(t, results.Cursor)
+}
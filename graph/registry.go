@@ -0,0 +1,52 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Factory - constructs a ready to use Engine
+type Factory func(ctx context.Context) (Engine, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register - make an engine available by name, typically from the engine
+// package's init. Panics on duplicate names like database/sql.Register
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if factory == nil {
+		panic("graph: Register factory is nil for " + name)
+	}
+	if _, dup := registry[name]; dup {
+		panic("graph: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// Engines - sorted names of the registered engines
+func Engines() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func lookup(name string) (Factory, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("graph: unknown engine %q (registered: %v)", name, Engines())
+	}
+	return factory, nil
+}
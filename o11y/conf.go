@@ -4,16 +4,42 @@ import "github.com/mikeblum/atgraph.dev/conf"
 
 type Conf struct {
 	conf.EnvConf
+	cfg *Config
 }
 
-func NewConf() *Conf {
-	return &Conf{conf.NewEnvConf()}
+// NewConf - settings decoded once, invalid ones are left at their defaults
+// and reported in the returned error alongside the still usable Conf
+func NewConf() (*Conf, error) {
+	c := &Conf{EnvConf: conf.NewEnvConf()}
+	var err error
+	c.cfg, err = c.decode()
+	return c, err
+}
+
+// Config - typed o11y settings
+type Config struct {
+	Env          string `env:"ENV"`
+	OTLPEndpoint string `env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+}
+
+// decode - defaults overridden by whatever conf.Decode can parse
+func (c *Conf) decode() (*Config, error) {
+	cfg := &Config{
+		Env:          DEFAULT_ENV,
+		OTLPEndpoint: DEFAULT_OTEL_OTLP_ENDPOINT,
+	}
+	return cfg, conf.Decode(c.EnvConf, cfg)
+}
+
+// Config - the settings decoded by NewConf
+func (c *Conf) Config() *Config {
+	return c.cfg
 }
 
 func (c *Conf) o11yEndpoint() string {
-	return c.GetEnv(ENV_OTEL_EXPORTER_OTLP_ENDPOINT, DEFAULT_OTEL_OTLP_ENDPOINT)
+	return c.cfg.OTLPEndpoint
 }
 
 func (c *Conf) env() string {
-	return c.GetEnv(ENV, DEFAULT_ENV)
+	return c.cfg.Env
 }
@@ -23,10 +23,12 @@ func NewO11y(ctx context.Context, log *conf.Log) (*otlpmetricgrpc.Exporter, erro
 	// configure global error log handler
 	otel.SetErrorHandler(NewOTELErrorHandler(log.Logger))
 
-	cfg := NewConf()
+	cfg, err := NewConf()
+	if err != nil {
+		return nil, err
+	}
 
 	var res *resource.Resource
-	var err error
 	if res, err = resource.New(ctx,
 		resource.WithAttributes(
 			semconv.ServiceName(ServiceName),
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"time"
+
+	"github.com/mikeblum/atgraph.dev/conf"
+)
+
+type Conf struct {
+	conf.EnvConf
+	cfg *Config
+}
+
+// NewConf - settings decoded once, invalid ones are left at their defaults
+// and reported in the returned error alongside the still usable Conf
+func NewConf() (*Conf, error) {
+	c := &Conf{EnvConf: conf.NewEnvConf()}
+	var err error
+	c.cfg, err = c.decode()
+	return c, err
+}
+
+// Config - typed server settings
+type Config struct {
+	Addr            string        `env:"ATGRAPH_SERVER_ADDR"`
+	ReadTimeout     time.Duration `env:"ATGRAPH_SERVER_READ_TIMEOUT" min:"1ns"`
+	WriteTimeout    time.Duration `env:"ATGRAPH_SERVER_WRITE_TIMEOUT" min:"1ns"`
+	ShutdownTimeout time.Duration `env:"ATGRAPH_SERVER_SHUTDOWN_TIMEOUT" min:"1ns"`
+}
+
+// decode - defaults overridden by whatever conf.Decode can parse
+func (c *Conf) decode() (*Config, error) {
+	cfg := &Config{
+		Addr:            DEFAULT_ADDR,
+		ReadTimeout:     DEFAULT_READ_TIMEOUT,
+		WriteTimeout:    DEFAULT_WRITE_TIMEOUT,
+		ShutdownTimeout: DEFAULT_SHUTDOWN_TIMEOUT,
+	}
+	return cfg, conf.Decode(c.EnvConf, cfg)
+}
+
+// Config - the settings decoded by NewConf
+func (c *Conf) Config() *Config {
+	return c.cfg
+}
+
+// Addr - listen address ie. :8080
+func (c *Conf) Addr() string {
+	return c.cfg.Addr
+}
+
+func (c *Conf) ReadTimeout() time.Duration {
+	return c.cfg.ReadTimeout
+}
+
+func (c *Conf) WriteTimeout() time.Duration {
+	return c.cfg.WriteTimeout
+}
+
+// ShutdownTimeout - how long in-flight requests are given to drain on shutdown
+func (c *Conf) ShutdownTimeout() time.Duration {
+	return c.cfg.ShutdownTimeout
+}
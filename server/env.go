@@ -0,0 +1,16 @@
+package server
+
+import "time"
+
+const (
+	ENV_ATGRAPH_SERVER_ADDR             = "ATGRAPH_SERVER_ADDR"
+	ENV_ATGRAPH_SERVER_READ_TIMEOUT     = "ATGRAPH_SERVER_READ_TIMEOUT"
+	ENV_ATGRAPH_SERVER_WRITE_TIMEOUT    = "ATGRAPH_SERVER_WRITE_TIMEOUT"
+	ENV_ATGRAPH_SERVER_SHUTDOWN_TIMEOUT = "ATGRAPH_SERVER_SHUTDOWN_TIMEOUT"
+
+	// defaults
+	DEFAULT_ADDR             = ":8080"
+	DEFAULT_READ_TIMEOUT     = 10 * time.Second
+	DEFAULT_WRITE_TIMEOUT    = 30 * time.Second
+	DEFAULT_SHUTDOWN_TIMEOUT = 15 * time.Second
+)
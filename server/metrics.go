@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type ServerMetrics struct {
+	requests metric.Int64Counter
+	latency  metric.Float64Histogram
+	inflight metric.Int64UpDownCounter
+}
+
+func NewServerMetrics(ctx context.Context) (*ServerMetrics, error) {
+	buildInfo, ok := debug.ReadBuildInfo()
+	version := "unknown"
+	if ok {
+		version = buildInfo.Main.Version
+	}
+
+	meter := otel.GetMeterProvider().Meter(
+		"atgraph.server",
+		metric.WithInstrumentationVersion(version),
+	)
+
+	requests, err := meter.Int64Counter(
+		"atgraph.server.requests",
+		metric.WithDescription("HTTP requests by route and status code"),
+		metric.WithUnit("{requests}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	latency, err := meter.Float64Histogram(
+		"atgraph.server.latency",
+		metric.WithDescription("HTTP request latency by route"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	inflight, err := meter.Int64UpDownCounter(
+		"atgraph.server.inflight",
+		metric.WithDescription("HTTP requests currently being served"),
+		metric.WithUnit("{requests}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ServerMetrics{
+		requests: requests,
+		latency:  latency,
+		inflight: inflight,
+	}, nil
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/identity"
+	"github.com/mikeblum/atgraph.dev/conf"
+	"github.com/mikeblum/atgraph.dev/graph"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	PATH_HEALTH = "/healthz"
+	PATH_READY  = "/readyz"
+
+	// readiness pings every engine - keep it well under typical probe timeouts
+	READY_TIMEOUT = 2 * time.Second
+)
+
+// Server - XRPC query endpoints over a graph.Reader plus health / readiness probes
+type Server struct {
+	conf   *Conf
+	log    *conf.Log
+	reader graph.Reader
+	pinger graph.Pinger
+	// paths / history - nil unless reader also implements graph.PathFinder / graph.History
+	paths     graph.PathFinder
+	history   graph.History
+	pathConf  *graph.Conf
+	directory identity.Directory
+	metrics   *ServerMetrics
+	mux       *http.ServeMux
+}
+
+func NewServer(ctx context.Context, cfg *Conf, reader graph.Reader, pinger graph.Pinger) (*Server, error) {
+	metrics, err := NewServerMetrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pathConf, err := graph.NewConf()
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		conf:      cfg,
+		log:       conf.NewLog(),
+		reader:    reader,
+		pinger:    pinger,
+		pathConf:  pathConf,
+		directory: identity.DefaultDirectory(),
+		metrics:   metrics,
+		mux:       http.NewServeMux(),
+	}
+	s.paths, _ = reader.(graph.PathFinder)
+	s.history, _ = reader.(graph.History)
+	s.handle(PATH_HEALTH, s.health)
+	s.handle(PATH_READY, s.ready)
+	s.routes()
+	return s, nil
+}
+
+// Handler - every route wrapped with request metrics
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// ListenAndServe - serve until ctx is cancelled then drain in-flight requests
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:         s.conf.Addr(),
+		Handler:      s.Handler(),
+		ReadTimeout:  s.conf.ReadTimeout(),
+		WriteTimeout: s.conf.WriteTimeout(),
+		// requests keep ctx's values but not its cancellation: in-flight
+		// requests drain until Shutdown's timeout rather than failing as
+		// soon as ctx is cancelled
+		BaseContext: func(net.Listener) context.Context {
+			return context.WithoutCancel(ctx)
+		},
+	}
+	errs := make(chan error, 1)
+	go func() {
+		s.log.With("addr", srv.Addr).Info("Serving atgraph XRPC")
+		errs <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.conf.ShutdownTimeout())
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// health - liveness: the process is up and serving
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// ready - readiness: every engine is reachable
+func (s *Server) ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), READY_TIMEOUT)
+	defer cancel()
+	if err := s.pinger.Ping(ctx); err != nil {
+		s.log.WithErrorMsg(err, "Engines not ready")
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "unavailable", "error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
+
+// handle - register a GET route recording request count, latency and in-flight requests
+func (s *Server) handle(route string, handler http.HandlerFunc) {
+	attrs := metric.WithAttributes(attribute.String("route", route))
+	s.mux.HandleFunc("GET "+route, func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		start := time.Now()
+		s.metrics.inflight.Add(ctx, 1, attrs)
+		defer s.metrics.inflight.Add(ctx, -1, attrs)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(rec, r)
+
+		s.metrics.latency.Record(ctx, time.Since(start).Seconds(), attrs)
+		s.metrics.requests.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("route", route),
+			attribute.Int("status", rec.status),
+		))
+	})
+}
+
+// statusRecorder - captures the response status for metrics
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
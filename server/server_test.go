@@ -0,0 +1,309 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/identity"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/mikeblum/atgraph.dev/bsky"
+	"github.com/mikeblum/atgraph.dev/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const didTest = "did:plc:test"
+
+// readerTest - graph.Reader over an in-memory slice of follows
+type readerTest struct {
+	follows []graph.Follow
+	err     error
+}
+
+func (f *readerTest) GetProfile(ctx context.Context, did string) (*graph.Profile, error) {
+	if did != didTest {
+		return nil, graph.ErrNotFound
+	}
+	return &graph.Profile{DID: did, Handle: "test.bsky.social"}, nil
+}
+
+func (f *readerTest) Followers(ctx context.Context, did string, page graph.Page) (*graph.Results[graph.Follow], error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	keys, err := page.Keys(1)
+	if err != nil {
+		return nil, err
+	}
+	var items []graph.Follow
+	for _, follow := range f.follows {
+		if follow.Subject == did && follow.DID > keys[0] {
+			items = append(items, follow)
+		}
+	}
+	return graph.NewResults(items[:min(len(items), page.Size()+1)], page, func(f graph.Follow) []string {
+		return []string{f.DID}
+	}), nil
+}
+
+func (f *readerTest) Following(ctx context.Context, did string, page graph.Page) (*graph.Results[graph.Follow], error) {
+	return graph.NewResults[graph.Follow](nil, page, nil), nil
+}
+
+func (f *readerTest) MutualFollows(ctx context.Context, did string, page graph.Page) (*graph.Results[graph.Follow], error) {
+	return graph.NewResults[graph.Follow](nil, page, nil), nil
+}
+
+func (f *readerTest) Blocks(ctx context.Context, did string, page graph.Page) (*graph.Results[graph.Block], error) {
+	return nil, graph.NotIngested(bsky.ITEM_GRAPH_BLOCK)
+}
+
+// pathReaderTest - readerTest answering shortest paths by BFS over its follows
+type pathReaderTest struct {
+	readerTest
+}
+
+func (f *pathReaderTest) ShortestPaths(ctx context.Context, q graph.PathQuery) ([]graph.Path, error) {
+	return graph.ShortestPathsBFS(ctx, q, func(ctx context.Context, dids []string, reverse bool) (map[string][]string, error) {
+		follows := make(map[[2]string]bool)
+		for _, follow := range f.follows {
+			follows[[2]string{follow.DID, follow.Subject}] = true
+		}
+		adj := make(map[string][]string)
+		for _, follow := range f.follows {
+			if q.Mutual && !follows[[2]string{follow.Subject, follow.DID}] {
+				continue
+			}
+			if reverse && !q.Mutual {
+				adj[follow.Subject] = append(adj[follow.Subject], follow.DID)
+			} else {
+				adj[follow.DID] = append(adj[follow.DID], follow.Subject)
+			}
+		}
+		return adj, nil
+	})
+}
+
+// historyReaderTest - readerTest whose follows carry validity intervals
+type historyReaderTest struct {
+	readerTest
+}
+
+func (f *historyReaderTest) FollowersAsOf(ctx context.Context, did string, at time.Time, page graph.Page) (*graph.Results[graph.Follow], error) {
+	var items []graph.Follow
+	for _, follow := range f.follows {
+		if follow.Subject == did && graph.ValidAt(follow.Created, follow.Removed, at) {
+			items = append(items, follow)
+		}
+	}
+	return graph.NewResults(items, page, func(f graph.Follow) []string {
+		return []string{f.DID, f.RKey}
+	}), nil
+}
+
+func (f *historyReaderTest) SubgraphAsOf(ctx context.Context, dids []string, at time.Time) (*graph.Subgraph, error) {
+	subgraph := &graph.Subgraph{At: at, DIDs: dids, Follows: []graph.Follow{}, Blocks: []graph.Block{}}
+	for _, follow := range f.follows {
+		if slices.Contains(dids, follow.DID) && slices.Contains(dids, follow.Subject) && graph.ValidAt(follow.Created, follow.Removed, at) {
+			subgraph.Follows = append(subgraph.Follows, follow)
+		}
+	}
+	return subgraph, nil
+}
+
+type pingerTest struct {
+	err error
+}
+
+func (p *pingerTest) Ping(ctx context.Context) error {
+	return p.err
+}
+
+func TestServer(t *testing.T) {
+	t.Run("health and readiness", serverProbesTest)
+	t.Run("get profile", serverProfileTest)
+	t.Run("paginate followers", serverPaginateTest)
+	t.Run("xrpc errors", serverErrorsTest)
+	t.Run("get path", serverPathTest)
+	t.Run("time travel", serverHistoryTest)
+}
+
+func serverTest(t *testing.T, reader graph.Reader, pinger graph.Pinger) http.Handler {
+	cfg, err := NewConf()
+	require.Nil(t, err)
+	srv, err := NewServer(context.Background(), cfg, reader, pinger)
+	require.Nil(t, err)
+	return srv.Handler()
+}
+
+func getTest(t *testing.T, handler http.Handler, target string, body any) int {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if body != nil {
+		require.Nil(t, json.Unmarshal(rec.Body.Bytes(), body), rec.Body.String())
+	}
+	return rec.Code
+}
+
+func serverProbesTest(t *testing.T) {
+	pinger := &pingerTest{}
+	handler := serverTest(t, &readerTest{}, pinger)
+	assert.Equal(t, http.StatusOK, getTest(t, handler, PATH_HEALTH, nil))
+	assert.Equal(t, http.StatusOK, getTest(t, handler, PATH_READY, nil))
+
+	pinger.err = errors.New("connection refused")
+	assert.Equal(t, http.StatusOK, getTest(t, handler, PATH_HEALTH, nil))
+	assert.Equal(t, http.StatusServiceUnavailable, getTest(t, handler, PATH_READY, nil))
+}
+
+func serverProfileTest(t *testing.T) {
+	handler := serverTest(t, &readerTest{}, &pingerTest{})
+	var profile graph.Profile
+	require.Equal(t, http.StatusOK, getTest(t, handler, XRPC_PREFIX+NSID_GET_PROFILE+"?actor="+didTest, &profile))
+	assert.Equal(t, didTest, profile.DID)
+	assert.Equal(t, "test.bsky.social", profile.Handle)
+}
+
+func serverPaginateTest(t *testing.T) {
+	reader := &readerTest{follows: []graph.Follow{
+		{DID: "did:plc:a", Subject: didTest},
+		{DID: "did:plc:b", Subject: didTest},
+		{DID: "did:plc:c", Subject: didTest},
+	}}
+	handler := serverTest(t, reader, &pingerTest{})
+
+	type followersTest struct {
+		Actor     string         `json:"actor"`
+		Followers []graph.Follow `json:"followers"`
+		Cursor    string         `json:"cursor"`
+	}
+	var page followersTest
+	require.Equal(t, http.StatusOK, getTest(t, handler, XRPC_PREFIX+NSID_GET_FOLLOWERS+"?actor="+didTest+"&limit=2", &page))
+	assert.Equal(t, didTest, page.Actor)
+	require.Len(t, page.Followers, 2)
+	require.NotEmpty(t, page.Cursor)
+
+	var next followersTest
+	require.Equal(t, http.StatusOK, getTest(t, handler, XRPC_PREFIX+NSID_GET_FOLLOWERS+"?actor="+didTest+"&limit=2&cursor="+page.Cursor, &next))
+	require.Len(t, next.Followers, 1)
+	assert.Equal(t, "did:plc:c", next.Followers[0].DID)
+	assert.Empty(t, next.Cursor)
+}
+
+func serverErrorsTest(t *testing.T) {
+	reader := &readerTest{}
+	handler := serverTest(t, reader, &pingerTest{})
+	type xrpcErrorTest struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	cases := []struct {
+		target string
+		status int
+		name   string
+	}{
+		{XRPC_PREFIX + NSID_GET_FOLLOWERS, http.StatusBadRequest, ERR_INVALID_REQUEST},
+		{XRPC_PREFIX + NSID_GET_FOLLOWERS + "?actor=not-a-did", http.StatusBadRequest, ERR_INVALID_REQUEST},
+		{XRPC_PREFIX + NSID_GET_FOLLOWERS + "?actor=" + didTest + "&limit=1000", http.StatusBadRequest, ERR_INVALID_REQUEST},
+		{XRPC_PREFIX + NSID_GET_FOLLOWERS + "?actor=" + didTest + "&cursor=garbage!", http.StatusBadRequest, ERR_INVALID_REQUEST},
+		{XRPC_PREFIX + NSID_GET_PROFILE + "?actor=did:plc:missing", http.StatusNotFound, ERR_NOT_FOUND},
+		{XRPC_PREFIX + NSID_GET_BLOCKS + "?actor=" + didTest, http.StatusNotImplemented, ERR_NOT_IMPLEMENTED},
+		{XRPC_PREFIX + NSID_GET_PATH + "?source=" + didTest + "&target=did:plc:a", http.StatusNotImplemented, ERR_NOT_IMPLEMENTED},
+		{XRPC_PREFIX + NSID_GET_FOLLOWERS_AT + "?actor=" + didTest + "&at=2024-01-01T00:00:00Z", http.StatusNotImplemented, ERR_NOT_IMPLEMENTED},
+	}
+	for _, c := range cases {
+		var body xrpcErrorTest
+		assert.Equal(t, c.status, getTest(t, handler, c.target, &body), c.target)
+		assert.Equal(t, c.name, body.Error, c.target)
+		assert.NotEmpty(t, body.Message, c.target)
+	}
+
+	// engine errors are masked
+	reader.err = errors.New("clickhouse: connection reset by peer")
+	var body xrpcErrorTest
+	assert.Equal(t, http.StatusInternalServerError, getTest(t, handler, XRPC_PREFIX+NSID_GET_FOLLOWERS+"?actor="+didTest, &body))
+	assert.Equal(t, ERR_INTERNAL_SERVER, body.Error)
+	assert.NotContains(t, body.Message, "clickhouse")
+}
+
+func serverPathTest(t *testing.T) {
+	reader := &pathReaderTest{readerTest{follows: []graph.Follow{
+		{DID: didTest, Subject: "did:plc:a"},
+		{DID: "did:plc:a", Subject: "did:plc:b"},
+	}}}
+	cfg, err := NewConf()
+	require.Nil(t, err)
+	srv, err := NewServer(context.Background(), cfg, reader, &pingerTest{})
+	require.Nil(t, err)
+	dir := identity.NewMockDirectory()
+	dir.Insert(identity.Identity{DID: syntax.DID(didTest), Handle: syntax.Handle("test.bsky.social")})
+	srv.directory = &dir
+	handler := srv.Handler()
+
+	type pathTest struct {
+		Source  string       `json:"source"`
+		Target  string       `json:"target"`
+		Degrees int          `json:"degrees"`
+		Paths   []graph.Path `json:"paths"`
+	}
+	var body pathTest
+	require.Equal(t, http.StatusOK, getTest(t, handler, XRPC_PREFIX+NSID_GET_PATH+"?source=test.bsky.social&target=did:plc:b", &body))
+	assert.Equal(t, didTest, body.Source)
+	assert.Equal(t, 2, body.Degrees)
+	assert.Equal(t, []graph.Path{{didTest, "did:plc:a", "did:plc:b"}}, body.Paths)
+
+	// no mutual follows
+	body = pathTest{}
+	require.Equal(t, http.StatusOK, getTest(t, handler, XRPC_PREFIX+NSID_GET_PATH+"?source="+didTest+"&target=did:plc:b&mutual=true", &body))
+	assert.Empty(t, body.Paths)
+
+	// too shallow
+	body = pathTest{}
+	require.Equal(t, http.StatusOK, getTest(t, handler, XRPC_PREFIX+NSID_GET_PATH+"?source="+didTest+"&target=did:plc:b&maxDepth=1", &body))
+	assert.Empty(t, body.Paths)
+
+	assert.Equal(t, http.StatusNotFound, getTest(t, handler, XRPC_PREFIX+NSID_GET_PATH+"?source=missing.bsky.social&target=did:plc:b", nil))
+	assert.Equal(t, http.StatusBadRequest, getTest(t, handler, XRPC_PREFIX+NSID_GET_PATH+"?source="+didTest, nil))
+	assert.Equal(t, http.StatusBadRequest, getTest(t, handler, XRPC_PREFIX+NSID_GET_PATH+"?source="+didTest+"&target=did:plc:b&maxDepth=100", nil))
+	assert.Equal(t, http.StatusBadRequest, getTest(t, handler, XRPC_PREFIX+NSID_GET_PATH+"?source="+didTest+"&target=did:plc:b&mutual=maybe", nil))
+}
+
+func serverHistoryTest(t *testing.T) {
+	jan := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	jun := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	reader := &historyReaderTest{readerTest{follows: []graph.Follow{
+		{DID: "did:plc:a", Subject: didTest, RKey: "1", Created: jan, Removed: &jun},
+		{DID: "did:plc:b", Subject: didTest, RKey: "2", Created: jun},
+	}}}
+	handler := serverTest(t, reader, &pingerTest{})
+
+	type followersTest struct {
+		Followers []graph.Follow `json:"followers"`
+	}
+	var march followersTest
+	require.Equal(t, http.StatusOK, getTest(t, handler, XRPC_PREFIX+NSID_GET_FOLLOWERS_AT+"?actor="+didTest+"&at=2024-03-01T00:00:00Z", &march))
+	require.Len(t, march.Followers, 1)
+	assert.Equal(t, "did:plc:a", march.Followers[0].DID)
+	assert.NotNil(t, march.Followers[0].Removed)
+
+	var july followersTest
+	require.Equal(t, http.StatusOK, getTest(t, handler, XRPC_PREFIX+NSID_GET_FOLLOWERS_AT+"?actor="+didTest+"&at=2024-07-01T00:00:00Z", &july))
+	require.Len(t, july.Followers, 1)
+	assert.Equal(t, "did:plc:b", july.Followers[0].DID)
+
+	var subgraph graph.Subgraph
+	require.Equal(t, http.StatusOK, getTest(t, handler, XRPC_PREFIX+NSID_GET_SUBGRAPH_AT+"?actors="+didTest+"&actors=did:plc:a&at=2024-03-01T00:00:00Z", &subgraph))
+	assert.Equal(t, []string{didTest, "did:plc:a"}, subgraph.DIDs)
+	assert.Len(t, subgraph.Follows, 1)
+
+	assert.Equal(t, http.StatusBadRequest, getTest(t, handler, XRPC_PREFIX+NSID_GET_FOLLOWERS_AT+"?actor="+didTest, nil))
+	assert.Equal(t, http.StatusBadRequest, getTest(t, handler, XRPC_PREFIX+NSID_GET_FOLLOWERS_AT+"?actor="+didTest+"&at=yesterday", nil))
+	assert.Equal(t, http.StatusBadRequest, getTest(t, handler, XRPC_PREFIX+NSID_GET_SUBGRAPH_AT+"?at=2024-03-01T00:00:00Z", nil))
+	assert.Equal(t, http.StatusBadRequest, getTest(t, handler, XRPC_PREFIX+NSID_GET_SUBGRAPH_AT+"?actors=alice&at=2024-03-01T00:00:00Z", nil))
+}
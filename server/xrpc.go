@@ -0,0 +1,314 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/identity"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/mikeblum/atgraph.dev/bsky"
+	"github.com/mikeblum/atgraph.dev/graph"
+)
+
+// dev.atgraph.* XRPC query lexicons
+const (
+	XRPC_PREFIX = "/xrpc/"
+
+	NSID_GET_PROFILE      = "dev.atgraph.getProfile"
+	NSID_GET_FOLLOWERS    = "dev.atgraph.getFollowers"
+	NSID_GET_FOLLOWS      = "dev.atgraph.getFollows"
+	NSID_GET_MUTUALS      = "dev.atgraph.getMutuals"
+	NSID_GET_BLOCKS       = "dev.atgraph.getBlocks"
+	NSID_GET_PATH         = "dev.atgraph.getPath"
+	NSID_GET_FOLLOWERS_AT = "dev.atgraph.getFollowersAsOf"
+	NSID_GET_SUBGRAPH_AT  = "dev.atgraph.getSubgraphAsOf"
+
+	// XRPC error names
+	ERR_INVALID_REQUEST = "InvalidRequest"
+	ERR_NOT_FOUND       = "NotFound"
+	ERR_NOT_IMPLEMENTED = "MethodNotImplemented"
+	ERR_INTERNAL_SERVER = "InternalServerError"
+
+	// query parameters
+	PARAM_ACTOR  = "actor"
+	PARAM_LIMIT  = "limit"
+	PARAM_CURSOR = "cursor"
+
+	// dev.atgraph.getPath parameters
+	PARAM_SOURCE    = "source"
+	PARAM_TARGET    = "target"
+	PARAM_MUTUAL    = "mutual"
+	PARAM_MAX_DEPTH = "maxDepth"
+
+	// time travel parameters
+	PARAM_AT     = "at"
+	PARAM_ACTORS = "actors"
+)
+
+func (s *Server) routes() {
+	s.handle(XRPC_PREFIX+NSID_GET_PROFILE, s.getProfile)
+	s.handle(XRPC_PREFIX+NSID_GET_FOLLOWERS, listQuery(s, PARAM_ACTOR, "followers", s.reader.Followers))
+	s.handle(XRPC_PREFIX+NSID_GET_FOLLOWS, listQuery(s, PARAM_ACTOR, "follows", s.reader.Following))
+	s.handle(XRPC_PREFIX+NSID_GET_MUTUALS, listQuery(s, PARAM_ACTOR, "mutuals", s.reader.MutualFollows))
+	s.handle(XRPC_PREFIX+NSID_GET_BLOCKS, listQuery(s, PARAM_ACTOR, "blocks", s.reader.Blocks))
+	s.handle(XRPC_PREFIX+NSID_GET_PATH, s.getPath)
+	s.handle(XRPC_PREFIX+NSID_GET_FOLLOWERS_AT, s.getFollowersAsOf)
+	s.handle(XRPC_PREFIX+NSID_GET_SUBGRAPH_AT, s.getSubgraphAsOf)
+}
+
+// getProfile - dev.atgraph.getProfile?actor=<did>
+func (s *Server) getProfile(w http.ResponseWriter, r *http.Request) {
+	did, err := subjectParam(r, PARAM_ACTOR)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	profile, err := s.reader.GetProfile(r.Context(), did)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// getPath - dev.atgraph.getPath?source=<handle|did>&target=<handle|did>&mutual=true&maxDepth=6&limit=10
+// responding with the shortest follow paths between source and target
+func (s *Server) getPath(w http.ResponseWriter, r *http.Request) {
+	if s.paths == nil {
+		s.writeError(w, r, graph.ErrNoPathFinder)
+		return
+	}
+	ctx := r.Context()
+	var source, target syntax.DID
+	var err error
+	if source, err = s.identityParam(ctx, r, PARAM_SOURCE); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if target, err = s.identityParam(ctx, r, PARAM_TARGET); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	var mutual bool
+	if raw := r.URL.Query().Get(PARAM_MUTUAL); raw != "" {
+		if mutual, err = strconv.ParseBool(raw); err != nil {
+			s.writeError(w, r, &invalidRequest{fmt.Sprintf("%s must be a boolean", PARAM_MUTUAL)})
+			return
+		}
+	}
+	var maxDepth, limit int
+	if maxDepth, err = intParam(r, PARAM_MAX_DEPTH, s.pathConf.PathMaxDepth()); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if limit, err = intParam(r, PARAM_LIMIT, s.pathConf.PathLimit()); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	var paths []graph.Path
+	q := s.pathConf.PathQuery(source.String(), target.String(), mutual, maxDepth, limit)
+	if paths, err = s.paths.ShortestPaths(ctx, q); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	body := map[string]any{
+		PARAM_SOURCE: q.From,
+		PARAM_TARGET: q.To,
+		"paths":      paths,
+	}
+	if len(paths) > 0 {
+		body["degrees"] = paths[0].Degrees()
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// getFollowersAsOf - dev.atgraph.getFollowersAsOf?actor=<did>&at=<datetime>&limit=50&cursor=<cursor>
+func (s *Server) getFollowersAsOf(w http.ResponseWriter, r *http.Request) {
+	if s.history == nil {
+		s.writeError(w, r, graph.ErrNoHistory)
+		return
+	}
+	at, err := timeParam(r, PARAM_AT)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	listQuery(s, PARAM_ACTOR, "followers", func(ctx context.Context, did string, page graph.Page) (*graph.Results[graph.Follow], error) {
+		return s.history.FollowersAsOf(ctx, did, at, page)
+	})(w, r)
+}
+
+// getSubgraphAsOf - dev.atgraph.getSubgraphAsOf?actors=<did>&actors=<did>&at=<datetime>
+// responding with the follow and block edges between actors at that time
+func (s *Server) getSubgraphAsOf(w http.ResponseWriter, r *http.Request) {
+	if s.history == nil {
+		s.writeError(w, r, graph.ErrNoHistory)
+		return
+	}
+	at, err := timeParam(r, PARAM_AT)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	actors := r.URL.Query()[PARAM_ACTORS]
+	if len(actors) == 0 || len(actors) > graph.SUBGRAPH_MAX_DIDS {
+		s.writeError(w, r, &invalidRequest{fmt.Sprintf("%s must list between 1 and %d DIDs", PARAM_ACTORS, graph.SUBGRAPH_MAX_DIDS)})
+		return
+	}
+	dids := make([]string, 0, len(actors))
+	for _, actor := range actors {
+		did, err := syntax.ParseDID(actor)
+		if err != nil {
+			s.writeError(w, r, &invalidRequest{fmt.Sprintf("%s must be DIDs: %s", PARAM_ACTORS, err)})
+			return
+		}
+		dids = append(dids, did.String())
+	}
+	subgraph, err := s.history.SubgraphAsOf(r.Context(), dids, at)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, subgraph)
+}
+
+// listQuery - paginated XRPC query ie. dev.atgraph.getFollowers?actor=<did>&limit=50&cursor=<cursor>
+// responding with {"<param>": ..., "<key>": [...], "cursor": ...}
+func listQuery[T any](s *Server, param, key string, query func(context.Context, string, graph.Page) (*graph.Results[T], error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		subject, err := subjectParam(r, param)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		var page graph.Page
+		if page, err = pageParams(r); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		var results *graph.Results[T]
+		if results, err = query(r.Context(), subject, page); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		body := map[string]any{
+			param: subject,
+			key:   results.Items,
+		}
+		if results.Cursor != "" {
+			body[PARAM_CURSOR] = results.Cursor
+		}
+		writeJSON(w, http.StatusOK, body)
+	}
+}
+
+// invalidRequest - a malformed parameter, reported as XRPC InvalidRequest
+type invalidRequest struct {
+	msg string
+}
+
+func (e *invalidRequest) Error() string {
+	return e.msg
+}
+
+// subjectParam - required DID parameter ie. actor
+func subjectParam(r *http.Request, param string) (string, error) {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		return "", &invalidRequest{fmt.Sprintf("missing required parameter: %s", param)}
+	}
+	did, err := syntax.ParseDID(value)
+	if err != nil {
+		return "", &invalidRequest{fmt.Sprintf("%s must be a DID: %s", param, err)}
+	}
+	return did.String(), nil
+}
+
+// identityParam - required handle or DID parameter resolved to a DID
+func (s *Server) identityParam(ctx context.Context, r *http.Request, param string) (syntax.DID, error) {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		return "", &invalidRequest{fmt.Sprintf("missing required parameter: %s", param)}
+	}
+	if _, err := syntax.ParseAtIdentifier(value); err != nil {
+		return "", &invalidRequest{fmt.Sprintf("%s must be a handle or DID: %s", param, err)}
+	}
+	did, err := bsky.ResolveDID(ctx, s.directory, value)
+	if errors.Is(err, identity.ErrHandleNotFound) || errors.Is(err, identity.ErrDIDNotFound) {
+		return "", fmt.Errorf("%w: %s %s", graph.ErrNotFound, param, value)
+	}
+	return did, err
+}
+
+// timeParam - required atproto datetime parameter
+func timeParam(r *http.Request, param string) (time.Time, error) {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		return time.Time{}, &invalidRequest{fmt.Sprintf("missing required parameter: %s", param)}
+	}
+	dt, err := syntax.ParseDatetimeLenient(value)
+	if err != nil {
+		return time.Time{}, &invalidRequest{fmt.Sprintf("%s must be a datetime: %s", param, err)}
+	}
+	return dt.Time().UTC(), nil
+}
+
+// intParam - optional integer parameter in [1, upper]
+func intParam(r *http.Request, param string, upper int) (int, error) {
+	raw := r.URL.Query().Get(param)
+	if raw == "" {
+		return 0, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 || value > upper {
+		return 0, &invalidRequest{fmt.Sprintf("%s must be an integer between 1 and %d", param, upper)}
+	}
+	return value, nil
+}
+
+// pageParams - optional limit in [1, PAGE_LIMIT_MAX] and cursor
+func pageParams(r *http.Request) (graph.Page, error) {
+	page := graph.Page{Cursor: r.URL.Query().Get(PARAM_CURSOR)}
+	if raw := r.URL.Query().Get(PARAM_LIMIT); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > graph.PAGE_LIMIT_MAX {
+			return page, &invalidRequest{fmt.Sprintf("%s must be an integer between 1 and %d", PARAM_LIMIT, graph.PAGE_LIMIT_MAX)}
+		}
+		page.Limit = limit
+	}
+	return page, nil
+}
+
+// writeError - XRPC error body {"error": <name>, "message": <detail>}
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var invalid *invalidRequest
+	status, name := http.StatusInternalServerError, ERR_INTERNAL_SERVER
+	switch {
+	case errors.As(err, &invalid), errors.Is(err, graph.ErrInvalidCursor):
+		status, name = http.StatusBadRequest, ERR_INVALID_REQUEST
+	case errors.Is(err, graph.ErrNotFound):
+		status, name = http.StatusNotFound, ERR_NOT_FOUND
+	case errors.Is(err, graph.ErrNotIngested), errors.Is(err, graph.ErrNoReader),
+		errors.Is(err, graph.ErrNoPathFinder), errors.Is(err, graph.ErrNoHistory):
+		status, name = http.StatusNotImplemented, ERR_NOT_IMPLEMENTED
+	default:
+		s.log.WithErrorMsg(err, "Error serving XRPC query", "path", r.URL.Path)
+	}
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		// don't leak engine internals
+		message = http.StatusText(status)
+	}
+	writeJSON(w, status, map[string]string{"error": name, "message": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
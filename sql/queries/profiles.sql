@@ -1,2 +0,0 @@
--- name: SelectProfiles :many
-SELECT * FROM atgraph.profiles;